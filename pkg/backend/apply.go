@@ -194,8 +194,17 @@ func confirmBeforeUpdating(kind apitype.UpdateKind, stack Stack,
 
 func PreviewThenPromptThenExecute(ctx context.Context, kind apitype.UpdateKind, stack Stack,
 	op UpdateOperation, apply Applier) (engine.ResourceChanges, result.Result) {
-	// Preview the operation to the user and ask them if they want to proceed.
+	// If we've only been asked for a preview, run it without prompting and stream its events to the caller.
+	if op.Opts.PreviewOnly {
+		opts := ApplierOptions{
+			DryRun:   true,
+			ShowLink: true,
+		}
+		_, changes, res := apply(ctx, kind, stack, op, opts, op.Events)
+		return changes, res
+	}
 
+	// Preview the operation to the user and ask them if they want to proceed.
 	if !op.Opts.SkipPreview {
 		// We want to run the preview with the given plan and then run the full update with the initial plan as well,
 		// but because plans are mutated as they're checked we need to clone it here.
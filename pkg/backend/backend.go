@@ -228,6 +228,8 @@ type UpdateOperation struct {
 	SecretsManager     secrets.Manager
 	StackConfiguration StackConfiguration
 	Scopes             CancellationScopeSource
+	// Events, if non-nil, receives the engine events raised by a PreviewOnly operation.
+	Events chan<- engine.Event
 }
 
 // QueryOperation configures a query operation.
@@ -259,6 +261,8 @@ type UpdateOptions struct {
 	SkipPreview bool
 	// Experimental plan support, when true cause plans to be generated.
 	ExperimentalPlans bool
+	// PreviewOnly, when true, causes the operation to stop after its preview without prompting or making changes.
+	PreviewOnly bool
 }
 
 // QueryOptions configures a query to operate against a backend and the engine.
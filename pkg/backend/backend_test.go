@@ -20,9 +20,12 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/pulumi/pulumi/pkg/v3/engine"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
 )
 
 func TestGetStackResourceOutputs(t *testing.T) {
@@ -117,3 +120,23 @@ func liveState(typ, name string, outs resource.PropertyMap) *resource.State {
 		Delete: false, Type: tokens.Type(typ), URN: testURN(typ, name), Outputs: outs,
 	}
 }
+
+func TestPreviewOnlyDoesNotExecute(t *testing.T) {
+	t.Parallel()
+
+	events := make(chan engine.Event, 1)
+	var calls []ApplierOptions
+	apply := func(ctx context.Context, kind apitype.UpdateKind, stack Stack, op UpdateOperation,
+		opts ApplierOptions, ch chan<- engine.Event) (*deploy.Plan, engine.ResourceChanges, result.Result) {
+
+		calls = append(calls, opts)
+		assert.Equal(t, chan<- engine.Event(events), ch)
+		return nil, engine.ResourceChanges{deploy.OpUpdate: 1}, nil
+	}
+
+	changes, res := PreviewThenPromptThenExecute(context.Background(), apitype.RefreshUpdate, &MockStack{},
+		UpdateOperation{Opts: UpdateOptions{PreviewOnly: true}, Events: events}, apply)
+	assert.Nil(t, res)
+	assert.Equal(t, engine.ResourceChanges{deploy.OpUpdate: 1}, changes)
+	assert.Equal(t, []ApplierOptions{{DryRun: true, ShowLink: true}}, calls)
+}
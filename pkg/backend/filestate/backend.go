@@ -321,15 +321,20 @@ func (b *localBackend) CreateStack(ctx context.Context, stackRef backend.StackRe
 
 func (b *localBackend) GetStack(ctx context.Context, stackRef backend.StackReference) (backend.Stack, error) {
 	stackName := stackRef.Name()
-	snapshot, path, err := b.getStack(stackName)
+	if stackName == "" {
+		return nil, errors.New("invalid empty stack name")
+	}
 
+	// Only read the stack's checkpoint here. It is materialized into a snapshot when the snapshot is first requested,
+	// so that operations that don't need the snapshot, such as exporting the stack, don't need its secrets provider.
+	chk, err := b.getCheckpoint(stackName)
 	switch {
 	case gcerrors.Code(err) == gcerrors.NotFound:
 		return nil, nil
 	case err != nil:
-		return nil, err
+		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
 	default:
-		return newStack(stackRef, path, snapshot, b), nil
+		return newStack(stackRef, b.stackPath(stackName), chk, b), nil
 	}
 }
 
@@ -430,6 +435,11 @@ func (b *localBackend) RenameStack(ctx context.Context, stack backend.Stack,
 	file := b.stackPath(stackName)
 	backupTarget(b.bucket, file, false)
 
+	// The old stack's checkpoint journal, if any, was replayed when its state was read above.
+	if err = b.clearJournal(stackName); err != nil {
+		return nil, err
+	}
+
 	// And rename the histoy folder as well.
 	if err = b.renameHistory(stackName, newStackName); err != nil {
 		return nil, err
@@ -578,7 +588,10 @@ func (b *localBackend) apply(
 	}()
 
 	// Create the management machinery.
-	persister := b.newSnapshotPersister(stackName, op.SecretsManager)
+	persister, err := b.newSnapshotPersister(stackName, op.SecretsManager)
+	if err != nil {
+		return nil, nil, result.FromError(err)
+	}
 	manager := backend.NewSnapshotManager(persister, update.GetTarget().Snapshot)
 	engineCtx := &engine.Context{
 		Cancel:          scope.Context(),
@@ -752,19 +765,20 @@ func GetLogsForTarget(target *deploy.Target, query operations.LogQuery) ([]opera
 func (b *localBackend) ExportDeployment(ctx context.Context,
 	stk backend.Stack) (*apitype.UntypedDeployment, error) {
 
+	// Export the checkpoint as it is stored rather than materializing a snapshot from it, so that exporting a stack
+	// does not require access to the secrets provider that encrypted it.
 	stackName := stk.Ref().Name()
-	snap, _, err := b.getStack(stackName)
+	chk, err := b.getCheckpoint(stackName)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
 	}
 
-	if snap == nil {
-		snap = deploy.NewSnapshot(deploy.Manifest{}, nil, nil, nil)
-	}
-
-	sdep, err := stack.SerializeDeployment(snap, snap.SecretsManager /* showSecrsts */, false)
-	if err != nil {
-		return nil, fmt.Errorf("serializing deployment: %w", err)
+	sdep := chk.Latest
+	if sdep == nil {
+		snap := deploy.NewSnapshot(deploy.Manifest{}, nil, nil, nil)
+		if sdep, err = stack.SerializeDeployment(snap, nil /* sm */, false /* showSecrets */); err != nil {
+			return nil, fmt.Errorf("serializing deployment: %w", err)
+		}
 	}
 
 	data, err := json.Marshal(sdep)
@@ -798,8 +812,12 @@ func (b *localBackend) ImportDeployment(ctx context.Context, stk backend.Stack,
 		return err
 	}
 
-	_, err = b.saveStack(stackName, snap, snap.SecretsManager)
-	return err
+	if _, err = b.saveStack(stackName, snap, snap.SecretsManager); err != nil {
+		return err
+	}
+
+	// The imported deployment supersedes any journaled changes to the previous checkpoint.
+	return b.clearJournal(stackName)
 }
 
 func (b *localBackend) Logout() error {
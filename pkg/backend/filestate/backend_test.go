@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	user "github.com/tweekmonster/luser"
@@ -199,6 +200,12 @@ func TestListStacksWithMultiplePassphrases(t *testing.T) {
 		assert.Equal(t, 1, *stack.ResourceCount())
 	}
 
+	// Ensure that we can export the stacks we created even without a passphrase, as exporting does not decrypt them
+	for _, stack := range []backend.Stack{aStack, bStack} {
+		exported, err := b.ExportDeployment(ctx, stack)
+		assert.NoError(t, err)
+		assert.Contains(t, string(exported.Deployment), "ciphertext")
+	}
 }
 
 func TestDrillError(t *testing.T) {
@@ -325,3 +332,79 @@ func TestRemoveMakesBackups(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, backupFileExists)
 }
+
+func TestCheckpointJournalRecovery(t *testing.T) {
+	t.Parallel()
+
+	// Login to a temp dir filestate backend
+	tmpDir := t.TempDir()
+	b, err := New(cmdutil.Diag(), "file://"+filepath.ToSlash(tmpDir))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	lb, ok := b.(*localBackend)
+	assert.True(t, ok)
+
+	aStackRef, err := b.ParseStackReference("a")
+	assert.NoError(t, err)
+	aStack, err := b.CreateStack(ctx, aStackRef, nil)
+	assert.NoError(t, err)
+	name := aStackRef.Name()
+
+	newSnapshot := func(resources ...*resource.State) *deploy.Snapshot {
+		manifest := deploy.Manifest{Time: time.Now(), Version: "v3.0.0"}
+		manifest.Magic = manifest.NewMagic()
+		return deploy.NewSnapshot(manifest, nil, resources, nil)
+	}
+	newResource := func(name string) *resource.State {
+		return &resource.State{
+			Type:    "test:index:resource",
+			URN:     resource.NewURN("a", "proj", "", "test:index:resource", tokens.QName(name)),
+			Custom:  true,
+			ID:      resource.ID(name),
+			Inputs:  resource.PropertyMap{},
+			Outputs: resource.PropertyMap{},
+		}
+	}
+
+	// Write a full checkpoint followed by two journaled saves, as though an update was interrupted before it could
+	// compact its journal.
+	resA, resB := newResource("a"), newResource("b")
+	persister := backend.NewJournalingSnapshotPersister(
+		&localSnapshotPersister{name: name, backend: lb}, &localSnapshotJournal{name: name, backend: lb}, 10)
+	assert.NoError(t, persister.Save(newSnapshot(resA)))
+	assert.NoError(t, persister.Save(newSnapshot(resA, resB)))
+	resA.Outputs["key"] = resource.NewStringProperty("value")
+	persister.Touch(resA)
+	assert.NoError(t, persister.Save(newSnapshot(resA, resB)))
+
+	// The checkpoint file itself only holds the first save, but reading the stack replays the journal.
+	chk, err := stack.UnmarshalVersionedCheckpointToLatestCheckpoint(readFile(t, lb, lb.stackPath(name)))
+	assert.NoError(t, err)
+	assert.Len(t, chk.Latest.Resources, 1)
+
+	snap, _, err := lb.getStack(name)
+	assert.NoError(t, err)
+	if assert.Len(t, snap.Resources, 2) {
+		assert.Equal(t, resA.URN, snap.Resources[0].URN)
+		assert.Equal(t, resource.NewStringProperty("value"), snap.Resources[0].Outputs["key"])
+		assert.Equal(t, resB.URN, snap.Resources[1].URN)
+	}
+
+	// Importing a deployment supersedes the journal.
+	deployment, err := b.ExportDeployment(ctx, aStack)
+	assert.NoError(t, err)
+	assert.NoError(t, b.ImportDeployment(ctx, aStack, deployment))
+	entries, err := lb.readJournal(name)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+	snap, _, err = lb.getStack(name)
+	assert.NoError(t, err)
+	assert.Len(t, snap.Resources, 2)
+}
+
+func readFile(t *testing.T, b *localBackend, file string) []byte {
+	byts, err := b.bucket.ReadAll(context.Background(), file)
+	assert.NoError(t, err)
+	return byts
+}
@@ -15,11 +15,30 @@
 package filestate
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/v3/secrets"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
 )
 
+// CheckpointJournalEnvVar, when truthy, causes updates to append the changes made by each step to a journal rather
+// than rewriting the stack's full checkpoint after every step. The journal is compacted into a full checkpoint
+// periodically and when the update completes.
+const CheckpointJournalEnvVar = "PULUMI_CHECKPOINT_JOURNAL"
+
+// CheckpointJournalCompactionIntervalEnvVar sets the number of journal entries that are written between full
+// checkpoints when CheckpointJournalEnvVar is set.
+const CheckpointJournalCompactionIntervalEnvVar = "PULUMI_CHECKPOINT_JOURNAL_COMPACTION_INTERVAL"
+
 // localSnapshotManager is a simple SnapshotManager implementation that persists snapshots
 // to disk on the local machine.
 type localSnapshotPersister struct {
@@ -38,6 +57,70 @@ func (sp *localSnapshotPersister) Save(snapshot *deploy.Snapshot) error {
 
 }
 
-func (b *localBackend) newSnapshotPersister(stackName tokens.Name, sm secrets.Manager) *localSnapshotPersister {
-	return &localSnapshotPersister{name: stackName, backend: b, sm: sm}
+func (b *localBackend) newSnapshotPersister(
+	stackName tokens.Name, sm secrets.Manager) (backend.SnapshotPersister, error) {
+
+	persister := &localSnapshotPersister{name: stackName, backend: b, sm: sm}
+	if !cmdutil.IsTruthy(os.Getenv(CheckpointJournalEnvVar)) {
+		return persister, nil
+	}
+
+	compactEvery := backend.DefaultJournalCompactionInterval
+	if v := os.Getenv(CheckpointJournalCompactionIntervalEnvVar); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%s must be a positive integer", CheckpointJournalCompactionIntervalEnvVar)
+		}
+		compactEvery = n
+	}
+	journal := &localSnapshotJournal{name: stackName, backend: b}
+	return backend.NewJournalingSnapshotPersister(persister, journal, compactEvery), nil
+}
+
+// localSnapshotJournal stores a stack's checkpoint journal as one file per entry in the backend's bucket. Blob
+// storage does not support appending to an existing object, so appending an entry writes a new file.
+type localSnapshotJournal struct {
+	name    tokens.Name
+	backend *localBackend
+}
+
+func (j *localSnapshotJournal) Append(entry backend.JournalEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	file := path.Join(j.backend.journalDirectory(j.name), fmt.Sprintf("%08d.json", entry.Sequence))
+	return j.backend.bucket.WriteAll(context.TODO(), file, b, nil)
+}
+
+func (j *localSnapshotJournal) Clear() error {
+	return j.backend.clearJournal(j.name)
+}
+
+// readJournal reads the entries of a stack's checkpoint journal, in order.
+func (b *localBackend) readJournal(name tokens.Name) ([]backend.JournalEntry, error) {
+	files, err := listBucket(b.bucket, b.journalDirectory(name))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]backend.JournalEntry, 0, len(files))
+	for _, file := range files {
+		byts, err := b.bucket.ReadAll(context.TODO(), file.Key)
+		if err != nil {
+			return nil, err
+		}
+		var entry backend.JournalEntry
+		if err = json.Unmarshal(byts, &entry); err != nil {
+			return nil, fmt.Errorf("reading checkpoint journal entry %s: %w", objectName(file), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// clearJournal removes all entries from a stack's checkpoint journal.
+func (b *localBackend) clearJournal(name tokens.Name) error {
+	logging.V(9).Infof("clearing checkpoint journal for stack %s", name)
+	return removeAllByPrefix(b.bucket, b.journalDirectory(name))
 }
@@ -16,6 +16,7 @@ package filestate
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
@@ -36,26 +37,44 @@ type Stack interface {
 
 // localStack is a local stack descriptor.
 type localStack struct {
-	ref      backend.StackReference // the stack's reference (qualified name).
-	path     string                 // a path to the stack's checkpoint file on disk.
-	snapshot *deploy.Snapshot       // a snapshot representing the latest deployment state.
-	b        *localBackend          // a pointer to the backend this stack belongs to.
+	ref  backend.StackReference // the stack's reference (qualified name).
+	path string                 // a path to the stack's checkpoint file on disk.
+	b    *localBackend          // a pointer to the backend this stack belongs to.
+
+	// The stack's checkpoint is materialized into a snapshot only when the snapshot is first requested, as doing so
+	// requires access to the stack's secrets provider.
+	snapshotLock sync.Mutex
+	checkpoint   *apitype.CheckpointV3 // the checkpoint to materialize the snapshot from, if not yet materialized.
+	snapshot     *deploy.Snapshot      // a snapshot representing the latest deployment state.
 }
 
-func newStack(ref backend.StackReference, path string, snapshot *deploy.Snapshot, b *localBackend) Stack {
+func newStack(ref backend.StackReference, path string, chk *apitype.CheckpointV3, b *localBackend) Stack {
 	return &localStack{
-		ref:      ref,
-		path:     path,
-		snapshot: snapshot,
-		b:        b,
+		ref:        ref,
+		path:       path,
+		checkpoint: chk,
+		b:          b,
 	}
 }
 
-func (s *localStack) Ref() backend.StackReference                            { return s.ref }
-func (s *localStack) Snapshot(ctx context.Context) (*deploy.Snapshot, error) { return s.snapshot, nil }
-func (s *localStack) Backend() backend.Backend                               { return s.b }
-func (s *localStack) Path() string                                           { return s.path }
-func (s *localStack) Tags() map[apitype.StackTagName]string                  { return nil }
+func (s *localStack) Ref() backend.StackReference           { return s.ref }
+func (s *localStack) Backend() backend.Backend              { return s.b }
+func (s *localStack) Path() string                          { return s.path }
+func (s *localStack) Tags() map[apitype.StackTagName]string { return nil }
+
+func (s *localStack) Snapshot(ctx context.Context) (*deploy.Snapshot, error) {
+	s.snapshotLock.Lock()
+	defer s.snapshotLock.Unlock()
+
+	if s.checkpoint != nil {
+		snapshot, err := materializeSnapshot(s.path, s.checkpoint)
+		if err != nil {
+			return nil, err
+		}
+		s.checkpoint, s.snapshot = nil, snapshot
+	}
+	return s.snapshot, nil
+}
 
 func (s *localStack) Remove(ctx context.Context, force bool) (bool, error) {
 	return backend.RemoveStack(ctx, s, force)
@@ -136,20 +136,29 @@ func (b *localBackend) getStack(name tokens.Name) (*deploy.Snapshot, string, err
 		return nil, file, fmt.Errorf("failed to load checkpoint: %w", err)
 	}
 
-	// Materialize an actual snapshot object.
+	snapshot, err := materializeSnapshot(file, chk)
+	if err != nil {
+		return nil, file, err
+	}
+	return snapshot, file, nil
+}
+
+// materializeSnapshot deserializes the snapshot of a checkpoint that was read from the given file, decrypting its
+// secrets, and verifies its integrity.
+func materializeSnapshot(file string, chk *apitype.CheckpointV3) (*deploy.Snapshot, error) {
 	snapshot, err := stack.DeserializeCheckpoint(chk)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
 	// Ensure the snapshot passes verification before returning it, to catch bugs early.
 	if !DisableIntegrityChecking {
 		if verifyerr := snapshot.VerifyIntegrity(); verifyerr != nil {
-			return nil, file, fmt.Errorf("%s: snapshot integrity failure; refusing to use it: %w", file, verifyerr)
+			return nil, fmt.Errorf("%s: snapshot integrity failure; refusing to use it: %w", file, verifyerr)
 		}
 	}
 
-	return snapshot, file, nil
+	return snapshot, nil
 }
 
 // GetCheckpoint loads a checkpoint file for the given stack in this project, from the current project workspace.
@@ -160,7 +169,24 @@ func (b *localBackend) getCheckpoint(stackName tokens.Name) (*apitype.Checkpoint
 		return nil, err
 	}
 
-	return stack.UnmarshalVersionedCheckpointToLatestCheckpoint(bytes)
+	chk, err := stack.UnmarshalVersionedCheckpointToLatestCheckpoint(bytes)
+	if err != nil || chk.Latest == nil {
+		return chk, err
+	}
+
+	// If an update was interrupted before it could compact its checkpoint journal, recover the changes it made
+	// since its last full checkpoint by replaying the journal.
+	entries, err := b.readJournal(stackName)
+	if err != nil {
+		return nil, fmt.Errorf("reading checkpoint journal: %w", err)
+	}
+	if len(entries) != 0 {
+		logging.V(5).Infof("replaying %d checkpoint journal entries for stack %s", len(entries), stackName)
+		if chk.Latest, err = backend.ReplaySnapshotJournal(chk.Latest, entries); err != nil {
+			return nil, fmt.Errorf("replaying checkpoint journal: %w", err)
+		}
+	}
+	return chk, nil
 }
 
 func (b *localBackend) saveStack(name tokens.Name, snap *deploy.Snapshot, sm secrets.Manager) (string, error) {
@@ -254,6 +280,10 @@ func (b *localBackend) removeStack(name tokens.Name) error {
 	file := b.stackPath(name)
 	backupTarget(b.bucket, file, false)
 
+	if err := b.clearJournal(name); err != nil {
+		return err
+	}
+
 	historyDir := b.historyDirectory(name)
 	return removeAllByPrefix(b.bucket, historyDir)
 }
@@ -320,6 +350,11 @@ func (b *localBackend) historyDirectory(stack tokens.Name) string {
 	return filepath.Join(b.StateDir(), workspace.HistoryDir, fsutil.NamePath(stack))
 }
 
+func (b *localBackend) journalDirectory(stack tokens.Name) string {
+	contract.Require(stack != "", "stack")
+	return filepath.Join(b.StateDir(), workspace.JournalDir, fsutil.NamePath(stack))
+}
+
 func (b *localBackend) backupDirectory(stack tokens.Name) string {
 	contract.Require(stack != "", "stack")
 	return filepath.Join(b.StateDir(), workspace.BackupDir, fsutil.NamePath(stack))
@@ -23,6 +23,7 @@ import (
 
 	"github.com/pulumi/pulumi/pkg/v3/engine"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
 	"github.com/pulumi/pulumi/pkg/v3/secrets"
 	"github.com/pulumi/pulumi/pkg/v3/version"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
@@ -66,11 +67,22 @@ type SnapshotManager struct {
 	mutationRequests chan<- mutationRequest   // The queue of mutation requests, to be retired serially by the manager
 	cancel           chan bool                // A channel used to request cancellation of any new mutation requests.
 	done             <-chan error             // A channel that sends a single result when the manager has shut down.
+
+	// The refreshed states of resources in the base snapshot, keyed by their base states.
+	refreshed map[*resource.State]*resource.State
+
+	// The persister, if it journals its snapshots. A journaling persister is told which resources each mutation may
+	// have changed in place.
+	journaler JournalingSnapshotPersister
+	// True if a refresh found a resource to be deleted. Once the refresh completes, the engine repairs the dependency
+	// lists of the resources that depended on it in place.
+	refreshDeleted bool
 }
 
 var _ engine.SnapshotManager = (*SnapshotManager)(nil)
 
 type mutationRequest struct {
+	step    deploy.Step
 	mutator func() bool
 	result  chan<- error
 }
@@ -97,10 +109,10 @@ func (sm *SnapshotManager) Close() error {
 //
 // You should never observe or mutate the global snapshot without using this function unless
 // you have a very good justification.
-func (sm *SnapshotManager) mutate(mutator func() bool) error {
+func (sm *SnapshotManager) mutate(step deploy.Step, mutator func() bool) error {
 	result := make(chan error)
 	select {
-	case sm.mutationRequests <- mutationRequest{mutator: mutator, result: result}:
+	case sm.mutationRequests <- mutationRequest{step: step, mutator: mutator, result: result}:
 		return <-result
 	case <-sm.cancel:
 		return errors.New("snapshot manager closed")
@@ -119,7 +131,7 @@ func (sm *SnapshotManager) mutate(mutator func() bool) error {
 // Note that this is completely not thread-safe and defeats the purpose of having a `mutate` callback
 // entirely, but the hope is that this state of things will not be permament.
 func (sm *SnapshotManager) RegisterResourceOutputs(step deploy.Step) error {
-	return sm.mutate(func() bool { return true })
+	return sm.mutate(step, func() bool { return true })
 }
 
 // BeginMutation signals to the SnapshotManager that the engine intends to mutate the global snapshot
@@ -210,6 +222,12 @@ func (ssm *sameSnapshotMutation) mustWrite(step *deploy.SameStep) bool {
 		return true
 	}
 
+	// We need to persist the changes if DeletedWith has changed
+	if old.DeletedWith != new.DeletedWith {
+		logging.V(9).Infof("SnapshotManager: mustWrite() true because of DeletedWith")
+		return true
+	}
+
 	contract.Assert(old.ID == new.ID)
 
 	// If this resource's provider has changed, we must write the checkpoint. This can happen in scenarios involving
@@ -270,7 +288,7 @@ func (ssm *sameSnapshotMutation) End(step deploy.Step, successful bool) error {
 	contract.Require(step.Op() == deploy.OpSame, "step.Op() == deploy.OpSame")
 	contract.Assert(successful)
 	logging.V(9).Infof("SnapshotManager: sameSnapshotMutation.End(..., %v)", successful)
-	return ssm.manager.mutate(func() bool {
+	return ssm.manager.mutate(step, func() bool {
 		sameStep := step.(*deploy.SameStep)
 
 		ssm.manager.markDone(step.Old())
@@ -302,7 +320,7 @@ func (ssm *sameSnapshotMutation) End(step deploy.Step, successful bool) error {
 
 func (sm *SnapshotManager) doCreate(step deploy.Step) (engine.SnapshotMutation, error) {
 	logging.V(9).Infof("SnapshotManager.doCreate(%s)", step.URN())
-	err := sm.mutate(func() bool {
+	err := sm.mutate(step, func() bool {
 		sm.markOperationPending(step.New(), resource.OperationTypeCreating)
 		return true
 	})
@@ -320,7 +338,7 @@ type createSnapshotMutation struct {
 func (csm *createSnapshotMutation) End(step deploy.Step, successful bool) error {
 	contract.Require(step != nil, "step != nil")
 	logging.V(9).Infof("SnapshotManager: createSnapshotMutation.End(..., %v)", successful)
-	return csm.manager.mutate(func() bool {
+	return csm.manager.mutate(step, func() bool {
 		csm.manager.markOperationComplete(step.New())
 		if successful {
 			// There is some very subtle behind-the-scenes magic here that
@@ -346,7 +364,7 @@ func (csm *createSnapshotMutation) End(step deploy.Step, successful bool) error
 
 func (sm *SnapshotManager) doUpdate(step deploy.Step) (engine.SnapshotMutation, error) {
 	logging.V(9).Infof("SnapshotManager.doUpdate(%s)", step.URN())
-	err := sm.mutate(func() bool {
+	err := sm.mutate(step, func() bool {
 		sm.markOperationPending(step.New(), resource.OperationTypeUpdating)
 		return true
 	})
@@ -364,7 +382,7 @@ type updateSnapshotMutation struct {
 func (usm *updateSnapshotMutation) End(step deploy.Step, successful bool) error {
 	contract.Require(step != nil, "step != nil")
 	logging.V(9).Infof("SnapshotManager: updateSnapshotMutation.End(..., %v)", successful)
-	return usm.manager.mutate(func() bool {
+	return usm.manager.mutate(step, func() bool {
 		usm.manager.markOperationComplete(step.New())
 		if successful {
 			usm.manager.markDone(step.Old())
@@ -376,7 +394,7 @@ func (usm *updateSnapshotMutation) End(step deploy.Step, successful bool) error
 
 func (sm *SnapshotManager) doDelete(step deploy.Step) (engine.SnapshotMutation, error) {
 	logging.V(9).Infof("SnapshotManager.doDelete(%s)", step.URN())
-	err := sm.mutate(func() bool {
+	err := sm.mutate(step, func() bool {
 		sm.markOperationPending(step.Old(), resource.OperationTypeDeleting)
 		return true
 	})
@@ -394,7 +412,7 @@ type deleteSnapshotMutation struct {
 func (dsm *deleteSnapshotMutation) End(step deploy.Step, successful bool) error {
 	contract.Require(step != nil, "step != nil")
 	logging.V(9).Infof("SnapshotManager: deleteSnapshotMutation.End(..., %v)", successful)
-	return dsm.manager.mutate(func() bool {
+	return dsm.manager.mutate(step, func() bool {
 		dsm.manager.markOperationComplete(step.Old())
 		if successful {
 			// Either old should not be protected or this is a replace
@@ -422,7 +440,7 @@ func (rsm *replaceSnapshotMutation) End(step deploy.Step, successful bool) error
 
 func (sm *SnapshotManager) doRead(step deploy.Step) (engine.SnapshotMutation, error) {
 	logging.V(9).Infof("SnapshotManager.doRead(%s)", step.URN())
-	err := sm.mutate(func() bool {
+	err := sm.mutate(step, func() bool {
 		sm.markOperationPending(step.New(), resource.OperationTypeReading)
 		return true
 	})
@@ -440,7 +458,7 @@ type readSnapshotMutation struct {
 func (rsm *readSnapshotMutation) End(step deploy.Step, successful bool) error {
 	contract.Require(step != nil, "step != nil")
 	logging.V(9).Infof("SnapshotManager: readSnapshotMutation.End(..., %v)", successful)
-	return rsm.manager.mutate(func() bool {
+	return rsm.manager.mutate(step, func() bool {
 		rsm.manager.markOperationComplete(step.New())
 		if successful {
 			if step.Old() != nil {
@@ -461,12 +479,21 @@ func (rsm *refreshSnapshotMutation) End(step deploy.Step, successful bool) error
 	contract.Require(step != nil, "step != nil")
 	contract.Require(step.Op() == deploy.OpRefresh, "step.Op() == deploy.OpRefresh")
 	logging.V(9).Infof("SnapshotManager: refreshSnapshotMutation.End(..., %v)", successful)
-	return rsm.manager.mutate(func() bool {
-		// We always elide refreshes. The expectation is that all of these run before any actual mutations and that
-		// some other component will rewrite the base snapshot in-memory, so there's no action the snapshot
-		// manager needs to take other than to remember that the base snapshot--and therefore the actual snapshot--may
-		// have changed.
-		return false
+	return rsm.manager.mutate(step, func() bool {
+		// Refreshes run before any actual mutations, and once they have all completed some other component rewrites
+		// the base snapshot in-memory. Until then, stream each successfully refreshed state into the snapshot in place
+		// of its base state so that the results of a long refresh are persisted as they arrive.
+		//
+		// Resources that a refresh found to be deleted remain in the snapshot until the base snapshot is rewritten, as
+		// removing them requires repairing the dependency lists of the resources that depend on them.
+		if successful && step.New() == nil {
+			rsm.manager.refreshDeleted = true
+		}
+		if !successful || step.New() == nil {
+			return false
+		}
+		rsm.manager.refreshed[step.Old()] = step.New()
+		return true
 	})
 }
 
@@ -477,7 +504,7 @@ type removePendingReplaceSnapshotMutation struct {
 func (rsm *removePendingReplaceSnapshotMutation) End(step deploy.Step, successful bool) error {
 	contract.Require(step != nil, "step != nil")
 	contract.Require(step.Op() == deploy.OpRemovePendingReplace, "step.Op() == deploy.OpRemovePendingReplace")
-	return rsm.manager.mutate(func() bool {
+	return rsm.manager.mutate(step, func() bool {
 		res := step.Old()
 		contract.Assert(res.PendingReplacement)
 		rsm.manager.markDone(res)
@@ -487,7 +514,7 @@ func (rsm *removePendingReplaceSnapshotMutation) End(step deploy.Step, successfu
 
 func (sm *SnapshotManager) doImport(step deploy.Step) (engine.SnapshotMutation, error) {
 	logging.V(9).Infof("SnapshotManager.doImport(%s)", step.URN())
-	err := sm.mutate(func() bool {
+	err := sm.mutate(step, func() bool {
 		sm.markOperationPending(step.New(), resource.OperationTypeImporting)
 		return true
 	})
@@ -507,7 +534,7 @@ func (ism *importSnapshotMutation) End(step deploy.Step, successful bool) error
 	contract.Require(step.Op() == deploy.OpImport || step.Op() == deploy.OpImportReplacement,
 		"step.Op() == deploy.OpImport || step.Op() == deploy.OpImportReplacement")
 
-	return ism.manager.mutate(func() bool {
+	return ism.manager.mutate(step, func() bool {
 		ism.manager.markOperationComplete(step.New())
 		if successful {
 			ism.manager.markNew(step.New())
@@ -516,6 +543,64 @@ func (ism *importSnapshotMutation) End(step deploy.Step, successful bool) error
 	})
 }
 
+// touch tells a journaling persister that the resources of the given step may have been mutated in place. The engine
+// only mutates the states of the resources that it is operating on, so these are the only resources that may have
+// changed since the last save.
+func (sm *SnapshotManager) touch(step deploy.Step) {
+	if sm.journaler == nil {
+		return
+	}
+
+	// The first mutation after a refresh that deleted resources may follow the repair of any resource's dependencies.
+	if sm.refreshDeleted && step.Op() != deploy.OpRefresh {
+		sm.journaler.TouchAll()
+		sm.refreshDeleted = false
+	}
+
+	// An import that replaces an existing resource marks the original for deletion, but doesn't expose it.
+	if step.Op() == deploy.OpImportReplacement {
+		sm.journaler.TouchAll()
+		return
+	}
+	for _, res := range []*resource.State{step.Old(), step.New()} {
+		if res != nil {
+			sm.journaler.Touch(res)
+		}
+	}
+}
+
+// touchAliasReferences tells a journaling persister about the resources whose references to other resources
+// NormalizeURNReferences is about to rewrite in place.
+func (sm *SnapshotManager) touchAliasReferences(snap *deploy.Snapshot) {
+	aliased := make(map[resource.URN]bool)
+	for _, res := range snap.Resources {
+		for _, alias := range res.Aliases {
+			aliased[alias] = true
+		}
+	}
+	if len(aliased) == 0 {
+		return
+	}
+
+	for _, res := range snap.Resources {
+		refs := append([]resource.URN{res.Parent}, res.Dependencies...)
+		for _, deps := range res.PropertyDependencies {
+			refs = append(refs, deps...)
+		}
+		if res.Provider != "" {
+			ref, err := providers.ParseReference(res.Provider)
+			contract.AssertNoError(err)
+			refs = append(refs, ref.URN())
+		}
+		for _, ref := range refs {
+			if aliased[ref] {
+				sm.journaler.Touch(res)
+				break
+			}
+		}
+	}
+}
+
 // markDone marks a resource as having been processed. Resources that have been marked
 // in this manner won't be persisted in the snapshot.
 func (sm *SnapshotManager) markDone(state *resource.State) {
@@ -587,6 +672,9 @@ func (sm *SnapshotManager) snap() *deploy.Snapshot {
 	if base := sm.baseSnapshot; base != nil {
 		for _, res := range base.Resources {
 			if !sm.dones[res] {
+				if refreshed, ok := sm.refreshed[res]; ok {
+					res = refreshed
+				}
 				resources = append(resources, res)
 			}
 		}
@@ -624,6 +712,9 @@ func (sm *SnapshotManager) snap() *deploy.Snapshot {
 // saveSnapshot persists the current snapshot and optionally verifies it afterwards.
 func (sm *SnapshotManager) saveSnapshot() error {
 	snap := sm.snap()
+	if sm.journaler != nil {
+		sm.touchAliasReferences(snap)
+	}
 	if err := snap.NormalizeURNReferences(); err != nil {
 		return fmt.Errorf("failed to normalize URN references: %w", err)
 	}
@@ -638,6 +729,18 @@ func (sm *SnapshotManager) saveSnapshot() error {
 	return nil
 }
 
+// compactSnapshot writes the current snapshot as a full checkpoint using the given journaling persister.
+func (sm *SnapshotManager) compactSnapshot(journaler JournalingSnapshotPersister) error {
+	snap := sm.snap()
+	if err := snap.NormalizeURNReferences(); err != nil {
+		return fmt.Errorf("failed to normalize URN references: %w", err)
+	}
+	if err := journaler.Compact(snap); err != nil {
+		return fmt.Errorf("failed to compact snapshot journal: %w", err)
+	}
+	return nil
+}
+
 // NewSnapshotManager creates a new SnapshotManager for the given stack name, using the given persister
 // and base snapshot.
 //
@@ -652,12 +755,17 @@ func NewSnapshotManager(persister SnapshotPersister, baseSnap *deploy.Snapshot)
 		baseSnapshot:     baseSnap,
 		dones:            make(map[*resource.State]bool),
 		completeOps:      make(map[*resource.State]bool),
+		refreshed:        make(map[*resource.State]*resource.State),
 		doVerify:         true,
 		mutationRequests: mutationRequests,
 		cancel:           cancel,
 		done:             done,
 	}
 
+	if journaler, ok := persister.(JournalingSnapshotPersister); ok {
+		manager.journaler = journaler
+	}
+
 	go func() {
 		// True if we have elided writes since the last actual write.
 		hasElidedWrites := false
@@ -668,6 +776,7 @@ func NewSnapshotManager(persister SnapshotPersister, baseSnap *deploy.Snapshot)
 			select {
 			case request := <-mutationRequests:
 				var err error
+				manager.touch(request.step)
 				if request.mutator() {
 					err = manager.saveSnapshot()
 					hasElidedWrites = false
@@ -680,12 +789,25 @@ func NewSnapshotManager(persister SnapshotPersister, baseSnap *deploy.Snapshot)
 			}
 		}
 
-		// If we still have elided writes once the channel has closed, flush the snapshot.
+		// If we still have elided writes once the channel has closed, flush the snapshot. If any resources were
+		// refreshed, the base snapshot has since been rewritten in-memory, so flush the snapshot in that case, too.
+		//
+		// The engine may also have rewritten the dependencies of resources in place after the last mutation, e.g. after
+		// a targeted destroy, so a journaling persister must compare every resource when flushing.
 		var err error
-		if hasElidedWrites {
+		if hasElidedWrites || len(manager.refreshed) != 0 {
 			logging.V(9).Infof("SnapshotManager: flushing elided writes...")
+			if manager.journaler != nil {
+				manager.journaler.TouchAll()
+			}
 			err = manager.saveSnapshot()
 		}
+
+		// If the persister journals its snapshots, compact the journal into a final checkpoint.
+		if manager.journaler != nil && err == nil {
+			logging.V(9).Infof("SnapshotManager: compacting snapshot journal...")
+			err = manager.compactSnapshot(manager.journaler)
+		}
 		done <- err
 	}()
 
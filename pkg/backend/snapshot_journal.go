@@ -0,0 +1,306 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+// DefaultJournalCompactionInterval is the default number of journal entries that a journaling snapshot persister
+// writes before compacting them into a full checkpoint.
+const DefaultJournalCompactionInterval = 100
+
+// JournalEntry records the state of a snapshot relative to the full checkpoint that began its journal. Rather than
+// carrying every resource, an entry carries only those resources that are new or have changed since the previous
+// entry, and refers to the rest by key.
+//
+// The resources of the journal's base checkpoint are keyed by their index in that checkpoint. Resources created
+// after the checkpoint was written are assigned increasing keys starting from the number of resources in the base.
+type JournalEntry struct {
+	// Sequence is the position of this entry in its journal, starting at 1.
+	Sequence int `json:"sequence"`
+	// Base is the manifest time of the checkpoint that this entry's journal applies to.
+	Base time.Time `json:"base"`
+	// Resources lists the keys of the snapshot's resources, in order.
+	Resources []int `json:"resources"`
+	// Changed holds the serialized state of each resource that is new or has changed since the previous entry.
+	Changed map[int]apitype.ResourceV3 `json:"changed,omitempty"`
+	// PendingOperations holds all of the snapshot's pending operations.
+	PendingOperations []apitype.OperationV2 `json:"pendingOperations,omitempty"`
+}
+
+// SnapshotJournal is the storage for the journal written by a journaling snapshot persister.
+type SnapshotJournal interface {
+	// Append durably appends an entry to the journal.
+	Append(entry JournalEntry) error
+	// Clear removes all entries from the journal.
+	Clear() error
+}
+
+// JournalingSnapshotPersister is a SnapshotPersister that appends a journal entry per save rather than rewriting the
+// full checkpoint. Entries are compacted into a full checkpoint periodically and when the operation completes.
+type JournalingSnapshotPersister interface {
+	SnapshotPersister
+
+	// Compact writes the given snapshot as a full checkpoint and clears the journal, if any entries have been
+	// written since the last compaction.
+	Compact(snapshot *deploy.Snapshot) error
+
+	// Touch records that the given resources may have been mutated in place since the last save. The next save only
+	// compares these resources and those that are new to the journal with their journaled contents.
+	Touch(resources ...*resource.State)
+	// TouchAll records that any resource may have been mutated in place since the last save.
+	TouchAll()
+}
+
+// NewJournalingSnapshotPersister returns a persister that journals the snapshots it is asked to save. The first save
+// and every compactEvery'th save thereafter write a full checkpoint using the given persister and then clear the
+// journal; all other saves append only the resources that have changed to the journal.
+func NewJournalingSnapshotPersister(
+	persister SnapshotPersister, journal SnapshotJournal, compactEvery int) JournalingSnapshotPersister {
+
+	if compactEvery <= 0 {
+		compactEvery = DefaultJournalCompactionInterval
+	}
+	return &journalingSnapshotPersister{
+		persister:    persister,
+		journal:      journal,
+		compactEvery: compactEvery,
+	}
+}
+
+type journalingSnapshotPersister struct {
+	persister    SnapshotPersister
+	journal      SnapshotJournal
+	compactEvery int
+
+	compacted bool                         // true once a full checkpoint has been written
+	base      time.Time                    // the manifest time of the last full checkpoint
+	entries   int                          // the number of entries written since the last full checkpoint
+	keys      map[*resource.State]int      // the journal key of each resource seen since the last full checkpoint
+	digests   map[*resource.State][32]byte // the digest of each resource as of the last save
+	nextKey   int                          // the next unused journal key
+
+	touched    map[*resource.State]bool // the resources that may have been mutated since the last save
+	touchedAll bool                     // true if any resource may have been mutated since the last save
+}
+
+var _ JournalingSnapshotPersister = (*journalingSnapshotPersister)(nil)
+
+func (p *journalingSnapshotPersister) SecretsManager() secrets.Manager {
+	return p.persister.SecretsManager()
+}
+
+func (p *journalingSnapshotPersister) Touch(resources ...*resource.State) {
+	if p.touched == nil {
+		p.touched = make(map[*resource.State]bool, len(resources))
+	}
+	for _, res := range resources {
+		p.touched[res] = true
+	}
+}
+
+func (p *journalingSnapshotPersister) TouchAll() {
+	p.touchedAll = true
+}
+
+func (p *journalingSnapshotPersister) Save(snapshot *deploy.Snapshot) error {
+	if !p.compacted || p.entries >= p.compactEvery {
+		return p.compact(snapshot)
+	}
+
+	enc, err := p.encrypter()
+	if err != nil {
+		return err
+	}
+
+	entry := JournalEntry{
+		Sequence:  p.entries + 1,
+		Base:      p.base,
+		Resources: make([]int, len(snapshot.Resources)),
+		Changed:   map[int]apitype.ResourceV3{},
+	}
+	keys, nextKey := map[*resource.State]int{}, p.nextKey
+	digests := map[*resource.State][32]byte{}
+	for i, res := range snapshot.Resources {
+		key, ok := p.keys[res]
+		if !ok {
+			key, nextKey = nextKey, nextKey+1
+			keys[res] = key
+		}
+		entry.Resources[i] = key
+
+		// The engine mutates resource states in place, so compare the current contents of each resource that may
+		// have been mutated with those last journaled rather than relying on the identity of the state object.
+		// Resources that haven't been touched since the last save are known to be unchanged.
+		if ok && !p.touchedAll && !p.touched[res] {
+			continue
+		}
+		digest, err := digestResource(res)
+		if err != nil {
+			return err
+		}
+		if ok && digest == p.digests[res] {
+			continue
+		}
+		sres, err := stack.SerializeResource(res, enc, false /* showSecrets */)
+		if err != nil {
+			return fmt.Errorf("serializing resources: %w", err)
+		}
+		entry.Changed[key], digests[res] = sres, digest
+	}
+	for _, op := range snapshot.PendingOperations {
+		sop, err := stack.SerializeOperation(op, enc, false /* showSecrets */)
+		if err != nil {
+			return err
+		}
+		entry.PendingOperations = append(entry.PendingOperations, sop)
+	}
+
+	if err := p.journal.Append(entry); err != nil {
+		return fmt.Errorf("appending to snapshot journal: %w", err)
+	}
+	for res, key := range keys {
+		p.keys[res] = key
+	}
+	for res, digest := range digests {
+		p.digests[res] = digest
+	}
+	p.entries, p.nextKey = p.entries+1, nextKey
+	p.touched, p.touchedAll = nil, false
+	logging.V(9).Infof("SnapshotManager: journaled %d changed resources in entry %d",
+		len(entry.Changed), entry.Sequence)
+	return nil
+}
+
+func (p *journalingSnapshotPersister) Compact(snapshot *deploy.Snapshot) error {
+	if p.entries == 0 {
+		return nil
+	}
+	return p.compact(snapshot)
+}
+
+// compact writes a full checkpoint, clears the journal, and resets the journal keys to the checkpoint's resources.
+func (p *journalingSnapshotPersister) compact(snapshot *deploy.Snapshot) error {
+	if err := p.persister.Save(snapshot); err != nil {
+		return err
+	}
+	if err := p.journal.Clear(); err != nil {
+		return fmt.Errorf("clearing snapshot journal: %w", err)
+	}
+
+	p.compacted, p.base, p.entries = true, snapshot.Manifest.Time, 0
+	p.keys = make(map[*resource.State]int, len(snapshot.Resources))
+	p.digests = make(map[*resource.State][32]byte, len(snapshot.Resources))
+	for i, res := range snapshot.Resources {
+		digest, err := digestResource(res)
+		if err != nil {
+			return err
+		}
+		p.keys[res], p.digests[res] = i, digest
+	}
+	p.nextKey = len(snapshot.Resources)
+	p.touched, p.touchedAll = nil, false
+	logging.V(9).Infof("SnapshotManager: compacted snapshot journal")
+	return nil
+}
+
+func (p *journalingSnapshotPersister) encrypter() (config.Encrypter, error) {
+	sm := p.persister.SecretsManager()
+	if sm == nil {
+		return config.NewPanicCrypter(), nil
+	}
+	enc, err := sm.Encrypter()
+	if err != nil {
+		return nil, fmt.Errorf("getting encrypter for deployment: %w", err)
+	}
+	return enc, nil
+}
+
+// digestResource returns a digest of a resource's contents. Secrets are digested in plaintext, as their ciphertext
+// changes each time they are encrypted.
+func digestResource(res *resource.State) ([32]byte, error) {
+	sres, err := stack.SerializeResource(res, config.NopEncrypter, true /* showSecrets */)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("serializing resources: %w", err)
+	}
+	b, err := json.Marshal(sres)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(b), nil
+}
+
+// ReplaySnapshotJournal applies the entries of a snapshot journal to the deployment they were journaled against and
+// returns the resulting deployment. Entries that belong to a different checkpoint, such as those left behind if an
+// operation was interrupted while compacting its journal, are ignored. If no entries apply, the deployment is
+// returned unchanged.
+func ReplaySnapshotJournal(deployment *apitype.DeploymentV3, entries []JournalEntry) (*apitype.DeploymentV3, error) {
+	contract.Require(deployment != nil, "deployment")
+
+	resources := make(map[int]apitype.ResourceV3, len(deployment.Resources))
+	for i, res := range deployment.Resources {
+		resources[i] = res
+	}
+
+	var last *JournalEntry
+	for i := range entries {
+		entry := &entries[i]
+		if !entry.Base.Equal(deployment.Manifest.Time) {
+			logging.V(7).Infof("ignoring snapshot journal entry %d for a different checkpoint", entry.Sequence)
+			continue
+		}
+
+		expected := 1
+		if last != nil {
+			expected = last.Sequence + 1
+		}
+		if entry.Sequence != expected {
+			return nil, fmt.Errorf("snapshot journal is missing entry %d", expected)
+		}
+
+		for key, res := range entry.Changed {
+			resources[key] = res
+		}
+		last = entry
+	}
+	if last == nil {
+		return deployment, nil
+	}
+
+	replayed := *deployment
+	replayed.Resources = make([]apitype.ResourceV3, len(last.Resources))
+	for i, key := range last.Resources {
+		res, ok := resources[key]
+		if !ok {
+			return nil, fmt.Errorf("snapshot journal entry %d refers to unknown resource %d", last.Sequence, key)
+		}
+		replayed.Resources[i] = res
+	}
+	replayed.PendingOperations = last.PendingOperations
+	return &replayed, nil
+}
@@ -0,0 +1,226 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/b64"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// checkpointPersister serializes each snapshot as it is saved, as the engine mutates resource states in place.
+type checkpointPersister struct {
+	t     *testing.T
+	saved []*apitype.DeploymentV3
+}
+
+func (p *checkpointPersister) Save(snap *deploy.Snapshot) error {
+	dep, err := stack.SerializeDeployment(snap, p.SecretsManager(), false /* showSecrets */)
+	require.NoError(p.t, err)
+	p.saved = append(p.saved, dep)
+	return nil
+}
+
+func (p *checkpointPersister) SecretsManager() secrets.Manager {
+	return b64.NewBase64SecretsManager()
+}
+
+type memoryJournal struct {
+	entries []JournalEntry
+}
+
+func (j *memoryJournal) Append(entry JournalEntry) error {
+	j.entries = append(j.entries, entry)
+	return nil
+}
+
+func (j *memoryJournal) Clear() error {
+	j.entries = nil
+	return nil
+}
+
+func TestJournalingSnapshotPersister(t *testing.T) {
+	t.Parallel()
+
+	resourceA := NewResource("a")
+	resourceB := NewResource("b")
+	snap := NewSnapshot([]*resource.State{resourceA, resourceB})
+
+	journal := &memoryJournal{}
+	checkpoints := &checkpointPersister{t: t}
+	persister := NewJournalingSnapshotPersister(checkpoints, journal, 100)
+	manager := NewSnapshotManager(persister, snap)
+
+	// Recovery must produce the same resources and pending operations as a full checkpoint of the last save.
+	var expected *apitype.DeploymentV3
+	assertRecoverable := func() {
+		require.NotEmpty(t, checkpoints.saved)
+		actual, err := ReplaySnapshotJournal(checkpoints.saved[len(checkpoints.saved)-1], journal.entries)
+		require.NoError(t, err)
+		assert.Equal(t, expected.Resources, actual.Resources)
+		assert.Equal(t, expected.PendingOperations, actual.PendingOperations)
+	}
+	save := func() {
+		dep, err := stack.SerializeDeployment(manager.snap(), b64.NewBase64SecretsManager(), false)
+		require.NoError(t, err)
+		expected = dep
+	}
+
+	// The first save writes a full checkpoint.
+	resourceC := NewResource("c")
+	createC := deploy.NewCreateStep(nil, &MockRegisterResourceEvent{}, resourceC)
+	mutation, err := manager.BeginMutation(createC)
+	require.NoError(t, err)
+	assert.Len(t, checkpoints.saved, 1)
+	assert.Empty(t, journal.entries)
+
+	// Subsequent saves are journaled, and only carry the resources that changed.
+	require.NoError(t, mutation.End(createC, true /* successful */))
+	save()
+	require.Len(t, journal.entries, 1)
+	assert.Len(t, journal.entries[0].Changed, 1)
+	assertRecoverable()
+
+	resourceC.Outputs["out"] = resource.MakeSecret(resource.NewStringProperty("shh"))
+	require.NoError(t, manager.RegisterResourceOutputs(createC))
+	save()
+	require.Len(t, journal.entries, 2)
+	assert.Len(t, journal.entries[1].Changed, 1)
+	assertRecoverable()
+
+	deleteB := deploy.NewDeleteStep(nil, map[resource.URN]bool{}, resourceB)
+	mutation, err = manager.BeginMutation(deleteB)
+	require.NoError(t, err)
+	save()
+	require.Len(t, journal.entries, 3)
+	assert.Empty(t, journal.entries[2].Changed)
+	assert.Len(t, journal.entries[2].PendingOperations, 1)
+	assertRecoverable()
+
+	require.NoError(t, mutation.End(deleteB, true /* successful */))
+	save()
+	assertRecoverable()
+
+	// Closing the manager compacts the journal into a final checkpoint.
+	require.NoError(t, manager.Close())
+	assert.Empty(t, journal.entries)
+	require.Len(t, checkpoints.saved, 2)
+	assert.Equal(t, expected.Resources, checkpoints.saved[1].Resources)
+}
+
+func TestJournalingSnapshotPersisterCompactsPeriodically(t *testing.T) {
+	t.Parallel()
+
+	journal := &memoryJournal{}
+	checkpoints := &checkpointPersister{t: t}
+	persister := NewJournalingSnapshotPersister(checkpoints, journal, 2)
+
+	resourceA := NewResource("a")
+	snap := NewSnapshot([]*resource.State{resourceA})
+	for i := 0; i < 7; i++ {
+		resourceA.Outputs["i"] = resource.NewNumberProperty(float64(i))
+		require.NoError(t, persister.Save(snap))
+	}
+
+	// Saves 1, 4 and 7 are compactions; the remainder are journaled.
+	assert.Len(t, checkpoints.saved, 3)
+	assert.Empty(t, journal.entries)
+
+	// Compacting with nothing journaled is a no-op.
+	require.NoError(t, persister.Compact(snap))
+	assert.Len(t, checkpoints.saved, 3)
+}
+
+func TestJournalingSnapshotPersisterTouch(t *testing.T) {
+	t.Parallel()
+
+	journal := &memoryJournal{}
+	persister := NewJournalingSnapshotPersister(&checkpointPersister{t: t}, journal, 100)
+
+	resourceA, resourceB := NewResource("a"), NewResource("b")
+	snap := NewSnapshot([]*resource.State{resourceA, resourceB})
+	require.NoError(t, persister.Save(snap))
+
+	// Only the resources that have been touched since the last save are compared with their journaled contents.
+	resourceA.Outputs["out"] = resource.NewStringProperty("a")
+	resourceB.Outputs["out"] = resource.NewStringProperty("b")
+	persister.Touch(resourceA)
+	require.NoError(t, persister.Save(snap))
+	require.Len(t, journal.entries, 1)
+	assert.Len(t, journal.entries[0].Changed, 1)
+	assert.Contains(t, journal.entries[0].Changed, 0)
+
+	// A touched resource that hasn't changed isn't journaled again.
+	persister.Touch(resourceA)
+	require.NoError(t, persister.Save(snap))
+	require.Len(t, journal.entries, 2)
+	assert.Empty(t, journal.entries[1].Changed)
+
+	// Touching every resource picks up the change to the other resource.
+	persister.TouchAll()
+	require.NoError(t, persister.Save(snap))
+	require.Len(t, journal.entries, 3)
+	assert.Len(t, journal.entries[2].Changed, 1)
+	assert.Contains(t, journal.entries[2].Changed, 1)
+
+	// New resources are always journaled.
+	resourceC := NewResource("c")
+	require.NoError(t, persister.Save(NewSnapshot([]*resource.State{resourceA, resourceB, resourceC})))
+	require.Len(t, journal.entries, 4)
+	assert.Len(t, journal.entries[3].Changed, 1)
+	assert.Contains(t, journal.entries[3].Changed, 2)
+}
+
+func TestReplaySnapshotJournal(t *testing.T) {
+	t.Parallel()
+
+	base := time.Now()
+	deployment := &apitype.DeploymentV3{
+		Manifest: apitype.ManifestV1{Time: base},
+		Resources: []apitype.ResourceV3{
+			{URN: "a"},
+			{URN: "b"},
+		},
+	}
+
+	// Entries from another checkpoint are ignored.
+	stale := []JournalEntry{{Sequence: 1, Base: base.Add(-time.Hour), Resources: []int{}}}
+	replayed, err := ReplaySnapshotJournal(deployment, stale)
+	require.NoError(t, err)
+	assert.Equal(t, deployment, replayed)
+
+	entries := []JournalEntry{
+		{Sequence: 1, Base: base, Resources: []int{0, 1, 2}, Changed: map[int]apitype.ResourceV3{2: {URN: "c"}}},
+		{Sequence: 2, Base: base, Resources: []int{2, 1}, Changed: map[int]apitype.ResourceV3{1: {URN: "b", ID: "id"}},
+			PendingOperations: []apitype.OperationV2{{Type: apitype.OperationTypeDeleting}}},
+	}
+	replayed, err = ReplaySnapshotJournal(deployment, entries)
+	require.NoError(t, err)
+	assert.Equal(t, []apitype.ResourceV3{{URN: "c"}, {URN: "b", ID: "id"}}, replayed.Resources)
+	assert.Len(t, replayed.PendingOperations, 1)
+	assert.Len(t, deployment.Resources, 2)
+
+	_, err = ReplaySnapshotJournal(deployment, entries[1:])
+	assert.EqualError(t, err, "snapshot journal is missing entry 1")
+}
@@ -541,7 +541,7 @@ func TestDeletion(t *testing.T) {
 	})
 
 	manager, sp := MockSetup(t, snap)
-	step := deploy.NewDeleteStep(nil, resourceA)
+	step := deploy.NewDeleteStep(nil, map[resource.URN]bool{}, resourceA)
 	mutation, err := manager.BeginMutation(step)
 	if !assert.NoError(t, err) {
 		t.FailNow()
@@ -567,7 +567,7 @@ func TestFailedDelete(t *testing.T) {
 	})
 
 	manager, sp := MockSetup(t, snap)
-	step := deploy.NewDeleteStep(nil, resourceA)
+	step := deploy.NewDeleteStep(nil, map[resource.URN]bool{}, resourceA)
 	mutation, err := manager.BeginMutation(step)
 	if !assert.NoError(t, err) {
 		t.FailNow()
@@ -740,7 +740,7 @@ func TestRecordingDeleteSuccess(t *testing.T) {
 		resourceA,
 	})
 	manager, sp := MockSetup(t, snap)
-	step := deploy.NewDeleteStep(nil, resourceA)
+	step := deploy.NewDeleteStep(nil, map[resource.URN]bool{}, resourceA)
 	mutation, err := manager.BeginMutation(step)
 	if !assert.NoError(t, err) {
 		t.FailNow()
@@ -772,7 +772,7 @@ func TestRecordingDeleteFailure(t *testing.T) {
 		resourceA,
 	})
 	manager, sp := MockSetup(t, snap)
-	step := deploy.NewDeleteStep(nil, resourceA)
+	step := deploy.NewDeleteStep(nil, map[resource.URN]bool{}, resourceA)
 	mutation, err := manager.BeginMutation(step)
 	if !assert.NoError(t, err) {
 		t.FailNow()
@@ -984,3 +984,61 @@ func TestRegisterOutputs(t *testing.T) {
 	assert.Len(t, lastSnap.Resources, 1)
 	assert.Equal(t, resourceA.URN, lastSnap.Resources[0].URN)
 }
+
+// refreshedStep is a refresh step whose resource has already been refreshed to the given state.
+type refreshedStep struct {
+	deploy.Step
+	new *resource.State
+}
+
+func (s *refreshedStep) New() *resource.State { return s.new }
+
+func TestRecordingRefreshes(t *testing.T) {
+	t.Parallel()
+
+	resourceA := NewResource("a")
+	resourceB := NewResource("b", resourceA.URN)
+	snap := NewSnapshot([]*resource.State{
+		resourceA,
+		resourceB,
+	})
+	manager, sp := MockSetup(t, snap)
+
+	// A successful refresh is written to the snapshot as soon as it completes, in place of the base state.
+	resourceARefreshed := NewResource("a")
+	resourceARefreshed.Outputs["key"] = resource.NewStringProperty("refreshed")
+	step := &refreshedStep{Step: deploy.NewRefreshStep(nil, resourceA, nil), new: resourceARefreshed}
+	mutation, err := manager.BeginMutation(step)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	err = mutation.End(step, true /* successful */)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Len(t, sp.SavedSnapshots, 1)
+	assert.Equal(t, []*resource.State{resourceARefreshed, resourceB}, sp.LastSnap().Resources)
+
+	// Failed refreshes and refreshes that found their resource to be deleted are not written.
+	for _, successful := range []bool{false, true} {
+		step = &refreshedStep{Step: deploy.NewRefreshStep(nil, resourceB, nil)}
+		mutation, err = manager.BeginMutation(step)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		err = mutation.End(step, successful)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+	assert.Len(t, sp.SavedSnapshots, 1)
+
+	// Once the base snapshot has been rewritten, closing the manager flushes it.
+	snap.Resources = []*resource.State{resourceARefreshed}
+	err = manager.Close()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Len(t, sp.SavedSnapshots, 2)
+	assert.Equal(t, []*resource.State{resourceARefreshed}, sp.LastSnap().Resources)
+}
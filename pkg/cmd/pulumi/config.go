@@ -84,6 +84,8 @@ func newConfigCmd() *cobra.Command {
 	cmd.AddCommand(newConfigSetAllCmd(&stack))
 	cmd.AddCommand(newConfigRefreshCmd(&stack))
 	cmd.AddCommand(newConfigCopyCmd(&stack))
+	cmd.AddCommand(newConfigExportCmd(&stack))
+	cmd.AddCommand(newConfigImportCmd(&stack))
 
 	return cmd
 }
@@ -895,7 +897,12 @@ const (
 // that suffers from false positives, but is better (a) than our prior approach of unconditionally printing a warning
 // for all plaintext values, and (b)  to be paranoid about such things. Inspired by the gas linter and securego project.
 func looksLikeSecret(k config.Key, v string) bool {
-	if !keyPattern.MatchString(k.Name()) {
+	return looksLikeSecretProperty(k.Name(), v)
+}
+
+// looksLikeSecretProperty returns true if a value with the given name "looks" like a secret. See looksLikeSecret.
+func looksLikeSecretProperty(name, v string) bool {
+	if !keyPattern.MatchString(name) || v == "" {
 		return false
 	}
 
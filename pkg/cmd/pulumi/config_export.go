@@ -0,0 +1,594 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// The file formats supported by `pulumi config export` and `pulumi config import`.
+const (
+	configFormatJSON   = "json"
+	configFormatDotenv = "dotenv"
+	configFormatYAML   = "yaml"
+)
+
+func newConfigExportCmd(stack *string) *cobra.Command {
+	var format string
+	var showSecrets bool
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export configuration values",
+		Long: "Export configuration values.\n\n" +
+			"Writes the stack's configuration to standard out in one of the following formats:\n\n" +
+			"  - `json` and `yaml` write a document that maps each fully-qualified configuration key to its\n" +
+			"    value. Structured values are written as nested objects and lists.\n" +
+			"  - `dotenv` writes one `key=value` line per value. Structured values are flattened into one\n" +
+			"    line per leaf value, keyed by its path (e.g. `proj:outer.inner[0]=value`).\n\n" +
+			"Secret values are only exported if `--show-secrets` is passed; otherwise keys that hold secrets are\n" +
+			"omitted and a warning is printed. The output can be read back with `pulumi config import`.",
+		Args: cmdutil.NoArgs,
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+
+			if err := validateConfigFormat(format); err != nil {
+				return err
+			}
+
+			s, err := requireStack(*stack, true, opts, true /*setCurrent*/)
+			if err != nil {
+				return err
+			}
+
+			ps, err := loadProjectStack(s)
+			if err != nil {
+				return err
+			}
+
+			var decrypter config.Decrypter = config.NewPanicCrypter()
+			if ps.Config.HasSecureValue() && showSecrets {
+				if decrypter, err = getStackDecrypter(s); err != nil {
+					return err
+				}
+			}
+
+			omitted, err := exportConfig(os.Stdout, ps.Config, format, decrypter, showSecrets)
+			if err != nil {
+				return err
+			}
+			for _, key := range omitted {
+				cmdutil.Diag().Warningf(diag.RawMessage("" /*urn*/, fmt.Sprintf(
+					"omitting '%s' because it holds a secret value; pass --show-secrets to export it\n", key)))
+			}
+
+			if showSecrets {
+				log3rdPartySecretsProviderDecryptionEvent(commandContext(), s, "", "pulumi config export")
+			}
+			return nil
+		}),
+	}
+
+	exportCmd.PersistentFlags().StringVarP(
+		&format, "format", "f", configFormatJSON,
+		"The format to export configuration in: json, dotenv, or yaml")
+	exportCmd.PersistentFlags().BoolVar(
+		&showSecrets, "show-secrets", false,
+		"Export secret values in plaintext instead of omitting them")
+
+	return exportCmd
+}
+
+func newConfigImportCmd(stack *string) *cobra.Command {
+	var format string
+	var secretKeys []string
+
+	importCmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import configuration values from a file",
+		Long: "Import configuration values from a file.\n\n" +
+			"Reads configuration values from a JSON, YAML, or dotenv file in the formats written by\n" +
+			"`pulumi config export` and sets them on the stack. The format is inferred from the file's\n" +
+			"extension (`.json`, `.yaml`, `.yml`, or `.env`) unless `--format` is given. Pass `-` to read\n" +
+			"from standard in, in which case `--format` is required.\n\n" +
+			"Keys with no namespace are treated as belonging to the current project. Nested objects and lists\n" +
+			"are set as structured configuration, keeping the types of their JSON and YAML values, and each\n" +
+			"top-level key in the file replaces any existing value of that key. Dotenv values are strings, so\n" +
+			"as with `pulumi config set --path`, nested dotenv values are converted to booleans and integers.\n\n" +
+			"Values are imported as plaintext unless their key matches one of the regular expressions given\n" +
+			"with `--secret-keys`, in which case they are encrypted. Keys are matched without their namespace,\n" +
+			"and nested values are matched by their path:\n\n" +
+			"  - `pulumi config import --secret-keys 'password|token' settings.json` will encrypt\n" +
+			"    `dbPassword` and `github.token`.",
+		Args: cmdutil.ExactArgs(1),
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+
+			file := args[0]
+			if format == "" {
+				format = inferConfigFormat(file)
+				if format == "" {
+					return fmt.Errorf("could not infer the format of '%s'; pass --format", file)
+				}
+			}
+			if err := validateConfigFormat(format); err != nil {
+				return err
+			}
+
+			patterns := make([]*regexp.Regexp, len(secretKeys))
+			for i, pattern := range secretKeys {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return fmt.Errorf("invalid --secret-keys pattern '%s': %w", pattern, err)
+				}
+				patterns[i] = re
+			}
+
+			var contents []byte
+			var err error
+			if file == "-" {
+				contents, err = ioutil.ReadAll(os.Stdin)
+			} else {
+				contents, err = ioutil.ReadFile(file)
+			}
+			if err != nil {
+				return err
+			}
+
+			proj, err := workspace.DetectProject()
+			if err != nil {
+				return err
+			}
+			values, err := parseConfigFile(contents, format, proj.Name)
+			if err != nil {
+				return fmt.Errorf("reading '%s': %w", file, err)
+			}
+
+			s, err := requireStack(*stack, true, opts, false /*setCurrent*/)
+			if err != nil {
+				return err
+			}
+
+			ps, err := loadProjectStack(s)
+			if err != nil {
+				return err
+			}
+
+			// Only create an encrypter if a value is secret, as doing so may prompt for a passphrase.
+			var encrypter config.Encrypter
+			getEncrypter := func() (config.Encrypter, error) {
+				if encrypter == nil {
+					enc, err := getStackEncrypter(s)
+					if err != nil {
+						return nil, err
+					}
+					encrypter = enc
+				}
+				return encrypter, nil
+			}
+
+			if err = importConfig(ps.Config, values, patterns, getEncrypter); err != nil {
+				return err
+			}
+
+			return saveProjectStack(s, ps)
+		}),
+	}
+
+	importCmd.PersistentFlags().StringVarP(
+		&format, "format", "f", "",
+		"The format of the file: json, dotenv, or yaml. Inferred from the file's extension if not set")
+	importCmd.PersistentFlags().StringArrayVar(
+		&secretKeys, "secret-keys", []string{},
+		"A regular expression matching the keys of values that should be encrypted. May be repeated")
+
+	return importCmd
+}
+
+func validateConfigFormat(format string) error {
+	switch format {
+	case configFormatJSON, configFormatDotenv, configFormatYAML:
+		return nil
+	default:
+		return fmt.Errorf("unsupported format '%s'; expected one of json, dotenv, or yaml", format)
+	}
+}
+
+// inferConfigFormat returns the configuration format implied by a file's name, or "" if there is none.
+func inferConfigFormat(file string) string {
+	switch ext := filepath.Ext(file); {
+	case ext == ".json":
+		return configFormatJSON
+	case ext == ".yaml" || ext == ".yml":
+		return configFormatYAML
+	case ext == ".env" || strings.HasPrefix(filepath.Base(file), ".env"):
+		return configFormatDotenv
+	default:
+		return ""
+	}
+}
+
+// exportConfig writes cfg to w in the given format. Secret values are only written if showSecrets is true; otherwise
+// the keys that hold them are returned so that the caller can report their omission.
+func exportConfig(w io.Writer, cfg config.Map, format string, decrypter config.Decrypter,
+	showSecrets bool) ([]config.Key, error) {
+
+	var keys config.KeyArray
+	for key := range cfg {
+		keys = append(keys, key)
+	}
+	sort.Sort(keys)
+
+	var omitted []config.Key
+	values := make(map[string]interface{})
+	var leaves []configLeaf
+	for _, key := range keys {
+		v := cfg[key]
+		if v.Secure() && !showSecrets {
+			omitted = append(omitted, key)
+			continue
+		}
+
+		raw, err := v.Value(decrypter)
+		if err != nil {
+			return nil, fmt.Errorf("could not decrypt configuration value: %w", err)
+		}
+
+		var value interface{} = raw
+		if v.Object() {
+			if value, err = config.NewObjectValue(raw).ToObject(); err != nil {
+				return nil, err
+			}
+		}
+		values[key.String()] = value
+		leaves = appendConfigLeaves(leaves, key, resource.PropertyPath{key.Name()}, value)
+	}
+
+	var b []byte
+	var err error
+	switch format {
+	case configFormatJSON:
+		b, err = json.MarshalIndent(values, "", "  ")
+		b = append(b, '\n')
+	case configFormatYAML:
+		b, err = yaml.Marshal(values)
+	case configFormatDotenv:
+		b = marshalDotenv(leaves)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = w.Write(b)
+	return omitted, err
+}
+
+// configLeaf is a single scalar configuration value, along with its path within the value of its configuration key.
+type configLeaf struct {
+	// Key is the configuration key that holds the value.
+	Key config.Key
+	// Path is the path of the value within the configuration key, beginning with the key's name.
+	Path resource.PropertyPath
+	// Value is the string representation of the value.
+	Value string
+}
+
+// appendConfigLeaves appends each scalar within v to leaves.
+func appendConfigLeaves(leaves []configLeaf, key config.Key, path resource.PropertyPath,
+	v interface{}) []configLeaf {
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		names := make([]string, 0, len(t))
+		for name := range t {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			leaves = appendConfigLeaves(leaves, key, append(append(resource.PropertyPath{}, path...), name), t[name])
+		}
+		return leaves
+	case []interface{}:
+		for i, e := range t {
+			leaves = appendConfigLeaves(leaves, key, append(append(resource.PropertyPath{}, path...), i), e)
+		}
+		return leaves
+	default:
+		return append(leaves, configLeaf{Key: key, Path: path, Value: configScalarString(t)})
+	}
+}
+
+// configLeafKey returns the path-style configuration key of a leaf, e.g. `proj:outer.inner[0]`.
+func configLeafKey(leaf configLeaf) string {
+	return fmt.Sprintf("%s:%s", leaf.Key.Namespace(), leaf.Path)
+}
+
+func marshalDotenv(leaves []configLeaf) []byte {
+	var buf bytes.Buffer
+	for _, leaf := range leaves {
+		fmt.Fprintf(&buf, "%s=%s\n", configLeafKey(leaf), strconv.Quote(leaf.Value))
+	}
+	return buf.Bytes()
+}
+
+// parseConfigFile parses the contents of a configuration file in the given format into the value of each
+// configuration key it sets. Keys with no namespace are treated as belonging to project.
+//
+// JSON and YAML values keep their types. Dotenv values are strings, so, as with `pulumi config set --path`, the values
+// within structured keys are converted to booleans and integers where possible.
+func parseConfigFile(contents []byte, format string, project tokens.PackageName) (map[config.Key]interface{}, error) {
+	parseKey := func(key string) (config.Key, error) {
+		if !strings.Contains(key, tokens.TokenDelimiter) {
+			key = fmt.Sprintf("%s:%s", project, key)
+		}
+		k, err := config.ParseKey(key)
+		if err != nil {
+			return config.Key{}, fmt.Errorf("invalid configuration key: %w", err)
+		}
+		return k, nil
+	}
+
+	if format == configFormatDotenv {
+		pairs, err := parseDotenv(contents)
+		if err != nil {
+			return nil, err
+		}
+
+		// Build up the structured values in a configuration map of their own. Nothing is secret yet.
+		cfg := config.Map{}
+		for _, pair := range pairs {
+			k, err := parseKey(pair[0])
+			if err != nil {
+				return nil, err
+			}
+			if err = cfg.Set(k, config.NewValue(pair[1]), true /*path*/); err != nil {
+				return nil, fmt.Errorf("invalid configuration key path '%s': %w", pair[0], err)
+			}
+		}
+
+		values := make(map[config.Key]interface{}, len(cfg))
+		for k, v := range cfg {
+			if !v.Object() {
+				if values[k], err = v.Value(config.NopDecrypter); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			obj, err := v.ToObject()
+			if err != nil {
+				return nil, err
+			}
+			values[k] = obj
+		}
+		return values, nil
+	}
+
+	var raw map[string]interface{}
+	var err error
+	if format == configFormatJSON {
+		err = json.Unmarshal(contents, &raw)
+	} else {
+		err = yaml.Unmarshal(contents, &raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[config.Key]interface{}, len(raw))
+	for name, v := range raw {
+		k, err := parseKey(name)
+		if err != nil {
+			return nil, err
+		}
+		values[k] = v
+	}
+	return values, nil
+}
+
+// parseDotenv parses the `key=value` pairs in a dotenv file. Blank lines, comments, and `export` prefixes are
+// ignored. Values may be double-quoted, in which case Go-style escapes are interpreted, or single-quoted, in which
+// case they are taken literally.
+func parseDotenv(contents []byte) ([][2]string, error) {
+	var pairs [][2]string
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		eq := strings.Index(line, "=")
+		if eq <= 0 {
+			return nil, fmt.Errorf("line %d: expected a line of the form key=value", lineno)
+		}
+		key, value := strings.TrimSpace(line[:eq]), strings.TrimSpace(line[eq+1:])
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid quoted value: %w", lineno, err)
+			}
+			value = unquoted
+		case strings.HasPrefix(value, "'"):
+			if len(value) < 2 || !strings.HasSuffix(value, "'") {
+				return nil, fmt.Errorf("line %d: unterminated quoted value", lineno)
+			}
+			value = value[1 : len(value)-1]
+		default:
+			if comment := strings.Index(value, " #"); comment != -1 {
+				value = strings.TrimSpace(value[:comment])
+			}
+		}
+
+		pairs = append(pairs, [2]string{key, value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return pairs, nil
+}
+
+// importConfig sets each of the given values in cfg, replacing the existing value of each configuration key that
+// appears in values. Scalar values whose paths match any of secretKeys are encrypted using the encrypter returned by
+// getEncrypter.
+func importConfig(cfg config.Map, values map[config.Key]interface{}, secretKeys []*regexp.Regexp,
+	getEncrypter func() (config.Encrypter, error)) error {
+
+	var keys config.KeyArray
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Sort(keys)
+
+	for _, key := range keys {
+		secure := false
+		encrypt := func(path resource.PropertyPath, v string) (string, bool, error) {
+			if !configPathMatches(path, secretKeys) {
+				return "", false, nil
+			}
+			enc, err := getEncrypter()
+			if err != nil {
+				return "", false, err
+			}
+			ciphertext, err := enc.EncryptValue(v)
+			if err != nil {
+				return "", false, fmt.Errorf("encrypting '%s:%s': %w", key.Namespace(), path, err)
+			}
+			secure = true
+			return ciphertext, true, nil
+		}
+
+		value := values[key]
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			obj, err := encryptConfigLeaves(resource.PropertyPath{key.Name()}, value, encrypt)
+			if err != nil {
+				return err
+			}
+			b, err := json.Marshal(obj)
+			if err != nil {
+				return err
+			}
+			if secure {
+				cfg[key] = config.NewSecureObjectValue(string(b))
+			} else {
+				cfg[key] = config.NewObjectValue(string(b))
+			}
+		default:
+			v := configScalarString(value)
+			ciphertext, isSecret, err := encrypt(resource.PropertyPath{key.Name()}, v)
+			if err != nil {
+				return err
+			}
+			if isSecret {
+				cfg[key] = config.NewSecureValue(ciphertext)
+			} else {
+				cfg[key] = config.NewValue(v)
+			}
+		}
+	}
+	return nil
+}
+
+// encryptConfigLeaves returns a copy of the structured value v in which each scalar that encrypt chooses to encrypt is
+// replaced by a secure value. Every other value keeps its type.
+func encryptConfigLeaves(path resource.PropertyPath, v interface{},
+	encrypt func(path resource.PropertyPath, v string) (string, bool, error)) (interface{}, error) {
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		obj := make(map[string]interface{}, len(t))
+		for name, e := range t {
+			ev, err := encryptConfigLeaves(append(append(resource.PropertyPath{}, path...), name), e, encrypt)
+			if err != nil {
+				return nil, err
+			}
+			obj[name] = ev
+		}
+		return obj, nil
+	case []interface{}:
+		arr := make([]interface{}, len(t))
+		for i, e := range t {
+			ev, err := encryptConfigLeaves(append(append(resource.PropertyPath{}, path...), i), e, encrypt)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = ev
+		}
+		return arr, nil
+	default:
+		ciphertext, isSecret, err := encrypt(path, configScalarString(v))
+		if err != nil {
+			return nil, err
+		}
+		if isSecret {
+			return map[string]interface{}{"secure": ciphertext}, nil
+		}
+		return v, nil
+	}
+}
+
+// configScalarString returns the string form of a scalar configuration value.
+func configScalarString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		// Avoid writing large numbers, such as integers decoded from JSON, in exponent form.
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func configPathMatches(path resource.PropertyPath, patterns []*regexp.Regexp) bool {
+	s := path.String()
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+)
+
+func TestParseDotenv(t *testing.T) {
+	t.Parallel()
+
+	pairs, err := parseDotenv([]byte(`
+# A comment
+export PLAIN=value # trailing comment
+QUOTED="line one\nline \"two\""
+SINGLE='$literal # not a comment'
+proj:outer.inner[0]=
+`))
+	require.NoError(t, err)
+	assert.Equal(t, [][2]string{
+		{"PLAIN", "value"},
+		{"QUOTED", "line one\nline \"two\""},
+		{"SINGLE", "$literal # not a comment"},
+		{"proj:outer.inner[0]", ""},
+	}, pairs)
+
+	_, err = parseDotenv([]byte("novalue\n"))
+	assert.EqualError(t, err, "line 1: expected a line of the form key=value")
+}
+
+func TestConfigExportImportRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	source := config.Map{
+		config.MustMakeKey("proj", "region"):  config.NewValue("us-west-2"),
+		config.MustMakeKey("proj", "a.b"):     config.NewValue("dotted"),
+		config.MustMakeKey("aws", "profile"):  config.NewValue("prod"),
+		config.MustMakeKey("proj", "db"):      config.NewObjectValue(`{"port":5432,"users":[{"name":"admin"}]}`),
+		config.MustMakeKey("proj", "secret"):  config.NewSecureValue("c2VjcmV0"),
+		config.MustMakeKey("proj", "enabled"): config.NewValue("true"),
+	}
+
+	for _, format := range []string{configFormatJSON, configFormatYAML, configFormatDotenv} {
+		format := format
+		t.Run(format, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			omitted, err := exportConfig(&buf, source, format, config.NewPanicCrypter(), false /*showSecrets*/)
+			require.NoError(t, err)
+			assert.Equal(t, []config.Key{config.MustMakeKey("proj", "secret")}, omitted)
+
+			values, err := parseConfigFile(buf.Bytes(), format, "proj")
+			require.NoError(t, err)
+
+			imported := config.Map{}
+			err = importConfig(imported, values, nil, func() (config.Encrypter, error) {
+				t.Fatal("no values should be encrypted")
+				return nil, nil
+			})
+			require.NoError(t, err)
+
+			expected := config.Map{}
+			for k, v := range source {
+				if !v.Secure() {
+					expected[k] = v
+				}
+			}
+			assert.Equal(t, expected, imported)
+		})
+	}
+}
+
+func TestConfigImportSecretKeys(t *testing.T) {
+	t.Parallel()
+
+	values, err := parseConfigFile([]byte(`{
+  "dbPassword": "hunter2",
+  "github": {"token": "abc", "user": "octocat"},
+  "other:region": "us-east-1"
+}`), configFormatJSON, "proj")
+	require.NoError(t, err)
+
+	cfg := config.Map{
+		config.MustMakeKey("proj", "github"): config.NewObjectValue(`{"org":"pulumi"}`),
+		config.MustMakeKey("proj", "kept"):   config.NewValue("unchanged"),
+	}
+	crypter := config.NewSymmetricCrypter(make([]byte, config.SymmetricCrypterKeyBytes))
+	err = importConfig(cfg, values, []*regexp.Regexp{regexp.MustCompile("(?i)password|token")},
+		func() (config.Encrypter, error) { return crypter, nil })
+	require.NoError(t, err)
+
+	decrypted, err := cfg.Decrypt(crypter)
+	require.NoError(t, err)
+	assert.Equal(t, map[config.Key]string{
+		config.MustMakeKey("proj", "dbPassword"): "hunter2",
+		config.MustMakeKey("proj", "github"):     `{"token":"abc","user":"octocat"}`,
+		config.MustMakeKey("proj", "kept"):       "unchanged",
+		config.MustMakeKey("other", "region"):    "us-east-1",
+	}, decrypted)
+
+	assert.True(t, cfg[config.MustMakeKey("proj", "dbPassword")].Secure())
+	assert.True(t, cfg[config.MustMakeKey("proj", "github")].Secure())
+	paths, err := cfg[config.MustMakeKey("proj", "github")].SecurePaths()
+	require.NoError(t, err)
+	require.Len(t, paths, 1)
+	assert.Equal(t, "token", paths[0].String())
+	assert.False(t, cfg[config.MustMakeKey("other", "region")].Secure())
+}
+
+func TestConfigImportKeepsTypes(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []string{configFormatJSON, configFormatYAML} {
+		format := format
+		t.Run(format, func(t *testing.T) {
+			t.Parallel()
+
+			contents := `{"db": {"port": 5432, "ratio": 0.5, "tls": true, "name": "true", "token": 42, "tags": ["1"]}}`
+			if format == configFormatYAML {
+				contents = "db:\n  port: 5432\n  ratio: 0.5\n  tls: true\n  name: \"true\"\n  token: 42\n" +
+					"  tags: [\"1\"]\n"
+			}
+			values, err := parseConfigFile([]byte(contents), format, "proj")
+			require.NoError(t, err)
+
+			cfg := config.Map{}
+			crypter := config.NewSymmetricCrypter(make([]byte, config.SymmetricCrypterKeyBytes))
+			err = importConfig(cfg, values, []*regexp.Regexp{regexp.MustCompile("token")},
+				func() (config.Encrypter, error) { return crypter, nil })
+			require.NoError(t, err)
+
+			// Plaintext values keep their types; secrets are always strings.
+			db := cfg[config.MustMakeKey("proj", "db")]
+			assert.True(t, db.Secure())
+			plaintext, err := db.Value(crypter)
+			require.NoError(t, err)
+			assert.JSONEq(t,
+				`{"name":"true","port":5432,"ratio":0.5,"tags":["1"],"tls":true,"token":"42"}`, plaintext)
+		})
+	}
+}
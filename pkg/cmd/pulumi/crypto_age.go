@@ -0,0 +1,85 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/base64"
+
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/age"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func newAgeSecretsManager(stackName tokens.Name, configFile, secretsProvider string) (secrets.Manager, error) {
+	contract.Assertf(stackName != "", "stackName %s", "!= \"\"")
+
+	recipients, err := age.ParseRecipients(secretsProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	if configFile == "" {
+		f, err := workspace.DetectProjectStackPath(stackName.Q())
+		if err != nil {
+			return nil, err
+		}
+		configFile = f
+	}
+
+	info, err := workspace.LoadProjectStack(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only a passphrase provider has an encryption salt, so switching to the age provider removes it.
+	if info.EncryptionSalt != "" {
+		info.EncryptionSalt = ""
+	}
+
+	switch {
+	case info.EncryptedKey != "" && info.SecretsProvider == secretsProvider:
+		// The data key is already encrypted to exactly these recipients.
+	case info.EncryptedKey != "" && age.IsAgeSecretsProvider(info.SecretsProvider):
+		// The recipients have changed. Re-encrypt the existing data key to the new recipients so that all existing
+		// secrets remain readable without being re-encrypted themselves.
+		dataKey, err := base64.StdEncoding.DecodeString(info.EncryptedKey)
+		if err != nil {
+			return nil, err
+		}
+		if dataKey, err = age.ReencryptDataKey(dataKey, recipients); err != nil {
+			return nil, err
+		}
+		info.EncryptedKey = base64.StdEncoding.EncodeToString(dataKey)
+	default:
+		// There is no key, or the stack is switching from another secrets provider, so generate a new data key.
+		dataKey, err := age.GenerateNewDataKey(recipients)
+		if err != nil {
+			return nil, err
+		}
+		info.EncryptedKey = base64.StdEncoding.EncodeToString(dataKey)
+	}
+	info.SecretsProvider = secretsProvider
+	if err = info.Save(configFile); err != nil {
+		return nil, err
+	}
+
+	dataKey, err := base64.StdEncoding.DecodeString(info.EncryptedKey)
+	if err != nil {
+		return nil, err
+	}
+	return age.NewAgeSecretsManager(recipients, dataKey)
+}
@@ -44,6 +44,8 @@ func newDestroyCmd() *cobra.Command {
 	var diffDisplay bool
 	var eventLogPath string
 	var parallel int
+	var recordProviders string
+	var replayProviders string
 	var refresh string
 	var showConfig bool
 	var showReplacementSteps bool
@@ -70,6 +72,10 @@ func newDestroyCmd() *cobra.Command {
 			"Warning: this command is generally irreversible and should be used with great care.",
 		Args: cmdutil.NoArgs,
 		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			if err := resolveProviderRecordingFlags(&recordProviders, &replayProviders); err != nil {
+				return result.FromError(err)
+			}
+
 			yes = yes || skipConfirmations()
 			interactive := cmdutil.Interactive()
 			if !interactive && !yes {
@@ -196,6 +202,8 @@ func newDestroyCmd() *cobra.Command {
 				DisableProviderPreview:    disableProviderPreview(),
 				DisableResourceReferences: disableResourceReferences(),
 				DisableOutputValues:       disableOutputValues(),
+				RecordProviders:           recordProviders,
+				ReplayProviders:           replayProviders,
 			}
 
 			_, res := s.Destroy(commandContext(), backend.UpdateOperation{
@@ -252,6 +260,7 @@ func newDestroyCmd() *cobra.Command {
 	cmd.Flags().BoolVarP(
 		&jsonDisplay, "json", "j", false,
 		"Serialize the destroy diffs, operations, and overall output as JSON")
+	addProviderRecordingFlags(cmd, &recordProviders, &replayProviders)
 	cmd.PersistentFlags().IntVarP(
 		&parallel, "parallel", "p", defaultParallel,
 		"Allow P resource operations to run in parallel at once (1 for no parallelism). Defaults to unbounded.")
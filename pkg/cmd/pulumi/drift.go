@@ -0,0 +1,287 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+// driftExitCode is the exit code used by `pulumi drift` when drift is detected. No drift exits with 0, and any error
+// exits with the standard failure code.
+const driftExitCode = 2
+
+// driftReport is the JSON report written by `pulumi drift`.
+type driftReport struct {
+	Stack     string            `json:"stack"`
+	Drifted   bool              `json:"drifted"`
+	Resources []driftedResource `json:"resources"`
+}
+
+// driftedResource describes a resource whose live state differs from the state recorded in the checkpoint.
+type driftedResource struct {
+	URN  resource.URN `json:"urn"`
+	Type tokens.Type  `json:"type"`
+	ID   resource.ID  `json:"id,omitempty"`
+	// Kind is "modified" if the resource's live outputs differ from its checkpointed outputs, or "deleted" if the
+	// resource no longer exists.
+	Kind       string             `json:"kind"`
+	Properties []driftedProperty `json:"properties,omitempty"`
+}
+
+// driftedProperty describes a single output property of a modified resource that has drifted.
+type driftedProperty struct {
+	Path       string      `json:"path"`
+	Kind       string      `json:"kind"`
+	Checkpoint interface{} `json:"checkpoint,omitempty"`
+	Live       interface{} `json:"live,omitempty"`
+}
+
+func newDriftCmd() *cobra.Command {
+	var debug bool
+	var stack string
+	var reportPath string
+	var parallel int
+	var suppressOutputs bool
+	var targets []string
+
+	var cmd = &cobra.Command{
+		Use:   "drift",
+		Short: "Detect drift between a stack's resources and their state",
+		Long: "Detect drift between a stack's resources and their state.\n" +
+			"\n" +
+			"This command previews a refresh of the current stack and reports each resource whose actual\n" +
+			"state in the cloud provider differs from the state recorded in the stack, along with the\n" +
+			"properties that differ. The stack's state is never modified.\n" +
+			"\n" +
+			"The command exits with code 0 if no drift is detected, " + fmt.Sprint(driftExitCode) + " if drift is\n" +
+			"detected, and 255 if an error occurs. Use `--report` to also write a JSON report of the drift to a file.",
+		Args: cmdutil.NoArgs,
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			interactive := cmdutil.Interactive()
+
+			opts := backend.UpdateOptions{
+				AutoApprove: true,
+				PreviewOnly: true,
+			}
+			opts.Display = display.Options{
+				Color:           cmdutil.GetGlobalColorization(),
+				SuppressOutputs: suppressOutputs,
+				IsInteractive:   interactive,
+				Type:            display.DisplayProgress,
+				Debug:           debug,
+			}
+
+			s, err := requireStack(stack, false, opts.Display, false /*setCurrent*/)
+			if err != nil {
+				return result.FromError(err)
+			}
+
+			proj, root, err := readProject()
+			if err != nil {
+				return result.FromError(err)
+			}
+
+			m, err := getUpdateMetadata("", root, "", "")
+			if err != nil {
+				return result.FromError(fmt.Errorf("gathering environment metadata: %w", err))
+			}
+
+			sm, err := getStackSecretsManager(s)
+			if err != nil {
+				return result.FromError(fmt.Errorf("getting secrets manager: %w", err))
+			}
+
+			cfg, err := getStackConfiguration(s, sm)
+			if err != nil {
+				return result.FromError(fmt.Errorf("getting stack configuration: %w", err))
+			}
+
+			targetUrns := []resource.URN{}
+			for _, t := range targets {
+				targetUrns = append(targetUrns, resource.URN(t))
+			}
+
+			opts.Engine = engine.UpdateOptions{
+				Parallel:                  parallel,
+				Debug:                     debug,
+				UseLegacyDiff:             useLegacyDiff(),
+				DisableProviderPreview:    disableProviderPreview(),
+				DisableResourceReferences: disableResourceReferences(),
+				DisableOutputValues:       disableOutputValues(),
+				RefreshTargets:            targetUrns,
+			}
+
+			// Collect the results of the refresh preview as they are reported by the engine.
+			events := make(chan engine.Event)
+			eventsDone := make(chan []driftedResource)
+			go func() {
+				eventsDone <- collectDrift(events)
+			}()
+
+			_, res := s.Refresh(commandContext(), backend.UpdateOperation{
+				Proj:               proj,
+				Root:               root,
+				M:                  m,
+				Opts:               opts,
+				StackConfiguration: cfg,
+				SecretsManager:     sm,
+				Scopes:             cancellationScopes,
+				Events:             events,
+			})
+			close(events)
+			drifted := <-eventsDone
+
+			switch {
+			case res != nil && res.Error() == context.Canceled:
+				return result.FromError(errors.New("drift detection cancelled"))
+			case res != nil:
+				return PrintEngineResult(res)
+			}
+
+			report := driftReport{
+				Stack:     s.Ref().String(),
+				Drifted:   len(drifted) > 0,
+				Resources: drifted,
+			}
+			if reportPath != "" {
+				b, err := json.MarshalIndent(report, "", "    ")
+				if err != nil {
+					return result.FromError(err)
+				}
+				if err = ioutil.WriteFile(reportPath, append(b, '\n'), 0600); err != nil {
+					return result.FromError(fmt.Errorf("writing drift report: %w", err))
+				}
+			}
+
+			if !report.Drifted {
+				fmt.Println("No drift detected.")
+				return nil
+			}
+			fmt.Printf("Drift detected in %d resource(s):\n", len(drifted))
+			for _, r := range drifted {
+				fmt.Printf("    %s (%s)\n", r.URN, r.Kind)
+				for _, p := range r.Properties {
+					fmt.Printf("        %s: %s\n", p.Path, p.Kind)
+				}
+			}
+			return cmdutil.BailWithExitCode(driftExitCode)
+		}),
+	}
+
+	cmd.PersistentFlags().BoolVarP(
+		&debug, "debug", "d", false,
+		"Print detailed debugging output during resource operations")
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.PersistentFlags().StringVar(
+		&stackConfigFile, "config-file", "",
+		"Use the configuration values in the specified file rather than detecting the file name")
+	cmd.PersistentFlags().StringVar(
+		&reportPath, "report", "",
+		"Write a JSON report of any detected drift to the given file")
+	cmd.PersistentFlags().StringArrayVarP(
+		&targets, "target", "t", []string{},
+		"Specify a single resource URN to check for drift. Multiple resources can be specified using: "+
+			"--target urn1 --target urn2")
+	cmd.PersistentFlags().IntVarP(
+		&parallel, "parallel", "p", defaultParallel,
+		"Allow P resource operations to run in parallel at once (1 for no parallelism). Defaults to unbounded.")
+	cmd.PersistentFlags().BoolVar(
+		&suppressOutputs, "suppress-outputs", false,
+		"Suppress display of stack outputs (in case they contain sensitive values)")
+
+	return cmd
+}
+
+// collectDrift reads the events raised by a refresh preview until the channel is closed, and returns the resources
+// whose refreshed state differs from their checkpointed state, ordered by URN.
+func collectDrift(events <-chan engine.Event) []driftedResource {
+	var drifted []driftedResource
+	for e := range events {
+		if e.Type != engine.ResourceOutputsEvent {
+			continue
+		}
+		// The op of a refresh step's outputs event reflects the change to the resource's state: OpUpdate if its
+		// outputs changed, OpDelete if it no longer exists, and OpSame otherwise.
+		md := e.Payload().(engine.ResourceOutputsEventPayload).Metadata
+		if md.Old == nil || !md.Old.Custom {
+			continue
+		}
+		switch md.Op {
+		case deploy.OpDelete:
+			drifted = append(drifted, driftedResource{URN: md.URN, Type: md.Type, ID: md.Old.ID, Kind: "deleted"})
+		case deploy.OpUpdate:
+			if md.New == nil {
+				continue
+			}
+			drifted = append(drifted, driftedResource{
+				URN:        md.URN,
+				Type:       md.Type,
+				ID:         md.Old.ID,
+				Kind:       "modified",
+				Properties: diffDriftedProperties(md.Old.Outputs, md.New.Outputs),
+			})
+		}
+	}
+
+	sort.Slice(drifted, func(i, j int) bool { return drifted[i].URN < drifted[j].URN })
+	return drifted
+}
+
+// diffDriftedProperties returns the property-level differences between a resource's checkpointed and live outputs,
+// ordered by path. Secret values are never included in the result.
+func diffDriftedProperties(checkpoint, live resource.PropertyMap) []driftedProperty {
+	diff := checkpoint.Diff(live)
+	if diff == nil {
+		return nil
+	}
+
+	getValue := func(m resource.PropertyMap, path resource.PropertyPath) interface{} {
+		v, ok := path.Get(resource.NewObjectProperty(m))
+		if !ok || v.IsNull() {
+			return nil
+		}
+		return display.MassageSecrets(resource.PropertyMap{"v": v}, false)["v"].Mappable()
+	}
+
+	var props []driftedProperty
+	for key, d := range plugin.NewDetailedDiffFromObjectDiff(diff) {
+		prop := driftedProperty{Path: key, Kind: d.Kind.String()}
+		if path, err := resource.ParsePropertyPath(key); err == nil {
+			prop.Checkpoint, prop.Live = getValue(checkpoint, path), getValue(live, path)
+		}
+		props = append(props, prop)
+	}
+	sort.Slice(props, func(i, j int) bool { return props[i].Path < props[j].Path })
+	return props
+}
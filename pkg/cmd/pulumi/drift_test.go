@@ -0,0 +1,81 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+func TestCollectDrift(t *testing.T) {
+	t.Parallel()
+
+	outputsEvent := func(op deploy.StepOp, urn resource.URN, custom bool, old, new resource.PropertyMap) engine.Event {
+		md := engine.StepEventMetadata{
+			Op:   op,
+			URN:  urn,
+			Type: urn.Type(),
+			Old:  &engine.StepEventStateMetadata{URN: urn, Custom: custom, ID: "id", Outputs: old},
+		}
+		if new != nil {
+			md.New = &engine.StepEventStateMetadata{URN: urn, Custom: custom, ID: "id", Outputs: new}
+		}
+		return engine.NewEvent(engine.ResourceOutputsEvent, engine.ResourceOutputsEventPayload{Metadata: md})
+	}
+
+	withToken := func(m resource.PropertyMap, token string) resource.PropertyMap {
+		m["token"] = resource.MakeSecret(resource.NewStringProperty(token))
+		return m
+	}
+
+	const urnA = resource.URN("urn:pulumi:dev::proj::pkg:index:typ::a")
+	const urnB = resource.URN("urn:pulumi:dev::proj::pkg:index:typ::b")
+	const urnC = resource.URN("urn:pulumi:dev::proj::pkg:index:typ::c")
+	const urnD = resource.URN("urn:pulumi:dev::proj::my:component::d")
+
+	events := make(chan engine.Event, 5)
+	events <- outputsEvent(deploy.OpUpdate, urnB, true,
+		withToken(resource.NewPropertyMapFromMap(map[string]interface{}{
+			"size": 1,
+			"tags": map[string]interface{}{"env": "prod", "owner": "me"},
+		}), "old"),
+		withToken(resource.NewPropertyMapFromMap(map[string]interface{}{
+			"size": 2,
+			"tags": map[string]interface{}{"env": "dev", "team": "infra"},
+		}), "new"))
+	events <- outputsEvent(deploy.OpDelete, urnA, true, resource.PropertyMap{}, nil)
+	events <- outputsEvent(deploy.OpSame, urnC, true, resource.PropertyMap{}, resource.PropertyMap{})
+	events <- outputsEvent(deploy.OpUpdate, urnD, false, resource.PropertyMap{}, resource.PropertyMap{
+		"x": resource.NewStringProperty("y"),
+	})
+	events <- engine.NewEvent(engine.SummaryEvent, engine.SummaryEventPayload{})
+	close(events)
+
+	assert.Equal(t, []driftedResource{
+		{URN: urnA, Type: urnA.Type(), ID: "id", Kind: "deleted"},
+		{URN: urnB, Type: urnB.Type(), ID: "id", Kind: "modified", Properties: []driftedProperty{
+			{Path: "size", Kind: "update", Checkpoint: float64(1), Live: float64(2)},
+			{Path: "tags.env", Kind: "update", Checkpoint: "prod", Live: "dev"},
+			{Path: "tags.owner", Kind: "delete", Checkpoint: "me"},
+			{Path: "tags.team", Kind: "add", Live: "infra"},
+			{Path: "token", Kind: "update", Checkpoint: "[secret]", Live: "[secret]"},
+		}},
+	}, collectDrift(events))
+}
@@ -283,6 +283,7 @@ func newImportCmd() *cobra.Command {
 	var importFilePath string
 	var outputFilePath string
 	var generateCode bool
+	var language string
 
 	var debug bool
 	var message string
@@ -453,8 +454,12 @@ func newImportCmd() *cobra.Command {
 				return result.FromError(err)
 			}
 
+			if language == "" {
+				language = proj.Runtime.Name()
+			}
+
 			var programGenerator programGeneratorFunc
-			switch proj.Runtime.Name() {
+			switch language {
 			case "dotnet":
 				programGenerator = dotnet.GenerateProgram
 			case "go":
@@ -468,7 +473,7 @@ func newImportCmd() *cobra.Command {
 			case "yaml":
 				programGenerator = yamlgen.GenerateProgram
 			default:
-				return result.Errorf("cannot generate resource definitions for %v", proj.Runtime.Name())
+				return result.Errorf("cannot generate resource definitions for %v", language)
 			}
 
 			// Fetch the current stack.
@@ -569,6 +574,9 @@ func newImportCmd() *cobra.Command {
 		&outputFilePath, "out", "o", "", "The path to the file that will contain the generated resource declarations")
 	cmd.PersistentFlags().BoolVar(
 		&generateCode, "generate-code", true, "Generate resource declaration code for the imported resources")
+	cmd.PersistentFlags().StringVar(
+		&language, "language", "",
+		"The language in which to generate resource declarations. Defaults to the language of the project")
 
 	cmd.PersistentFlags().BoolVarP(
 		&debug, "debug", "d", false,
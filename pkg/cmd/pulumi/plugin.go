@@ -15,9 +15,19 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
 	"github.com/spf13/cobra"
 
+	"github.com/pulumi/pulumi/pkg/v3/backend"
 	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
@@ -45,7 +55,9 @@ func newPluginCmd() *cobra.Command {
 
 	cmd.AddCommand(newPluginInstallCmd())
 	cmd.AddCommand(newPluginLsCmd())
+	cmd.AddCommand(newPluginPruneCmd())
 	cmd.AddCommand(newPluginRmCmd())
+	cmd.AddCommand(newPluginVendorCmd())
 
 	return cmd
 }
@@ -88,3 +100,114 @@ func getProjectPlugins() ([]workspace.PluginInfo, error) {
 	}
 	return results, nil
 }
+
+// pluginPlatform is an OS and architecture for which plugins are built.
+type pluginPlatform struct {
+	OS   string
+	Arch string
+}
+
+func (p pluginPlatform) String() string {
+	return p.OS + "/" + p.Arch
+}
+
+// parsePluginPlatforms parses a list of OS/ARCH platforms. The current platform is always first in the result.
+func parsePluginPlatforms(platforms []string) ([]pluginPlatform, error) {
+	opSy, arch, err := workspace.GetPluginPlatform()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []pluginPlatform{{OS: opSy, Arch: arch}}
+	for _, p := range platforms {
+		parts := strings.Split(p, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid platform %q: expected OS/ARCH", p)
+		}
+		platform := pluginPlatform{OS: parts[0], Arch: parts[1]}
+		if !containsPluginPlatform(result, platform) {
+			result = append(result, platform)
+		}
+	}
+	return result, nil
+}
+
+func containsPluginPlatform(platforms []pluginPlatform, platform pluginPlatform) bool {
+	for _, p := range platforms {
+		if p == platform {
+			return true
+		}
+	}
+	return false
+}
+
+// getStackPlugins returns the plugins that the checkpoint of each stack in the given backend references, keyed by
+// stack name. A plugin is referenced if the checkpoint's manifest records it or a provider resource requires it.
+// Checkpoints are read in their exported form, so the secrets providers of the stacks need not be available.
+func getStackPlugins(ctx context.Context, b backend.Backend) (map[string][]workspace.PluginInfo, error) {
+	result := map[string][]workspace.PluginInfo{}
+
+	var inContToken backend.ContinuationToken
+	for {
+		summaries, outContToken, err := b.ListStacks(ctx, backend.ListStacksFilter{}, inContToken)
+		if err != nil {
+			return nil, fmt.Errorf("could not query backend for stacks: %w", err)
+		}
+
+		for _, summary := range summaries {
+			name := summary.Name().String()
+			s, err := b.GetStack(ctx, summary.Name())
+			if err != nil {
+				return nil, fmt.Errorf("getting stack %s: %w", name, err)
+			}
+			if s == nil {
+				continue
+			}
+			untyped, err := s.ExportDeployment(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("getting the checkpoint of stack %s: %w", name, err)
+			}
+			deployment, err := stack.UpgradeUntypedDeployment(untyped)
+			if err != nil {
+				return nil, fmt.Errorf("getting the checkpoint of stack %s: %w", name, err)
+			}
+			plugins, err := getDeploymentPlugins(deployment)
+			if err != nil {
+				return nil, fmt.Errorf("stack %s: %w", name, err)
+			}
+			result[name] = plugins
+		}
+
+		if outContToken == nil {
+			break
+		}
+		inContToken = outContToken
+	}
+	return result, nil
+}
+
+// getDeploymentPlugins returns the plugins that a deployment references: those that its manifest records and those
+// that its provider resources require.
+func getDeploymentPlugins(deployment *apitype.DeploymentV3) ([]workspace.PluginInfo, error) {
+	manifest, err := deploy.DeserializeManifest(deployment.Manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	plugins := manifest.Plugins
+	for _, res := range deployment.Resources {
+		if !providers.IsProviderType(res.URN.Type()) {
+			continue
+		}
+		version, err := providers.GetProviderVersion(resource.NewPropertyMapFromMap(res.Inputs))
+		if err != nil {
+			return nil, err
+		}
+		plugins = append(plugins, workspace.PluginInfo{
+			Name:    providers.GetProviderPackage(res.URN.Type()).String(),
+			Kind:    workspace.ResourcePlugin,
+			Version: version,
+		})
+	}
+	return plugins, nil
+}
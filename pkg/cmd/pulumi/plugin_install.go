@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
@@ -29,6 +30,7 @@ import (
 	"github.com/pulumi/pulumi/pkg/v3/backend/display"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 )
 
@@ -37,6 +39,8 @@ func newPluginInstallCmd() *cobra.Command {
 	var exact bool
 	var file string
 	var reinstall bool
+	var lockPlugins bool
+	var platforms []string
 
 	var cmd = &cobra.Command{
 		Use:   "install [KIND NAME [VERSION]]",
@@ -50,12 +54,44 @@ func newPluginInstallCmd() *cobra.Command {
 			"project. If specified VERSION cannot be a range: it must be a specific number.\n" +
 			"\n" +
 			"If you let Pulumi compute the set to download, it is conservative and may end up\n" +
-			"downloading more plugins than is strictly necessary.",
+			"downloading more plugins than is strictly necessary.\n" +
+			"\n" +
+			"If the current project has a pulumi-plugins.lock file, plugins are installed at their\n" +
+			"locked versions, and each plugin's tarball must match the checksum that the lock file\n" +
+			"records for it. Pass --lock to record the installed plugins in the lock file instead;\n" +
+			"--platform adds the tarballs for other platforms (e.g. linux/arm64) to the lock file.",
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 			displayOpts := display.Options{
 				Color: cmdutil.GetGlobalColorization(),
 			}
 
+			if lockPlugins && file != "" {
+				return errors.New("--lock cannot be used with --file (-f)")
+			}
+			if len(platforms) != 0 && !lockPlugins {
+				return errors.New("--platform can only be used with --lock")
+			}
+			lockPlatforms, err := parsePluginPlatforms(platforms)
+			if err != nil {
+				return err
+			}
+			current := lockPlatforms[0]
+
+			// Load the current project's plugin lock, if any. Plugins are only locked for a project.
+			var lock *workspace.PluginLock
+			var root string
+			if _, projRoot, err := readProject(); err == nil {
+				root = projRoot
+				if lock, err = workspace.LoadPluginLock(root); err != nil {
+					return err
+				}
+			} else if lockPlugins {
+				return fmt.Errorf("--lock requires a project: %w", err)
+			}
+			if lockPlugins && lock == nil {
+				lock = &workspace.PluginLock{}
+			}
+
 			// Parse the kind, name, and version, if specified.
 			var installs []workspace.PluginInfo
 			if len(args) > 0 {
@@ -81,8 +117,11 @@ func newPluginInstallCmd() *cobra.Command {
 					PluginDownloadURL: serverURL, // If empty, will use default plugin source.
 				}
 
-				// If we don't have a version try to look one up
-				if version == nil {
+				// If we don't have a version, use the locked version or try to look one up.
+				if !lockPlugins {
+					pluginInfo = lock.Resolve(pluginInfo)
+				}
+				if pluginInfo.Version == nil {
 					latestVersion, err := pluginInfo.GetLatestVersion()
 					if err != nil {
 						return err
@@ -105,6 +144,9 @@ func newPluginInstallCmd() *cobra.Command {
 					// Skip language plugins; by definition, we already have one installed.
 					// TODO[pulumi/pulumi#956]: eventually we will want to honor and install these in the usual way.
 					if plugin.Kind != workspace.LanguagePlugin {
+						if !lockPlugins {
+							plugin = lock.Resolve(plugin)
+						}
 						installs = append(installs, plugin)
 					}
 				}
@@ -114,6 +156,14 @@ func newPluginInstallCmd() *cobra.Command {
 			for _, install := range installs {
 				label := fmt.Sprintf("[%s plugin %s]", install.Kind, install)
 
+				// Locking a plugin records the checksum of its tarball, so the tarball is always downloaded.
+				if lockPlugins {
+					if err := installAndLockPlugin(lock, install, lockPlatforms, reinstall, displayOpts); err != nil {
+						return fmt.Errorf("%s locking: %w", label, err)
+					}
+					continue
+				}
+
 				// If the plugin already exists, don't download it unless --reinstall was passed.  Note that
 				// by default we accept plugins with >= constraints, unless --exact was passed which requires ==.
 				if !reinstall {
@@ -133,14 +183,24 @@ func newPluginInstallCmd() *cobra.Command {
 				cmdutil.Diag().Infoerrf(
 					diag.Message("", "%s installing"), label)
 
+				// If the plugin is locked, its tarball must match the lock file's checksum.
+				artifact, locked := lock.Artifact(install, current.OS, current.Arch)
+
 				// If we got here, actually try to do the download.
 				var source string
 				var tarball io.ReadCloser
 				var err error
 				if file == "" {
 					var size int64
-					if tarball, size, err = install.Download(); err != nil {
-						return fmt.Errorf("%s downloading from %s: %w", label, install.PluginDownloadURL, err)
+					if locked {
+						source = artifact.URL
+						tarball, size, err = artifact.Download()
+					} else {
+						source = install.PluginDownloadURL
+						tarball, size, err = install.Download()
+					}
+					if err != nil {
+						return fmt.Errorf("%s downloading from %s: %w", label, source, err)
 					}
 					tarball = workspace.ReadCloserProgressBar(tarball, size, "Downloading plugin", displayOpts.Color)
 				} else {
@@ -151,11 +211,19 @@ func newPluginInstallCmd() *cobra.Command {
 					}
 				}
 				logging.V(1).Infof("%s installing tarball ...", label)
-				if err = install.Install(tarball, reinstall); err != nil {
+				if locked {
+					err = install.InstallWithChecksum(tarball, artifact.SHA256, reinstall)
+				} else {
+					err = install.Install(tarball, reinstall)
+				}
+				if err != nil {
 					return fmt.Errorf("installing %s from %s: %w", label, source, err)
 				}
 			}
 
+			if lockPlugins {
+				return lock.Save(root)
+			}
 			return nil
 		}),
 	}
@@ -168,6 +236,40 @@ func newPluginInstallCmd() *cobra.Command {
 		"file", "f", "", "Install a plugin from a tarball file, instead of downloading it")
 	cmd.PersistentFlags().BoolVar(&reinstall,
 		"reinstall", false, "Reinstall a plugin even if it already exists")
+	cmd.PersistentFlags().BoolVar(&lockPlugins,
+		"lock", false, "Record the installed plugins' versions, URLs and checksums in the project's pulumi-plugins.lock")
+	cmd.PersistentFlags().StringSliceVar(&platforms,
+		"platform", nil, "With --lock, also lock the plugins' tarballs for the given OS/ARCH platforms")
 
 	return cmd
 }
+
+// installAndLockPlugin downloads the given plugin's tarball for each of the given platforms and records its URL and
+// checksum in the given lock. The first platform must be the current platform; its tarball is also installed.
+func installAndLockPlugin(lock *workspace.PluginLock, install workspace.PluginInfo, platforms []pluginPlatform,
+	reinstall bool, displayOpts display.Options) error {
+
+	for i, platform := range platforms {
+		tarball, size, url, err := install.DownloadArtifact(platform.OS, platform.Arch)
+		if err != nil {
+			return fmt.Errorf("downloading for %s: %w", platform, err)
+		}
+		hashing := workspace.NewHashingReader(
+			workspace.ReadCloserProgressBar(tarball, size, "Downloading plugin", displayOpts.Color))
+		if i == 0 {
+			// Install closes its tarball, but the rest of the tarball still needs to be read to compute its checksum.
+			err = install.Install(ioutil.NopCloser(hashing), reinstall)
+		}
+		sum, sumErr := hashing.Sum()
+		contract.IgnoreClose(hashing)
+		if err != nil {
+			return err
+		}
+		if sumErr != nil {
+			return sumErr
+		}
+
+		lock.Record(install, workspace.PluginArtifact{OS: platform.OS, Arch: platform.Arch, URL: url, SHA256: sum})
+	}
+	return nil
+}
@@ -17,11 +17,13 @@ package main
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/dustin/go-humanize"
 
 	"github.com/spf13/cobra"
 
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 )
@@ -29,10 +31,18 @@ import (
 func newPluginLsCmd() *cobra.Command {
 	var projectOnly bool
 	var jsonOut bool
+	var usedBy bool
 	cmd := &cobra.Command{
 		Use:   "ls",
 		Short: "List plugins",
-		Args:  cmdutil.NoArgs,
+		Long: "List plugins.\n" +
+			"\n" +
+			"With --used-by, each plugin is listed with the stacks in the current backend whose\n" +
+			"checkpoints reference it, either in their manifests or through their provider\n" +
+			"resources.  A reference that doesn't name a version refers to the newest installed\n" +
+			"version of the plugin.  Checkpoints are read without decrypting their secrets, so the\n" +
+			"stacks' passphrases and keys are not needed.",
+		Args: cmdutil.NoArgs,
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 			// Produce a list of plugins, sorted by name and version.
 			var plugins []workspace.PluginInfo
@@ -60,10 +70,27 @@ func newPluginLsCmd() *cobra.Command {
 				return false
 			})
 
+			// Find the stacks that use each plugin, if requested.
+			var stacks [][]string
+			if usedBy {
+				opts := display.Options{
+					Color: cmdutil.GetGlobalColorization(),
+				}
+				b, err := currentBackend(opts)
+				if err != nil {
+					return err
+				}
+				stackPlugins, err := getStackPlugins(commandContext(), b)
+				if err != nil {
+					return err
+				}
+				stacks = getPluginStacks(plugins, stackPlugins)
+			}
+
 			if jsonOut {
-				return formatPluginsJSON(plugins)
+				return formatPluginsJSON(plugins, stacks)
 			}
-			return formatPluginConsole(plugins)
+			return formatPluginConsole(plugins, stacks)
 		}),
 	}
 
@@ -73,10 +100,57 @@ func newPluginLsCmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVarP(
 		&jsonOut, "json", "j", false,
 		"Emit output as JSON")
+	cmd.PersistentFlags().BoolVar(
+		&usedBy, "used-by", false,
+		"Also list the stacks in the current backend that use each plugin")
 
 	return cmd
 }
 
+// getPluginStacks returns the sorted names of the stacks that use each of the given plugins, given the plugins that
+// each stack uses. A stack that uses a plugin without naming its version uses the plugin's newest version.
+func getPluginStacks(plugins []workspace.PluginInfo, stackPlugins map[string][]workspace.PluginInfo) [][]string {
+	// Find the newest version of each plugin.
+	newest := map[string]*workspace.PluginInfo{}
+	for i, plugin := range plugins {
+		key := pluginReferenceKey(plugin.Kind, plugin.Name, nil)
+		if n, has := newest[key]; plugin.Version != nil && (!has || n.Version.LT(*plugin.Version)) {
+			newest[key] = &plugins[i]
+		}
+	}
+
+	users := map[string]map[string]bool{}
+	for stack, refs := range stackPlugins {
+		for _, ref := range refs {
+			key := pluginReferenceKey(ref.Kind, ref.Name, ref.Version)
+			if ref.Version == nil {
+				n, has := newest[key]
+				if !has {
+					continue
+				}
+				key = pluginReferenceKey(n.Kind, n.Name, n.Version)
+			}
+			if users[key] == nil {
+				users[key] = map[string]bool{}
+			}
+			users[key][stack] = true
+		}
+	}
+
+	result := make([][]string, len(plugins))
+	for i, plugin := range plugins {
+		stacks := []string{}
+		if plugin.Version != nil {
+			for stack := range users[pluginReferenceKey(plugin.Kind, plugin.Name, plugin.Version)] {
+				stacks = append(stacks, stack)
+			}
+		}
+		sort.Strings(stacks)
+		result[i] = stacks
+	}
+	return result
+}
+
 // pluginInfoJSON is the shape of the --json output for a configuration value.  While we can add fields to this
 // structure in the future, we should not change existing fields.
 type pluginInfoJSON struct {
@@ -86,9 +160,11 @@ type pluginInfoJSON struct {
 	Size         int     `json:"size"`
 	InstallTime  *string `json:"installTime,omitempty"`
 	LastUsedTime *string `json:"lastUsedTime,omitempty"`
+	// UsedBy lists the stacks that use the plugin. It is only present with --used-by.
+	UsedBy *[]string `json:"usedBy,omitempty"`
 }
 
-func formatPluginsJSON(plugins []workspace.PluginInfo) error {
+func formatPluginsJSON(plugins []workspace.PluginInfo, stacks [][]string) error {
 	makeStringRef := func(s string) *string {
 		return &s
 	}
@@ -109,17 +185,21 @@ func formatPluginsJSON(plugins []workspace.PluginInfo) error {
 		if !plugin.LastUsedTime.IsZero() {
 			jsonPluginInfo[idx].LastUsedTime = makeStringRef(plugin.LastUsedTime.UTC().Format(timeFormat))
 		}
+
+		if stacks != nil {
+			jsonPluginInfo[idx].UsedBy = &stacks[idx]
+		}
 	}
 
 	return printJSON(jsonPluginInfo)
 }
 
-func formatPluginConsole(plugins []workspace.PluginInfo) error {
+func formatPluginConsole(plugins []workspace.PluginInfo, stacks [][]string) error {
 	var totalSize uint64
 
 	rows := []cmdutil.TableRow{}
 
-	for _, plugin := range plugins {
+	for idx, plugin := range plugins {
 		var version string
 		if plugin.Version != nil {
 			version = plugin.Version.String()
@@ -143,15 +223,25 @@ func formatPluginConsole(plugins []workspace.PluginInfo) error {
 			lastUsedTime = humanize.Time(plugin.LastUsedTime)
 		}
 
-		rows = append(rows, cmdutil.TableRow{
-			Columns: []string{plugin.Name, string(plugin.Kind), version, bytes, installTime, lastUsedTime},
-		})
+		columns := []string{plugin.Name, string(plugin.Kind), version, bytes, installTime, lastUsedTime}
+		if stacks != nil {
+			usedBy := strings.Join(stacks[idx], ", ")
+			if usedBy == "" {
+				usedBy = humanNoneString
+			}
+			columns = append(columns, usedBy)
+		}
+		rows = append(rows, cmdutil.TableRow{Columns: columns})
 
 		totalSize += uint64(plugin.Size)
 	}
 
+	headers := []string{"NAME", "KIND", "VERSION", "SIZE", "INSTALLED", "LAST USED"}
+	if stacks != nil {
+		headers = append(headers, "USED BY")
+	}
 	cmdutil.PrintTable(cmdutil.Table{
-		Headers: []string{"NAME", "KIND", "VERSION", "SIZE", "INSTALLED", "LAST USED"},
+		Headers: headers,
 		Rows:    rows,
 	})
 
@@ -163,3 +253,4 @@ func formatPluginConsole(plugins []workspace.PluginInfo) error {
 
 const humanNeverTime = "never"
 const naString = "n/a"
+const humanNoneString = "none"
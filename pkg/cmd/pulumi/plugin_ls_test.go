@@ -0,0 +1,115 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func TestGetPluginStacks(t *testing.T) {
+	t.Parallel()
+
+	plugin := func(kind workspace.PluginKind, name, version string) workspace.PluginInfo {
+		info := workspace.PluginInfo{Kind: kind, Name: name}
+		if version != "" {
+			v := semver.MustParse(version)
+			info.Version = &v
+		}
+		return info
+	}
+	installed := []workspace.PluginInfo{
+		plugin(workspace.ResourcePlugin, "aws", "5.1.0"),
+		plugin(workspace.ResourcePlugin, "aws", "4.0.0"),
+		plugin(workspace.ResourcePlugin, "gcp", "6.0.0"),
+		plugin(workspace.AnalyzerPlugin, "aws", "1.0.0"),
+	}
+	stackPlugins := map[string][]workspace.PluginInfo{
+		"prod": {
+			plugin(workspace.ResourcePlugin, "aws", "4.0.0"),
+			plugin(workspace.LanguagePlugin, "nodejs", ""),
+		},
+		"dev": {
+			// Referenced from both the manifest and a provider resource.
+			plugin(workspace.ResourcePlugin, "aws", "5.1.0"),
+			plugin(workspace.ResourcePlugin, "aws", "5.1.0"),
+		},
+		"test": {
+			// Without a version, the newest installed version is used.
+			plugin(workspace.ResourcePlugin, "aws", ""),
+			// Not installed.
+			plugin(workspace.ResourcePlugin, "gcp", "6.1.0"),
+		},
+	}
+
+	assert.Equal(t, [][]string{
+		{"dev", "test"},
+		{"prod"},
+		{},
+		{},
+	}, getPluginStacks(installed, stackPlugins))
+}
+
+//nolint:paralleltest // mutates environment variables
+func TestGetStackPluginsWithoutPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	b, err := filestate.New(cmdutil.Diag(), "file://"+filepath.ToSlash(dir))
+	require.NoError(t, err)
+
+	// Write a checkpoint whose provider has a secret input encrypted with a passphrase that is not available. The
+	// checkpoint is written directly so that no secrets manager for its passphrase is cached by this process.
+	stacks := filepath.Join(dir, workspace.BookkeepingDir, workspace.StackDir)
+	require.NoError(t, os.MkdirAll(stacks, 0700))
+	err = ioutil.WriteFile(filepath.Join(stacks, "dev.json"), []byte(`{
+  "version": 3,
+  "checkpoint": {
+    "stack": "dev",
+    "latest": {
+      "manifest": {"time": "2022-01-01T00:00:00Z", "magic": "", "version": ""},
+      "secrets_providers": {"type": "passphrase",
+        "state": {"salt": "v1:nnfVDhQ7YpE=:v1:Ra4x2bJ2q0J2wzsb:7d5Bh0mDq1CIzq9gfcBrWrcCbMsHCg=="}},
+      "resources": [{
+        "urn": "urn:pulumi:dev::proj::pulumi:providers:aws::default",
+        "type": "pulumi:providers:aws",
+        "custom": true,
+        "id": "id",
+        "inputs": {"version": "5.1.0", "accessKey": {
+          "4dabf18193072939515e22adb298388d": "1b47061264138c4ac30d75fd1eb44270",
+          "ciphertext": "v1:AAAAAAAAAAAAAAAA:AAAAAAAAAAAAAAAAAAAAAA=="}}
+      }]
+    }
+  }
+}`), 0600)
+	require.NoError(t, err)
+
+	t.Setenv("PULUMI_CONFIG_PASSPHRASE", "")
+	require.NoError(t, os.Unsetenv("PULUMI_CONFIG_PASSPHRASE"))
+	stackPlugins, err := getStackPlugins(context.Background(), b)
+	require.NoError(t, err)
+	require.Len(t, stackPlugins["dev"], 1)
+	assert.Equal(t, "aws", stackPlugins["dev"][0].Name)
+	assert.Equal(t, "5.1.0", stackPlugins["dev"][0].Version.String())
+}
@@ -0,0 +1,235 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/blang/semver"
+	"github.com/dustin/go-humanize"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func newPluginPruneCmd() *cobra.Command {
+	var keep int
+	var lockFiles []string
+	var dryRun bool
+	var yes bool
+	var cmd = &cobra.Command{
+		Use:   "prune",
+		Args:  cmdutil.NoArgs,
+		Short: "Remove old versions of plugins from the download cache",
+		Long: "Remove old versions of plugins from the download cache.\n" +
+			"\n" +
+			"This command keeps the --keep newest versions of each plugin, along with any version\n" +
+			"that is referenced by the checkpoint of a stack in the current backend or by the\n" +
+			"pulumi-plugins.lock file of the current project or of any project given with\n" +
+			"--lock-file, and removes every other version.  Plugins that are being installed are\n" +
+			"not removed until their installation completes.  Checkpoints are read without\n" +
+			"decrypting their secrets, so the stacks' passphrases and keys are not needed.\n" +
+			"\n" +
+			"This removal cannot be undone.  If a deleted plugin is subsequently required\n" +
+			"in order to execute a Pulumi program, it must be re-downloaded and installed\n" +
+			"using the plugin install command.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			yes = yes || skipConfirmations()
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+
+			if keep < 0 {
+				return fmt.Errorf("--keep must not be negative")
+			}
+
+			installed, err := workspace.GetPlugins()
+			if err != nil {
+				return fmt.Errorf("loading plugins: %w", err)
+			}
+
+			// Gather the plugins that are referenced by lock files and by the checkpoints of the backend's stacks.
+			referenced := newPluginReferences()
+			if _, root, err := readProject(); err == nil {
+				lock, err := workspace.LoadPluginLock(root)
+				if err != nil {
+					return err
+				}
+				referenced.addLock(lock)
+			}
+			for _, path := range lockFiles {
+				lock, err := workspace.LoadPluginLockFile(path)
+				if err != nil {
+					return fmt.Errorf("loading %s: %w", path, err)
+				}
+				referenced.addLock(lock)
+			}
+
+			b, err := currentBackend(opts)
+			if err != nil {
+				return err
+			}
+			stackPlugins, err := getStackPlugins(commandContext(), b)
+			if err != nil {
+				return err
+			}
+			for _, plugins := range stackPlugins {
+				for _, plugin := range plugins {
+					referenced.add(plugin.Kind, plugin.Name, plugin.Version)
+				}
+			}
+
+			deletes := selectPluginsToPrune(installed, keep, referenced)
+			if len(deletes) == 0 {
+				cmdutil.Diag().Infof(
+					diag.Message("", "no plugins found to prune"))
+				return nil
+			}
+
+			// Compute the size of each plugin that will be removed.
+			var total uint64
+			for i := range deletes {
+				if path, err := deletes[i].DirPath(); err == nil {
+					contract.IgnoreError(deletes[i].SetFileMetadata(path))
+				}
+				total += uint64(deletes[i].Size)
+			}
+
+			var suffix string
+			if len(deletes) != 1 {
+				suffix = "s"
+			}
+			fmt.Print(
+				opts.Color.Colorize(
+					fmt.Sprintf("%sThis will remove %d plugin%s (%s) from the cache:%s\n",
+						colors.SpecAttention, len(deletes), suffix, humanize.Bytes(total), colors.Reset)))
+			for _, del := range deletes {
+				fmt.Printf("    %s %s (%s)\n", del.Kind, del.String(), humanize.Bytes(uint64(del.Size)))
+			}
+			if dryRun {
+				return nil
+			}
+
+			if yes || confirmPrompt("", "yes", opts) {
+				var freed uint64
+				var result error
+				for _, plugin := range deletes {
+					if err := plugin.DeleteWithLock(); err != nil {
+						result = multierror.Append(
+							result, fmt.Errorf("failed to delete %s plugin %s: %w", plugin.Kind, plugin, err))
+						continue
+					}
+					freed += uint64(plugin.Size)
+				}
+				fmt.Printf("Freed %s\n", humanize.Bytes(freed))
+				if result != nil {
+					return result
+				}
+			}
+
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().IntVar(
+		&keep, "keep", 1,
+		"The number of newest versions of each plugin to keep")
+	cmd.PersistentFlags().StringSliceVar(
+		&lockFiles, "lock-file", nil,
+		"Also keep the plugin versions that the given pulumi-plugins.lock files reference")
+	cmd.PersistentFlags().BoolVar(
+		&dryRun, "dry-run", false,
+		"Only list the plugins that would be removed and the space that would be freed")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false,
+		"Skip confirmation prompts, and proceed with removal anyway")
+
+	return cmd
+}
+
+// pluginReferences is a set of referenced plugin versions. A reference to a plugin without a version references the
+// plugin's newest installed version.
+type pluginReferences map[string]bool
+
+func newPluginReferences() pluginReferences {
+	return pluginReferences{}
+}
+
+func pluginReferenceKey(kind workspace.PluginKind, name string, version *semver.Version) string {
+	v := "latest"
+	if version != nil {
+		v = version.String()
+	}
+	return fmt.Sprintf("%s/%s/%s", kind, name, v)
+}
+
+func (refs pluginReferences) add(kind workspace.PluginKind, name string, version *semver.Version) {
+	refs[pluginReferenceKey(kind, name, version)] = true
+}
+
+func (refs pluginReferences) addLock(lock *workspace.PluginLock) {
+	if lock == nil {
+		return
+	}
+	for _, p := range lock.Plugins {
+		v := p.Version
+		refs.add(p.Kind, p.Name, &v)
+	}
+}
+
+// selectPluginsToPrune returns the installed plugins to remove: every version of each plugin other than its keep
+// newest versions and the versions that are referenced.
+func selectPluginsToPrune(installed []workspace.PluginInfo, keep int,
+	referenced pluginReferences) []workspace.PluginInfo {
+
+	// Group the installed versions of each plugin, newest first.
+	byPlugin := map[string][]workspace.PluginInfo{}
+	var names []string
+	for _, plugin := range installed {
+		if plugin.Version == nil {
+			continue
+		}
+		key := pluginReferenceKey(plugin.Kind, plugin.Name, nil)
+		if _, has := byPlugin[key]; !has {
+			names = append(names, key)
+		}
+		byPlugin[key] = append(byPlugin[key], plugin)
+	}
+	sort.Strings(names)
+
+	var deletes []workspace.PluginInfo
+	for _, name := range names {
+		versions := byPlugin[name]
+		sort.Slice(versions, func(i, j int) bool {
+			return versions[i].Version.GT(*versions[j].Version)
+		})
+		for i, plugin := range versions {
+			if i < keep || (i == 0 && referenced[name]) ||
+				referenced[pluginReferenceKey(plugin.Kind, plugin.Name, plugin.Version)] {
+				continue
+			}
+			deletes = append(deletes, plugin)
+		}
+	}
+	return deletes
+}
@@ -0,0 +1,113 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func TestSelectPluginsToPrune(t *testing.T) {
+	t.Parallel()
+
+	plugin := func(kind workspace.PluginKind, name, version string) workspace.PluginInfo {
+		v := semver.MustParse(version)
+		return workspace.PluginInfo{Kind: kind, Name: name, Version: &v}
+	}
+	installed := []workspace.PluginInfo{
+		plugin(workspace.ResourcePlugin, "aws", "4.0.0"),
+		plugin(workspace.ResourcePlugin, "aws", "5.1.0"),
+		plugin(workspace.ResourcePlugin, "aws", "4.2.0"),
+		plugin(workspace.ResourcePlugin, "aws", "5.0.0"),
+		plugin(workspace.ResourcePlugin, "gcp", "6.0.0"),
+		plugin(workspace.ResourcePlugin, "gcp", "6.1.0"),
+		plugin(workspace.AnalyzerPlugin, "gcp", "1.0.0"),
+	}
+
+	referenced := newPluginReferences()
+	v420 := semver.MustParse("4.2.0")
+	referenced.add(workspace.ResourcePlugin, "aws", &v420)
+	referenced.add(workspace.ResourcePlugin, "gcp", nil)
+
+	deletes := selectPluginsToPrune(installed, 2, referenced)
+	assert.Equal(t, []workspace.PluginInfo{
+		plugin(workspace.ResourcePlugin, "aws", "4.0.0"),
+	}, deletes)
+
+	deletes = selectPluginsToPrune(installed, 0, referenced)
+	assert.Equal(t, []workspace.PluginInfo{
+		plugin(workspace.AnalyzerPlugin, "gcp", "1.0.0"),
+		plugin(workspace.ResourcePlugin, "aws", "5.1.0"),
+		plugin(workspace.ResourcePlugin, "aws", "5.0.0"),
+		plugin(workspace.ResourcePlugin, "aws", "4.0.0"),
+		plugin(workspace.ResourcePlugin, "gcp", "6.0.0"),
+	}, deletes)
+}
+
+func TestPluginReferencesFromLockFile(t *testing.T) {
+	t.Parallel()
+
+	// Lock files may give versions with a leading "v".
+	path := filepath.Join(t.TempDir(), workspace.PluginLockFile)
+	err := ioutil.WriteFile(path,
+		[]byte(`{"plugins": [{"kind": "resource", "name": "aws", "version": "v5.1.0"}]}`), 0600)
+	require.NoError(t, err)
+	lock, err := workspace.LoadPluginLockFile(path)
+	require.NoError(t, err)
+
+	referenced := newPluginReferences()
+	referenced.addLock(lock)
+	v := semver.MustParse("5.1.0")
+	assert.True(t, referenced[pluginReferenceKey(workspace.ResourcePlugin, "aws", &v)])
+}
+
+func TestGetDeploymentPlugins(t *testing.T) {
+	t.Parallel()
+
+	// The deployment's secrets are never decrypted, so its secrets provider need not be available.
+	var deployment apitype.DeploymentV3
+	err := json.Unmarshal([]byte(`{
+  "manifest": {"time": "2022-01-01T00:00:00Z", "magic": "", "version": "",
+               "plugins": [{"name": "nodejs", "type": "language", "version": "v3.0.0"}]},
+  "secrets_providers": {"type": "passphrase", "state": {"salt": "v1:unavailable"}},
+  "resources": [
+    {"urn": "urn:pulumi:dev::proj::pulumi:providers:aws::default", "type": "pulumi:providers:aws",
+     "custom": true, "id": "id", "inputs": {"version": "5.1.0", "accessKey": {
+       "4dabf18193072939515e22adb298388d": "1b47061264138c4ac30d75fd1eb44270", "ciphertext": "v1:x:y"}}},
+    {"urn": "urn:pulumi:dev::proj::aws:s3/bucket:Bucket::b", "type": "aws:s3/bucket:Bucket",
+     "custom": true, "id": "b", "inputs": {"version": "1.0.0"}}
+  ]
+}`), &deployment)
+	require.NoError(t, err)
+
+	plugins, err := getDeploymentPlugins(&deployment)
+	require.NoError(t, err)
+	require.Len(t, plugins, 2)
+	assert.Equal(t, workspace.LanguagePlugin, plugins[0].Kind)
+	assert.Equal(t, "nodejs", plugins[0].Name)
+	assert.Equal(t, "3.0.0", plugins[0].Version.String())
+	assert.Equal(t, workspace.ResourcePlugin, plugins[1].Kind)
+	assert.Equal(t, "aws", plugins[1].Name)
+	assert.Equal(t, "5.1.0", plugins[1].Version.String())
+}
@@ -0,0 +1,191 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func newPluginVendorCmd() *cobra.Command {
+	var dir string
+	var platforms []string
+	var force bool
+
+	var cmd = &cobra.Command{
+		Use:   "vendor",
+		Args:  cmdutil.NoArgs,
+		Short: "Download the plugins that the current project needs into a mirror directory",
+		Long: "Download the plugins that the current project needs into a mirror directory.\n" +
+			"\n" +
+			"This command downloads the tarball of each plugin that the current project may require\n" +
+			"for the current platform and for each platform given with --platform (e.g. linux/arm64).\n" +
+			"Plugins are downloaded at the versions that the project's pulumi-plugins.lock file\n" +
+			"records, if any, and are checked against the lock file's checksums.\n" +
+			"\n" +
+			"The mirror directory holds one tarball per plugin and platform, named like the tarballs\n" +
+			"of a plugin download server, e.g. pulumi-resource-aws-v5.0.0-linux-amd64.tar.gz, along\n" +
+			"with the tarball's .minisig signature if the plugin's source publishes one. It can\n" +
+			"be served over HTTP, or used directly on machines without internet access as a file://\n" +
+			"plugin download URL, e.g. `pulumi plugin install --server file:///path/to/mirror`.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			displayOpts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+
+			if dir == "" {
+				return errors.New("missing required flag --dir")
+			}
+			vendorPlatforms, err := parsePluginPlatforms(platforms)
+			if err != nil {
+				return err
+			}
+
+			_, root, err := readProject()
+			if err != nil {
+				return err
+			}
+			lock, err := workspace.LoadPluginLock(root)
+			if err != nil {
+				return err
+			}
+			plugins, err := getProjectPlugins()
+			if err != nil {
+				return err
+			}
+
+			if err = os.MkdirAll(dir, 0700); err != nil {
+				return err
+			}
+
+			vendored := 0
+			for _, plugin := range plugins {
+				// Language plugins are installed with the CLI rather than downloaded.
+				if plugin.Kind == workspace.LanguagePlugin {
+					continue
+				}
+
+				plugin = lock.Resolve(plugin)
+				if plugin.Version == nil {
+					version, err := plugin.GetLatestVersion()
+					if err != nil {
+						return fmt.Errorf("could not get latest version for %s plugin %s: %w", plugin.Kind, plugin.Name, err)
+					}
+					plugin.Version = version
+				}
+
+				for _, platform := range vendorPlatforms {
+					label := fmt.Sprintf("[%s plugin %s %s]", plugin.Kind, plugin, platform)
+
+					path := filepath.Join(dir, plugin.TarballName(platform.OS, platform.Arch))
+					if _, err := os.Stat(path); err == nil && !force {
+						logging.V(1).Infof("%s skipping download (already vendored)", label)
+						continue
+					}
+
+					cmdutil.Diag().Infoerrf(diag.Message("", "%s downloading"), label)
+					if err := vendorPlugin(lock, plugin, platform, path, displayOpts); err != nil {
+						return fmt.Errorf("%s vendoring: %w", label, err)
+					}
+					vendored++
+				}
+			}
+
+			fmt.Printf("Vendored %d plugin tarball(s) into %s\n", vendored, dir)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVar(&dir,
+		"dir", "", "The mirror directory to download the plugins into")
+	cmd.PersistentFlags().StringSliceVar(&platforms,
+		"platform", nil, "Also download the plugins for the given OS/ARCH platforms")
+	cmd.PersistentFlags().BoolVar(&force,
+		"force", false, "Download the plugins even if they have already been vendored")
+
+	return cmd
+}
+
+// vendorPlugin downloads the tarball of the given plugin for the given platform to the given path. If the plugin is
+// locked, the tarball is downloaded from its locked URL and must match its locked checksum.
+func vendorPlugin(lock *workspace.PluginLock, plugin workspace.PluginInfo, platform pluginPlatform, path string,
+	displayOpts display.Options) error {
+
+	artifact, locked := lock.Artifact(plugin, platform.OS, platform.Arch)
+
+	var tarball io.ReadCloser
+	var size int64
+	var err error
+	if locked {
+		tarball, size, err = artifact.Download()
+	} else {
+		tarball, size, _, err = plugin.DownloadArtifact(platform.OS, platform.Arch)
+	}
+	if err != nil {
+		return err
+	}
+	hashing := workspace.NewHashingReader(
+		workspace.ReadCloserProgressBar(tarball, size, "Downloading plugin", displayOpts.Color))
+	defer contract.IgnoreClose(hashing)
+
+	// Download to a temporary file so that an interrupted download never leaves a partial tarball in the mirror.
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".download")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		contract.IgnoreClose(f)
+		contract.IgnoreError(os.Remove(f.Name()))
+	}()
+
+	if _, err = io.Copy(f, hashing); err != nil {
+		return err
+	}
+	sum, err := hashing.Sum()
+	if err != nil {
+		return err
+	}
+	if locked && sum != artifact.SHA256 {
+		return &workspace.IntegrityError{Plugin: plugin, Expected: artifact.SHA256, Actual: sum}
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+
+	// Vendor the tarball's detached signature too, if its source publishes one.
+	signature, err := plugin.DownloadSignature(platform.OS, platform.Arch)
+	if err != nil {
+		logging.V(1).Infof("%s %s: no signature vendored: %v", plugin, platform, err)
+		return nil
+	}
+	return ioutil.WriteFile(path+workspace.PluginSignatureSuffix, signature, 0600)
+}
@@ -51,6 +51,8 @@ func newPreviewCmd() *cobra.Command {
 	var diffDisplay bool
 	var eventLogPath string
 	var parallel int
+	var recordProviders string
+	var replayProviders string
 	var refresh string
 	var showConfig bool
 	var showReplacementSteps bool
@@ -81,6 +83,10 @@ func newPreviewCmd() *cobra.Command {
 			"`--cwd` flag to use a different directory.",
 		Args: cmdutil.NoArgs,
 		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			if err := resolveProviderRecordingFlags(&recordProviders, &replayProviders); err != nil {
+				return result.FromError(err)
+			}
+
 			var displayType = display.DisplayProgress
 			if diffDisplay {
 				displayType = display.DisplayDiff
@@ -186,6 +192,8 @@ func newPreviewCmd() *cobra.Command {
 					UpdateTargets:             targetURNs,
 					TargetDependents:          targetDependents,
 					ExperimentalPlans:         hasExperimentalCommands() || planFilePath != "",
+					RecordProviders:           recordProviders,
+					ReplayProviders:           replayProviders,
 				},
 				Display: displayOpts,
 			}
@@ -292,6 +300,7 @@ func newPreviewCmd() *cobra.Command {
 	cmd.Flags().BoolVarP(
 		&jsonDisplay, "json", "j", false,
 		"Serialize the preview diffs, operations, and overall output as JSON")
+	addProviderRecordingFlags(cmd, &recordProviders, &replayProviders)
 	cmd.PersistentFlags().IntVarP(
 		&parallel, "parallel", "p", defaultParallel,
 		"Allow P resource operations to run in parallel at once (1 for no parallelism). Defaults to unbounded.")
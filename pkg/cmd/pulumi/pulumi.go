@@ -204,6 +204,7 @@ func NewPulumiCmd() *cobra.Command {
 	cmd.AddCommand(newCancelCmd())
 	cmd.AddCommand(newImportCmd())
 	cmd.AddCommand(newRefreshCmd())
+	cmd.AddCommand(newDriftCmd())
 	cmd.AddCommand(newStateCmd())
 	//     - Other Commands:
 	cmd.AddCommand(newLogsCmd())
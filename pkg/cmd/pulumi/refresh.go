@@ -42,6 +42,8 @@ func newRefreshCmd() *cobra.Command {
 	var diffDisplay bool
 	var eventLogPath string
 	var parallel int
+	var recordProviders string
+	var replayProviders string
 	var showConfig bool
 	var showReplacementSteps bool
 	var showSames bool
@@ -65,6 +67,10 @@ func newRefreshCmd() *cobra.Command {
 			"`--cwd` flag to use a different directory.",
 		Args: cmdutil.NoArgs,
 		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			if err := resolveProviderRecordingFlags(&recordProviders, &replayProviders); err != nil {
+				return result.FromError(err)
+			}
+
 			yes = yes || skipConfirmations()
 			interactive := cmdutil.Interactive()
 			if !interactive && !yes {
@@ -151,6 +157,8 @@ func newRefreshCmd() *cobra.Command {
 				DisableResourceReferences: disableResourceReferences(),
 				DisableOutputValues:       disableOutputValues(),
 				RefreshTargets:            targetUrns,
+				RecordProviders:           recordProviders,
+				ReplayProviders:           replayProviders,
 			}
 
 			changes, res := s.Refresh(commandContext(), backend.UpdateOperation{
@@ -204,6 +212,7 @@ func newRefreshCmd() *cobra.Command {
 	cmd.Flags().BoolVarP(
 		&jsonDisplay, "json", "j", false,
 		"Serialize the refresh diffs, operations, and overall output as JSON")
+	addProviderRecordingFlags(cmd, &recordProviders, &replayProviders)
 	cmd.PersistentFlags().IntVarP(
 		&parallel, "parallel", "p", defaultParallel,
 		"Allow P resource operations to run in parallel at once (1 for no parallelism). Defaults to unbounded.")
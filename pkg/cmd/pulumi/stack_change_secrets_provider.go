@@ -34,7 +34,8 @@ func newStackChangeSecretsProviderCmd() *cobra.Command {
 		Args:  cmdutil.ExactArgs(1),
 		Short: "Change the secrets provider for the current stack",
 		Long: "Change the secrets provider for the current stack. " +
-			"Valid secret providers types are `default`, `passphrase`, `awskms`, `azurekeyvault`, `gcpkms`, `hashivault`.\n\n" +
+			"Valid secret providers types are `default`, `passphrase`, `age`, `awskms`, `azurekeyvault`, `gcpkms`, " +
+			"`hashivault`.\n\n" +
 			"To change to using the Pulumi Default Secrets Provider, use the following:\n" +
 			"\n" +
 			"pulumi stack change-secrets-provider default" +
@@ -50,7 +51,15 @@ func newStackChangeSecretsProviderCmd() *cobra.Command {
 			"\"azurekeyvault://mykeyvaultname.vault.azure.net/keys/mykeyname\"`\n" +
 			"* `pulumi stack change-secrets-provider " +
 			"\"gcpkms://projects/<p>/locations/<l>/keyRings/<r>/cryptoKeys/<k>\"`\n" +
-			"* `pulumi stack change-secrets-provider \"hashivault://mykey\"`",
+			"* `pulumi stack change-secrets-provider \"hashivault://mykey\"`" +
+			"\n" +
+			"\n" +
+			"To change the stack to encrypt its data key to one or more age recipients, use the following:\n" +
+			"\n" +
+			"* `pulumi stack change-secrets-provider \"age://<recipient>[,<recipient>...]\"`\n" +
+			"\n" +
+			"The age identity used to decrypt the data key is read from the file named by PULUMI_AGE_IDENTITY_FILE.\n" +
+			"Adding or removing a recipient only re-encrypts the data key.",
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 			opts := display.Options{
 				Color: cmdutil.GetGlobalColorization(),
@@ -26,7 +26,7 @@ import (
 
 const (
 	possibleSecretsProviderChoices = "The type of the provider that should be used to encrypt and decrypt secrets\n" +
-		"(possible choices: default, passphrase, awskms, azurekeyvault, gcpkms, hashivault)"
+		"(possible choices: default, passphrase, age, awskms, azurekeyvault, gcpkms, hashivault)"
 )
 
 func newStackInitCmd() *cobra.Command {
@@ -63,6 +63,10 @@ func newStackInitCmd() *cobra.Command {
 			"* `pulumi stack init --secrets-provider=\"gcpkms://projects/<p>/locations/<l>/keyRings/<r>/cryptoKeys/<k>\"`\n" +
 			"* `pulumi stack init --secrets-provider=\"hashivault://mykey\"\n`" +
 			"\n" +
+			"To encrypt secrets to one or more age recipients, use the following:\n" +
+			"\n" +
+			"* `pulumi stack init --secrets-provider=\"age://<recipient>[,<recipient>...]\"`\n" +
+			"\n" +
 			"A stack can be created based on the configuration of an existing stack by passing the\n" +
 			"`--copy-config-from` flag.\n" +
 			"* `pulumi stack init --copy-config-from dev`",
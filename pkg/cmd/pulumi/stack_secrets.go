@@ -0,0 +1,384 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/backend/httpstate"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/passphrase"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/service"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func newStackSecretsCmd() *cobra.Command {
+	var stack string
+
+	cmd := &cobra.Command{
+		Use:   "secrets",
+		Short: "Inspect the secrets held by a stack",
+		Long: "Inspect the secrets held by a stack\n" +
+			"\n" +
+			"A stack holds secrets in its configuration and in the inputs and outputs of its\n" +
+			"resources. The `list` command reports where each of these secrets lives and which\n" +
+			"secrets manager encrypts it.\n",
+		Args: cmdutil.NoArgs,
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "", "The name of the stack to operate on. Defaults to the current stack")
+
+	cmd.AddCommand(newStackSecretsListCmd(&stack))
+
+	return cmd
+}
+
+func newStackSecretsListCmd(stackName *string) *cobra.Command {
+	var jsonOut bool
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List every secret in a stack's configuration and state",
+		Long: "List every secret in a stack's configuration and state\n" +
+			"\n" +
+			"This command reports the location of each secret held by a stack: its configuration\n" +
+			"key or resource URN, its property path, and the secrets manager that encrypts it.\n" +
+			"Secret values are never decrypted for display.\n" +
+			"\n" +
+			"Values that look like credentials but are not marked as secret are reported\n" +
+			"separately so that they can be reviewed.",
+		Args: cmdutil.NoArgs,
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+
+			s, err := requireStack(*stackName, false, opts, false /*setCurrent*/)
+			if err != nil {
+				return err
+			}
+
+			ps, err := loadProjectStack(s)
+			if err != nil {
+				return err
+			}
+			entries, err := listConfigSecrets(ps.Config, stackConfigSecretsManager(s, ps))
+			if err != nil {
+				return err
+			}
+
+			// Read the stack's state without decrypting it, so that listing its secrets does not require access to
+			// the key that encrypts them.
+			untyped, err := s.ExportDeployment(commandContext())
+			if err != nil {
+				return err
+			}
+			deployment, err := stack.UpgradeUntypedDeployment(untyped)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, listStateSecrets(deployment)...)
+
+			if jsonOut {
+				if entries == nil {
+					entries = []stackSecretJSON{}
+				}
+				return printJSON(entries)
+			}
+
+			printStackSecrets(entries)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().BoolVarP(
+		&jsonOut, "json", "j", false, "Emit output as JSON")
+
+	return cmd
+}
+
+// Sources of secrets reported by `pulumi stack secrets list`.
+const (
+	secretSourceConfig  = "config"
+	secretSourceInputs  = "inputs"
+	secretSourceOutputs = "outputs"
+)
+
+// stackSecretJSON describes the location of a single secret, or of a value that looks like it should be secret.
+type stackSecretJSON struct {
+	// Source is where the value lives: "config", "inputs", or "outputs".
+	Source string `json:"source"`
+	// Key is the configuration key that holds the value. Only set for config values.
+	Key string `json:"key,omitempty"`
+	// URN is the URN of the resource that holds the value. Only set for resource inputs and outputs.
+	URN resource.URN `json:"urn,omitempty"`
+	// Path is the property path of the value within its configuration key or resource property map.
+	Path string `json:"path,omitempty"`
+	// SecretsManager describes the secrets manager that encrypted the value. Only set for secret values.
+	SecretsManager string `json:"secretsManager,omitempty"`
+	// Secret is true if the value is marked as secret. If false, the value is plaintext that looks like a
+	// credential.
+	Secret bool `json:"secret"`
+}
+
+// stackConfigSecretsManager describes the secrets manager that encrypts a stack's configuration without creating it,
+// which would otherwise require credentials such as a passphrase.
+func stackConfigSecretsManager(s backend.Stack, ps *workspace.ProjectStack) string {
+	switch {
+	case ps.SecretsProvider != passphrase.Type && ps.SecretsProvider != "default" && ps.SecretsProvider != "":
+		return ps.SecretsProvider
+	case ps.EncryptionSalt != "":
+		return passphrase.Type
+	}
+
+	if _, ok := s.(httpstate.Stack); ok {
+		return service.Type
+	}
+	return passphrase.Type
+}
+
+// describeSecretsManager describes the secrets manager of a deployment by its type and, if it has one, the URL of its
+// key.
+func describeSecretsManager(sp *apitype.SecretsProvidersV1) string {
+	if sp == nil {
+		return ""
+	}
+
+	var state struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(sp.State, &state); err == nil && state.URL != "" {
+		return fmt.Sprintf("%s (%s)", sp.Type, state.URL)
+	}
+	return sp.Type
+}
+
+// listConfigSecrets returns the secrets in cfg along with any plaintext values that look like secrets.
+func listConfigSecrets(cfg config.Map, secretsManager string) ([]stackSecretJSON, error) {
+	var keys config.KeyArray
+	for key := range cfg {
+		keys = append(keys, key)
+	}
+	sort.Sort(keys)
+
+	var entries []stackSecretJSON
+	for _, key := range keys {
+		v := cfg[key]
+		if !v.Secure() {
+			if v.Object() {
+				obj, err := v.ToObject()
+				if err != nil {
+					return nil, err
+				}
+				walkPlaintextConfig(obj, nil, func(path resource.PropertyPath, name, value string) {
+					if looksLikeSecretProperty(name, value) {
+						entries = append(entries, stackSecretJSON{
+							Source: secretSourceConfig,
+							Key:    key.String(),
+							Path:   path.String(),
+						})
+					}
+				})
+			} else if looksLikeSecret(key, mustPlaintext(v)) {
+				entries = append(entries, stackSecretJSON{
+					Source: secretSourceConfig,
+					Key:    key.String(),
+				})
+			}
+			continue
+		}
+
+		paths, err := v.SecurePaths()
+		if err != nil {
+			return nil, fmt.Errorf("reading configuration value %v: %w", key, err)
+		}
+		for _, path := range paths {
+			entries = append(entries, stackSecretJSON{
+				Source:         secretSourceConfig,
+				Key:            key.String(),
+				Path:           path.String(),
+				SecretsManager: secretsManager,
+				Secret:         true,
+			})
+		}
+	}
+	return entries, nil
+}
+
+// mustPlaintext returns the value of a plaintext configuration entry.
+func mustPlaintext(v config.Value) string {
+	s, err := v.Value(config.NewPanicCrypter())
+	contract.AssertNoError(err)
+	return s
+}
+
+// walkPlaintextConfig calls visit for each string within a plaintext configuration object, passing its path, the
+// name of the key that holds it (if any), and its value.
+func walkPlaintextConfig(v interface{}, path resource.PropertyPath,
+	visit func(path resource.PropertyPath, name, value string)) {
+
+	switch t := v.(type) {
+	case string:
+		var name string
+		if len(path) > 0 {
+			name, _ = path[len(path)-1].(string)
+		}
+		visit(path, name, t)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for key := range t {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walkPlaintextConfig(t[key], append(path, key), visit)
+		}
+	case []interface{}:
+		for i, val := range t {
+			walkPlaintextConfig(val, append(path, i), visit)
+		}
+	}
+}
+
+// listStateSecrets returns the secrets in the inputs and outputs of the resources in a deployment along with any
+// plaintext values that look like secrets. Secrets are found by their serialized form, so they are never decrypted.
+func listStateSecrets(deployment *apitype.DeploymentV3) []stackSecretJSON {
+	if deployment == nil {
+		return nil
+	}
+
+	secretsManager := describeSecretsManager(deployment.SecretsProviders)
+
+	var entries []stackSecretJSON
+	for _, res := range deployment.Resources {
+		for _, props := range []struct {
+			source string
+			props  map[string]interface{}
+		}{
+			{secretSourceInputs, res.Inputs},
+			{secretSourceOutputs, res.Outputs},
+		} {
+			keys := make([]string, 0, len(props.props))
+			for key := range props.props {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				walkStateProperty(props.props[key], resource.PropertyPath{key},
+					func(path resource.PropertyPath, secret bool) {
+						entry := stackSecretJSON{
+							Source: props.source,
+							URN:    res.URN,
+							Path:   path.String(),
+							Secret: secret,
+						}
+						if secret {
+							entry.SecretsManager = secretsManager
+						}
+						entries = append(entries, entry)
+					})
+			}
+		}
+	}
+	return entries
+}
+
+// walkStateProperty calls report with the path of each secret within the serialized property value v and of each
+// plaintext string within v that looks like a secret.
+func walkStateProperty(v interface{}, path resource.PropertyPath,
+	report func(path resource.PropertyPath, secret bool)) {
+
+	switch t := v.(type) {
+	case string:
+		if name, ok := path[len(path)-1].(string); ok && looksLikeSecretProperty(name, t) {
+			report(path, false)
+		}
+	case []interface{}:
+		for i, e := range t {
+			walkStateProperty(e, append(path, i), report)
+		}
+	case map[string]interface{}:
+		// Of the values that are serialized with a signature, only secrets are of interest. Assets, archives and
+		// resource references are skipped.
+		if sig, ok := t[resource.SigKey]; ok {
+			if sig == resource.SecretSig {
+				report(path, true)
+			}
+			return
+		}
+
+		keys := make([]string, 0, len(t))
+		for key := range t {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walkStateProperty(t[key], append(path, key), report)
+		}
+	}
+}
+
+func printStackSecrets(entries []stackSecretJSON) {
+	var secretRows, plaintextRows []cmdutil.TableRow
+	for _, e := range entries {
+		location, path := e.Key, e.Path
+		if e.Source != secretSourceConfig {
+			location = string(e.URN)
+		}
+		if path == "" {
+			path = "-"
+		}
+
+		if e.Secret {
+			secretRows = append(secretRows, cmdutil.TableRow{
+				Columns: []string{e.Source, location, path, e.SecretsManager},
+			})
+		} else {
+			plaintextRows = append(plaintextRows, cmdutil.TableRow{
+				Columns: []string{e.Source, location, path},
+			})
+		}
+	}
+
+	if len(secretRows) == 0 {
+		fmt.Printf("This stack has no secrets\n")
+	} else {
+		cmdutil.PrintTable(cmdutil.Table{
+			Headers: []string{"SOURCE", "LOCATION", "PATH", "SECRETS MANAGER"},
+			Rows:    secretRows,
+		})
+	}
+
+	if len(plaintextRows) > 0 {
+		fmt.Printf("\nThe following values look like credentials but are not marked as secret:\n")
+		cmdutil.PrintTable(cmdutil.Table{
+			Headers: []string{"SOURCE", "LOCATION", "PATH"},
+			Rows:    plaintextRows,
+			Prefix:  "    ",
+		})
+	}
+}
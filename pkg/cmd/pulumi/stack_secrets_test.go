@@ -0,0 +1,91 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/b64"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+)
+
+func TestListConfigSecrets(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Map{
+		config.MustMakeKey("test", "plain"):     config.NewValue("hello"),
+		config.MustMakeKey("test", "apiToken"):  config.NewValue("1415fc1f4eaeb5e096ee58c1480016638fff29bf"),
+		config.MustMakeKey("test", "password"):  config.NewSecureValue("ciphertext"),
+		config.MustMakeKey("test", "database"):  config.NewSecureObjectValue(`{"user":"admin","pass":{"secure":"c"}}`),
+		config.MustMakeKey("test", "endpoints"): config.NewObjectValue(`[{"token":"1415fc1f4eaeb5e096ee58c1480016638fff29bf"}]`),
+	}
+
+	entries, err := listConfigSecrets(cfg, "passphrase")
+	assert.NoError(t, err)
+	assert.Equal(t, []stackSecretJSON{
+		{Source: "config", Key: "test:apiToken"},
+		{Source: "config", Key: "test:database", Path: "pass", SecretsManager: "passphrase", Secret: true},
+		{Source: "config", Key: "test:endpoints", Path: "[0].token"},
+		{Source: "config", Key: "test:password", SecretsManager: "passphrase", Secret: true},
+	}, entries)
+}
+
+func TestListStateSecrets(t *testing.T) {
+	t.Parallel()
+
+	urn := resource.NewURN("stack", "proj", "", "pkg:index:typ", "res")
+	snap := &deploy.Snapshot{
+		SecretsManager: b64.NewBase64SecretsManager(),
+		Resources: []*resource.State{{
+			URN: urn,
+			Inputs: resource.PropertyMap{
+				"name": resource.NewStringProperty("res"),
+				"credentials": resource.NewObjectProperty(resource.PropertyMap{
+					"secret": resource.MakeSecret(resource.NewStringProperty("s3cr3t")),
+				}),
+			},
+			Outputs: resource.PropertyMap{
+				"tokens": resource.NewArrayProperty([]resource.PropertyValue{
+					resource.NewObjectProperty(resource.PropertyMap{
+						"token": resource.NewStringProperty("1415fc1f4eaeb5e096ee58c1480016638fff29bf"),
+					}),
+				}),
+			},
+		}},
+	}
+
+	// Secrets are found in the exported state, where they are encrypted.
+	deployment, err := stack.SerializeDeployment(snap, snap.SecretsManager, false /*showSecrets*/)
+	require.NoError(t, err)
+	bytes, err := json.Marshal(deployment)
+	require.NoError(t, err)
+	deployment, err = stack.UpgradeUntypedDeployment(&apitype.UntypedDeployment{
+		Version:    apitype.DeploymentSchemaVersionCurrent,
+		Deployment: bytes,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []stackSecretJSON{
+		{Source: "inputs", URN: urn, Path: "credentials.secret", SecretsManager: "b64", Secret: true},
+		{Source: "outputs", URN: urn, Path: "tokens[0].token"},
+	}, listStateSecrets(deployment))
+}
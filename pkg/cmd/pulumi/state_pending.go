@@ -0,0 +1,349 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	survey "gopkg.in/AlecAivazis/survey.v1"
+	surveycore "gopkg.in/AlecAivazis/survey.v1/core"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+func newStatePendingCommand() *cobra.Command {
+	var stack string
+
+	cmd := &cobra.Command{
+		Use:   "pending",
+		Short: "Inspect and resolve the pending operations in a stack's state",
+		Long: `Inspect and resolve the pending operations in a stack's state
+
+When an update is interrupted, the operations that were in flight are recorded in the stack's state as pending
+operations. The next update discards them, which can leave behind resources that were created but never recorded
+in the state.
+
+The 'ls' command lists the pending operations in a stack's state. The 'resolve' command resolves pending creates
+by importing the resource that was created, or by recording that it was never created.`,
+		Args: cmdutil.NoArgs,
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+
+	cmd.AddCommand(newStatePendingListCommand(&stack))
+	cmd.AddCommand(newStatePendingResolveCommand(&stack))
+	return cmd
+}
+
+// pendingOperationJSON is the JSON representation of a pending operation reported by `pulumi state pending ls`.
+type pendingOperationJSON struct {
+	URN       resource.URN           `json:"urn"`
+	Type      string                 `json:"type"`
+	Operation resource.OperationType `json:"operation"`
+	ID        resource.ID            `json:"id,omitempty"`
+}
+
+func newStatePendingListCommand(stack *string) *cobra.Command {
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List the pending operations in a stack's state",
+		Long: `List the pending operations in a stack's state
+
+This command lists the creates, updates, deletes, reads and imports that were in flight when an update was
+interrupted.`,
+		Args: cmdutil.NoArgs,
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+			s, err := requireStack(*stack, false, opts, false /*setCurrent*/)
+			if err != nil {
+				return err
+			}
+			snap, err := s.Snapshot(commandContext())
+			if err != nil {
+				return err
+			}
+
+			ops := []pendingOperationJSON{}
+			if snap != nil {
+				for _, op := range snap.PendingOperations {
+					ops = append(ops, pendingOperationJSON{
+						URN:       op.Resource.URN,
+						Type:      string(op.Resource.Type),
+						Operation: op.Type,
+						ID:        op.Resource.ID,
+					})
+				}
+			}
+
+			if jsonOut {
+				return printJSON(ops)
+			}
+
+			if len(ops) == 0 {
+				fmt.Printf("No pending operations found in stack %s\n", s.Ref())
+				return nil
+			}
+
+			rows := make([]cmdutil.TableRow, len(ops))
+			for i, op := range ops {
+				rows[i] = cmdutil.TableRow{Columns: []string{string(op.Operation), op.Type, string(op.URN)}}
+			}
+			cmdutil.PrintTable(cmdutil.Table{
+				Headers: []string{"OPERATION", "TYPE", "URN"},
+				Rows:    rows,
+			})
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().BoolVarP(
+		&jsonOut, "json", "j", false, "Emit output as JSON")
+
+	return cmd
+}
+
+// The ways in which a pending create may be resolved.
+const (
+	pendingCreateImport      = "import the resource by its ID"
+	pendingCreateMarkDeleted = "mark the resource as deleted"
+	pendingCreateKeep        = "keep the operation pending"
+)
+
+func newStatePendingResolveCommand(stack *string) *cobra.Command {
+	var importID string
+	var markDeleted bool
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "resolve [resource URN]",
+		Short: "Resolve the pending creates in a stack's state",
+		Long: `Resolve the pending creates in a stack's state
+
+A pending create records a resource that may or may not have been created by an interrupted update. Each
+pending create can be resolved in one of three ways:
+
+  - The resource can be imported by its ID. The resource's provider reads its live state, and the resource
+    is recorded in the stack's state as if the create had completed.
+  - The resource can be marked as deleted, which records that it was never created or has since been
+    deleted by hand.
+  - The create can be kept pending.
+
+When run interactively without a URN, this command prompts for a resolution of each pending create in turn.
+Otherwise, the URN of the resource and either --import-id or --mark-deleted must be given.
+
+Example:
+pulumi state pending resolve 'urn:pulumi:dev::demo::aws:s3/bucket:Bucket::my-bucket' --import-id my-bucket-1a2b3c
+`,
+		Args: cmdutil.MaximumNArgs(1),
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			yes = yes || skipConfirmations()
+			// Show the confirmation prompt if the user didn't pass the --yes parameter to skip it.
+			showPrompt := !yes
+
+			if importID != "" && markDeleted {
+				return result.Error("only one of --import-id and --mark-deleted may be specified")
+			}
+			hasResolution := importID != "" || markDeleted
+			if len(args) == 0 && hasResolution {
+				return result.Error("a resource URN must be given with --import-id or --mark-deleted")
+			}
+			if !hasResolution && !cmdutil.Interactive() {
+				return result.Error("--import-id or --mark-deleted must be specified when not running interactively")
+			}
+
+			var messages []string
+			res := runTotalStateEdit(*stack, showPrompt, func(opts display.Options, snap *deploy.Snapshot) error {
+				if snap == nil {
+					return errors.New("the stack has no state")
+				}
+
+				var ops []resource.Operation
+				if len(args) == 1 {
+					urn := resource.URN(args[0])
+					ops = edit.LocatePendingOperations(snap, urn)
+					if len(ops) == 0 {
+						return fmt.Errorf("no pending operation exists for %q", urn)
+					}
+					for _, op := range ops {
+						if op.Type != resource.OperationTypeCreating {
+							return fmt.Errorf("the pending operation on %q is not a create; pending %s operations "+
+								"are discarded by the next update", urn, op.Type)
+						}
+					}
+				} else {
+					for _, op := range snap.PendingOperations {
+						if op.Type == resource.OperationTypeCreating {
+							ops = append(ops, op)
+						}
+					}
+					if len(ops) == 0 {
+						return errors.New("no pending creates exist in the stack's state")
+					}
+				}
+
+				for _, op := range ops {
+					resolution, id := pendingCreateKeep, resource.ID(importID)
+					switch {
+					case importID != "":
+						resolution = pendingCreateImport
+					case markDeleted:
+						resolution = pendingCreateMarkDeleted
+					default:
+						var err error
+						if resolution, id, err = promptPendingCreateResolution(opts, op.Resource); err != nil {
+							return err
+						}
+					}
+
+					switch resolution {
+					case pendingCreateImport:
+						live, err := readPendingResource(snap, op.Resource, id)
+						if err != nil {
+							return err
+						}
+						if err = edit.ImportPendingCreate(snap, op.Resource, live); err != nil {
+							return err
+						}
+						messages = append(messages, fmt.Sprintf("Imported %s with ID %s", op.Resource.URN, live.ID))
+					case pendingCreateMarkDeleted:
+						if err := edit.RemovePendingOperation(snap, op.Resource); err != nil {
+							return err
+						}
+						messages = append(messages, fmt.Sprintf("Marked %s as deleted", op.Resource.URN))
+					default:
+						messages = append(messages, fmt.Sprintf("Kept the create of %s pending", op.Resource.URN))
+					}
+				}
+				return nil
+			})
+			if res != nil {
+				return res
+			}
+
+			for _, msg := range messages {
+				fmt.Println(msg)
+			}
+			return nil
+		}),
+	}
+
+	cmd.Flags().StringVar(&importID, "import-id", "",
+		"Import the resource with the given ID, as read by its provider")
+	cmd.Flags().BoolVar(&markDeleted, "mark-deleted", false,
+		"Mark the resource as deleted, discarding its pending create")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+
+	return cmd
+}
+
+// promptPendingCreateResolution asks the user how to resolve the pending create of the given resource, and, if it is
+// to be imported, the ID of the resource to import.
+func promptPendingCreateResolution(opts display.Options, res *resource.State) (string, resource.ID, error) {
+	surveycore.DisableColor = true
+	surveycore.QuestionIcon = ""
+	surveycore.SelectFocusIcon = opts.Color.Colorize(colors.BrightGreen + ">" + colors.Reset)
+	prompt := fmt.Sprintf("The create of %s is pending. How should it be resolved?", res.URN)
+	prompt = opts.Color.Colorize(colors.SpecPrompt + prompt + colors.Reset)
+
+	cmdutil.EndKeypadTransmitMode()
+
+	var resolution string
+	if err := survey.AskOne(&survey.Select{
+		Message: prompt,
+		Options: []string{pendingCreateImport, pendingCreateMarkDeleted, pendingCreateKeep},
+	}, &resolution, nil); err != nil {
+		return "", "", errors.New("no resolution selected")
+	}
+	if resolution != pendingCreateImport {
+		return resolution, "", nil
+	}
+
+	var id string
+	if err := survey.AskOne(&survey.Input{
+		Message: opts.Color.Colorize(colors.SpecPrompt + "ID of the resource to import:" + colors.Reset),
+	}, &id, survey.Required); err != nil {
+		return "", "", errors.New("no ID entered")
+	}
+	return resolution, resource.ID(id), nil
+}
+
+// readPendingResource reads the live state of the resource with the given ID using the provider recorded for the
+// given resource in the snapshot.
+func readPendingResource(snap *deploy.Snapshot, res *resource.State, id resource.ID) (plugin.ReadResult, error) {
+	if res.Provider == "" {
+		return plugin.ReadResult{}, fmt.Errorf("resource %q has no provider", res.URN)
+	}
+	ref, err := providers.ParseReference(res.Provider)
+	if err != nil {
+		return plugin.ReadResult{}, fmt.Errorf("resource %q has an invalid provider reference: %w", res.URN, err)
+	}
+
+	var providerState *resource.State
+	for _, r := range snap.Resources {
+		if r.URN == ref.URN() && r.ID == ref.ID() {
+			providerState = r
+			break
+		}
+	}
+	if providerState == nil {
+		return plugin.ReadResult{}, fmt.Errorf("the provider for %q does not exist in the stack's state", res.URN)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return plugin.ReadResult{}, err
+	}
+	sink := cmdutil.Diag()
+	ctx, err := plugin.NewContext(sink, sink, nil, nil, cwd, nil, true, nil)
+	if err != nil {
+		return plugin.ReadResult{}, err
+	}
+	defer contract.IgnoreClose(ctx)
+
+	registry, err := providers.NewRegistry(ctx.Host, []*resource.State{providerState}, false, nil)
+	if err != nil {
+		return plugin.ReadResult{}, err
+	}
+	provider, ok := registry.GetProvider(ref)
+	contract.Assertf(ok, "provider %v was not loaded", ref)
+
+	live, _, err := provider.Read(res.URN, id, nil, nil)
+	if err != nil {
+		return plugin.ReadResult{}, fmt.Errorf("reading %q: %w", res.URN, err)
+	}
+	if live.ID == "" {
+		live.ID = id
+	}
+	return live, nil
+}
@@ -62,6 +62,8 @@ func newUpCmd() *cobra.Command {
 	var diffDisplay bool
 	var eventLogPath string
 	var parallel int
+	var recordProviders string
+	var replayProviders string
 	var refresh string
 	var showConfig bool
 	var showReplacementSteps bool
@@ -77,6 +79,7 @@ func newUpCmd() *cobra.Command {
 	var targetReplaces []string
 	var targetDependents bool
 	var planFilePath string
+	var explainPlan bool
 
 	// up implementation used when the source of the Pulumi program is in the current working directory.
 	upWorkingDirectory := func(opts backend.UpdateOptions) result.Result {
@@ -158,6 +161,8 @@ func newUpCmd() *cobra.Command {
 			UpdateTargets:             targetURNs,
 			TargetDependents:          targetDependents,
 			ExperimentalPlans:         hasExperimentalCommands() || planFilePath != "",
+			RecordProviders:           recordProviders,
+			ReplayProviders:           replayProviders,
 		}
 
 		if planFilePath != "" {
@@ -176,6 +181,25 @@ func newUpCmd() *cobra.Command {
 			opts.Engine.Plan = plan
 		}
 
+		// When explaining a plan, only preview the update and report every way in which it differs from the plan.
+		if explainPlan {
+			opts.Engine.ExplainPlan = true
+			_, _, res := s.Preview(commandContext(), backend.UpdateOperation{
+				Proj:               proj,
+				Root:               root,
+				M:                  m,
+				Opts:               opts,
+				StackConfiguration: cfg,
+				SecretsManager:     sm,
+				Scopes:             cancellationScopes,
+			})
+			if res != nil {
+				return PrintEngineResult(res)
+			}
+			fmt.Printf("The update conforms to the plan in %s\n", planFilePath)
+			return nil
+		}
+
 		changes, res := s.Update(commandContext(), backend.UpdateOperation{
 			Proj:               proj,
 			Root:               root,
@@ -346,6 +370,8 @@ func newUpCmd() *cobra.Command {
 			Debug:             debug,
 			Refresh:           refreshOption,
 			ExperimentalPlans: hasExperimentalCommands() || planFilePath != "",
+			RecordProviders:   recordProviders,
+			ReplayProviders:   replayProviders,
 		}
 
 		// TODO for the URL case:
@@ -392,8 +418,23 @@ func newUpCmd() *cobra.Command {
 			"`--cwd` flag to use a different directory.",
 		Args: cmdutil.MaximumNArgs(1),
 		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			if err := resolveProviderRecordingFlags(&recordProviders, &replayProviders); err != nil {
+				return result.FromError(err)
+			}
+
 			yes = yes || skipConfirmations()
 
+			if explainPlan {
+				if planFilePath == "" {
+					return result.FromError(errors.New("--explain requires --plan"))
+				}
+				if len(args) > 0 {
+					return result.FromError(errors.New("--explain cannot be used with a template"))
+				}
+				// An explained plan is only previewed, so there is nothing to approve.
+				yes = true
+			}
+
 			interactive := cmdutil.Interactive()
 			if !interactive && !yes {
 				return result.FromError(errors.New("--yes must be passed in to proceed when running in non-interactive mode"))
@@ -474,7 +515,7 @@ func newUpCmd() *cobra.Command {
 		"Config keys contain a path to a property in a map or list to set")
 	cmd.PersistentFlags().StringVar(
 		&secretsProvider, "secrets-provider", "default", "The type of the provider that should be used to encrypt and "+
-			"decrypt secrets (possible choices: default, passphrase, awskms, azurekeyvault, gcpkms, hashivault). Only"+
+			"decrypt secrets (possible choices: default, passphrase, age, awskms, azurekeyvault, gcpkms, hashivault). Only"+
 			"used when creating a new stack from an existing template")
 
 	cmd.PersistentFlags().StringVar(
@@ -515,6 +556,7 @@ func newUpCmd() *cobra.Command {
 	cmd.Flags().BoolVarP(
 		&jsonDisplay, "json", "j", false,
 		"Serialize the update diffs, operations, and overall output as JSON")
+	addProviderRecordingFlags(cmd, &recordProviders, &replayProviders)
 	cmd.PersistentFlags().IntVarP(
 		&parallel, "parallel", "p", defaultParallel,
 		"Allow P resource operations to run in parallel at once (1 for no parallelism). Defaults to unbounded.")
@@ -555,8 +597,13 @@ func newUpCmd() *cobra.Command {
 		"[EXPERIMENTAL] Path to a plan file to use for the update. The update will not "+
 			"perform operations that exceed its plan (e.g. replacements instead of updates, or updates instead"+
 			"of sames).")
+	cmd.PersistentFlags().BoolVar(
+		&explainPlan, "explain", false,
+		"[EXPERIMENTAL] Preview the update and report every way in which it differs from the plan given by --plan, "+
+			"rather than stopping at the first difference. No resources are changed.")
 	if !hasExperimentalCommands() {
 		contract.AssertNoError(cmd.PersistentFlags().MarkHidden("plan"))
+		contract.AssertNoError(cmd.PersistentFlags().MarkHidden("explain"))
 	}
 
 	if hasDebugCommands() {
@@ -31,6 +31,7 @@ import (
 
 	multierror "github.com/hashicorp/go-multierror"
 	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/spf13/cobra"
 
 	survey "gopkg.in/AlecAivazis/survey.v1"
 	surveycore "gopkg.in/AlecAivazis/survey.v1/core"
@@ -44,6 +45,7 @@ import (
 	"github.com/pulumi/pulumi/pkg/v3/engine"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/age"
 	"github.com/pulumi/pulumi/pkg/v3/secrets/passphrase"
 	"github.com/pulumi/pulumi/pkg/v3/util/cancel"
 	"github.com/pulumi/pulumi/pkg/v3/util/tracing"
@@ -83,6 +85,42 @@ func disableOutputValues() bool {
 	return cmdutil.IsTruthy(os.Getenv("PULUMI_DISABLE_OUTPUT_VALUES"))
 }
 
+// addProviderRecordingFlags adds the flags that record and replay the RPCs made to resource providers to an update
+// command.
+func addProviderRecordingFlags(cmd *cobra.Command, record, replay *string) {
+	cmd.PersistentFlags().StringVar(
+		record, "record-providers", "",
+		"Record the RPCs made to resource providers, with their secrets masked, in the given directory")
+	cmd.PersistentFlags().StringVar(
+		replay, "replay-providers", "",
+		"Answer the RPCs made to resource providers with the responses recorded in the given directory "+
+			"by --record-providers rather than starting the providers")
+}
+
+// resolveProviderRecordingFlags checks the --record-providers and --replay-providers flags, and makes their
+// directories absolute.
+func resolveProviderRecordingFlags(record, replay *string) error {
+	if *record != "" && *replay != "" {
+		return errors.New("--record-providers and --replay-providers cannot be used together")
+	}
+	for _, dir := range []*string{record, replay} {
+		if *dir == "" {
+			continue
+		}
+		abs, err := filepath.Abs(*dir)
+		if err != nil {
+			return err
+		}
+		*dir = abs
+	}
+	if *replay != "" {
+		if info, err := os.Stat(*replay); err != nil || !info.IsDir() {
+			return fmt.Errorf("--replay-providers: %s is not a directory of recorded provider RPCs", *replay)
+		}
+	}
+	return nil
+}
+
 // skipConfirmations returns whether or not confirmation prompts should
 // be skipped. This should be used by pass any requirement that a --yes
 // parameter has been set for non-interactive scenarios.
@@ -179,6 +217,10 @@ func createSecretsManager(b backend.Backend, stackRef backend.StackReference, se
 			rotatePassphraseSecretsProvider); pharseErr != nil {
 			return pharseErr
 		}
+	} else if age.IsAgeSecretsProvider(secretsProvider) {
+		if _, ageErr := newAgeSecretsManager(stackRef.Name(), stackConfigFile, secretsProvider); ageErr != nil {
+			return ageErr
+		}
 	} else if !isDefaultSecretsProvider {
 		// All other non-default secrets providers are handled by the cloud secrets provider which
 		// uses a URL schema to identify the provider
@@ -128,6 +128,19 @@ type deploymentOptions struct {
 	// true if we should trust the dependency graph reported by the language host. Not all Pulumi-supported languages
 	// correctly report their dependencies, in which case this will be false.
 	trustDependencies bool
+
+	// the policies for retrying resource operations that fail with transient errors, as configured by the project.
+	retries deploy.RetryOptions
+
+	// the limits on the number of concurrent resource operations for specific packages and types, as configured by
+	// the project.
+	concurrencyLimits deploy.ConcurrencyLimits
+
+	// the commands that are run before and after operations on specific resources, as configured by the project.
+	hooks deploy.LifecycleHooks
+
+	// the default custom timeouts of resources whose types match a pattern, as configured by the project.
+	defaultTimeouts deploy.DefaultTimeouts
 }
 
 // deploymentSourceFunc is a callback that will be used to prepare for, and evaluate, the "new" state for a stack.
@@ -152,8 +165,28 @@ func newDeployment(ctx *Context, info *deploymentContext, opts deploymentOptions
 	if err != nil {
 		return nil, err
 	}
+	plugctx.RecordProvidersDir = opts.RecordProviders
+	plugctx.ReplayProvidersDir = opts.ReplayProviders
 
 	opts.trustDependencies = proj.TrustResourceDependencies()
+	if proj.Options != nil {
+		if opts.retries, err = deploy.NewRetryOptions(proj.Options.Retries); err != nil {
+			contract.IgnoreClose(plugctx)
+			return nil, fmt.Errorf("invalid retries in project options: %w", err)
+		}
+		if opts.concurrencyLimits, err = deploy.NewConcurrencyLimits(proj.Options.Concurrency); err != nil {
+			contract.IgnoreClose(plugctx)
+			return nil, fmt.Errorf("invalid concurrency in project options: %w", err)
+		}
+		if opts.hooks, err = deploy.NewLifecycleHooks(projinfo.Root, proj.Options.Hooks); err != nil {
+			contract.IgnoreClose(plugctx)
+			return nil, fmt.Errorf("invalid hooks in project options: %w", err)
+		}
+		if opts.defaultTimeouts, err = deploy.NewDefaultTimeouts(proj.Options.Timeouts); err != nil {
+			contract.IgnoreClose(plugctx)
+			return nil, fmt.Errorf("invalid timeouts in project options: %w", err)
+		}
+	}
 	// Now create the state source.  This may issue an error if it can't create the source.  This entails,
 	// for example, loading any plugins which will be required to execute a program, among other things.
 	source, err := opts.SourceFunc(ctx.BackendClient, opts, proj, pwd, main, target, plugctx, dryRun)
@@ -265,6 +298,11 @@ func (deployment *deployment) run(cancelCtx *Context, actions runActions, policy
 			DisableResourceReferences: deployment.Options.DisableResourceReferences,
 			DisableOutputValues:       deployment.Options.DisableOutputValues,
 			ExperimentalPlans:         deployment.Options.UpdateOptions.ExperimentalPlans,
+			Retries:                   deployment.Options.retries,
+			ConcurrencyLimits:         deployment.Options.concurrencyLimits,
+			Hooks:                     deployment.Options.hooks,
+			ExplainPlan:               deployment.Options.UpdateOptions.ExplainPlan,
+			DefaultTimeouts:           deployment.Options.defaultTimeouts,
 		}
 		newPlan, walkResult = deployment.Deployment.Execute(ctx, opts, preview)
 		close(done)
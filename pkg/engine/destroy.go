@@ -71,7 +71,10 @@ func newDestroySource(
 	}
 
 	// Like Update, if we're missing plugins, attempt to download the missing plugins.
-	if err := ensurePluginsAreInstalled(plugins); err != nil {
+	if err := ensurePluginsAreInstalled(plugctx, plugins); err != nil {
+		if isIntegrityError(err) {
+			return nil, err
+		}
 		logging.V(7).Infof("newDestroySource(): failed to install missing plugins: %v", err)
 	}
 
@@ -205,37 +205,46 @@ func makeEventEmitter(events chan<- Event, update UpdateInfo) (eventEmitter, err
 	target := update.GetTarget()
 	var secrets []string
 	if target != nil && target.Config.HasSecureValue() {
+		decrypter := config.NewTrackingDecrypter(target.Decrypter)
 		for k, v := range target.Config {
 			if !v.Secure() {
 				continue
 			}
 
-			secureValues, err := v.SecureValues(target.Decrypter)
-			if err != nil {
+			if _, err := v.Value(decrypter); err != nil {
 				return eventEmitter{}, DecryptError{
 					Key: k,
 					Err: err,
 				}
 			}
-			secrets = append(secrets, secureValues...)
 		}
+		secrets = decrypter.SecureValues()
 	}
 
 	logging.AddGlobalFilter(logging.CreateFilter(secrets, "[secret]"))
 
+	// In addition to the global filter, which only knows about secret configuration, each emitter redacts the secret
+	// properties of the target's resources and any secrets that resolve while the operation runs.
+	filter := newSecretFilter()
+	filter.addSecrets(secrets...)
+	if target != nil {
+		filter.addSnapshot(target.Snapshot)
+	}
+
 	buffer, done := make(chan Event), make(chan bool)
-	go queueEvents(events, buffer, done)
+	go queueEvents(events, buffer, done, filter)
 
 	return eventEmitter{
-		done: done,
-		ch:   buffer,
+		done:   done,
+		ch:     buffer,
+		filter: filter,
 	}, nil
 }
 
 func makeQueryEventEmitter(events chan<- Event) (eventEmitter, error) {
 	buffer, done := make(chan Event), make(chan bool)
 
-	go queueEvents(events, buffer, done)
+	go queueEvents(events, buffer, done, nil)
 
 	return eventEmitter{
 		done: done,
@@ -244,11 +253,12 @@ func makeQueryEventEmitter(events chan<- Event) (eventEmitter, error) {
 }
 
 type eventEmitter struct {
-	done <-chan bool
-	ch   chan<- Event
+	done   <-chan bool
+	ch     chan<- Event
+	filter *secretFilter // the filter used to redact secrets from event messages, if any.
 }
 
-func queueEvents(events chan<- Event, buffer chan Event, done chan bool) {
+func queueEvents(events chan<- Event, buffer chan Event, done chan bool, filter *secretFilter) {
 	// Instead of sending to the source channel directly, buffer events to account for slow receivers.
 	//
 	// Buffering is done by a goroutine that concurrently receives from the senders and attempts to send events to the
@@ -256,6 +266,8 @@ func queueEvents(events chan<- Event, buffer chan Event, done chan bool) {
 	//
 	// We do not use a buffered channel because it is empirically less likely that the goroutine reading from a
 	// buffered channel will be scheduled when new data is placed in the channel.
+	//
+	// Secrets are redacted from each event as it is received so that no event leaves the engine in the clear.
 
 	defer close(done)
 
@@ -267,7 +279,7 @@ func queueEvents(events chan<- Event, buffer chan Event, done chan bool) {
 		if !ok {
 			return
 		}
-		queue = append(queue, e)
+		queue = append(queue, filter.filterEvent(e))
 
 		// While there are events in the queue, attempt to send them to the waiting receiver. If the receiver is
 		// blocked and an event is received from the event senders, stick that event in the queue.
@@ -281,7 +293,7 @@ func queueEvents(events chan<- Event, buffer chan Event, done chan bool) {
 					}
 					return
 				}
-				queue = append(queue, e)
+				queue = append(queue, filter.filterEvent(e))
 			case events <- queue[0]:
 				queue = queue[1:]
 			}
@@ -363,6 +375,10 @@ func (e *eventEmitter) resourceOperationFailedEvent(
 func (e *eventEmitter) resourceOutputsEvent(op deploy.StepOp, step deploy.Step, planning bool, debug bool) {
 	contract.Requiref(e != nil, "e", "!= nil")
 
+	if new := step.New(); new != nil {
+		e.filter.addSecretProperties(new.Outputs)
+	}
+
 	e.ch <- NewEvent(ResourceOutputsEvent, ResourceOutputsEventPayload{
 		Metadata: makeStepEventMetadata(op, step, debug),
 		Planning: planning,
@@ -375,6 +391,10 @@ func (e *eventEmitter) resourcePreEvent(
 
 	contract.Requiref(e != nil, "e", "!= nil")
 
+	if new := step.New(); new != nil {
+		e.filter.addSecretProperties(new.Inputs)
+	}
+
 	e.ch <- NewEvent(ResourcePreEvent, ResourcePreEventPayload{
 		Metadata: makeStepEventMetadata(step.Op(), step, debug),
 		Planning: planning,
@@ -0,0 +1,109 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func TestConcurrencyLimits(t *testing.T) {
+	t.Parallel()
+
+	const resourceCount = 8
+
+	// Track the maximum number of concurrent creates for each resource type and for the package as a whole.
+	var lock sync.Mutex
+	current, max := map[string]int{}, map[string]int{}
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool) (resource.ID, resource.PropertyMap, resource.Status, error) {
+
+					keys := []string{string(urn.Type()), "pkgA"}
+					lock.Lock()
+					for _, k := range keys {
+						current[k]++
+						if current[k] > max[k] {
+							max[k] = current[k]
+						}
+					}
+					lock.Unlock()
+
+					time.Sleep(10 * time.Millisecond)
+
+					lock.Lock()
+					for _, k := range keys {
+						current[k]--
+					}
+					lock.Unlock()
+					return resource.ID(urn.Name()), news, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		var wg sync.WaitGroup
+		errs := make([]error, 2*resourceCount)
+		for i := 0; i < resourceCount; i++ {
+			wg.Add(2)
+			go func(i int) {
+				defer wg.Done()
+				_, _, _, errs[2*i] = monitor.RegisterResource("pkgA:m:typA", fmt.Sprintf("resA%d", i), true)
+			}(i)
+			go func(i int) {
+				defer wg.Done()
+				_, _, _, errs[2*i+1] = monitor.RegisterResource("pkgA:m:typB", fmt.Sprintf("resB%d", i), true)
+			}(i)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Parallel: 2 * resourceCount, Host: host},
+	}
+	project := p.GetProject()
+	project.Options = &workspace.ProjectOptions{
+		Concurrency: &workspace.ProjectConcurrency{
+			Packages: map[string]int{"pkgA": 3},
+			Types:    map[string]int{"pkgA:m:typA": 1},
+		},
+	}
+
+	_, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Equal(t, 1, max["pkgA:m:typA"])
+	assert.LessOrEqual(t, max["pkgA"], 3)
+}
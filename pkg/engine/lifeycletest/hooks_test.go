@@ -0,0 +1,329 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/blang/semver"
+	pbempty "github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+func TestLifecycleHooks(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands in this test require a POSIX shell")
+	}
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					return resource.ID(urn.Name() + "-id"), news, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	createResources := true
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		if !createResources {
+			return nil
+		}
+		for _, name := range []string{"resA", "resB"} {
+			if _, _, _, err := monitor.RegisterResource("pkgA:m:typA", name, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host},
+	}
+
+	// Each hook appends its event and the resource's name and ID to a log file.
+	log := filepath.Join(t.TempDir(), "hooks.log")
+	record := `echo "$PULUMI_HOOK_EVENT $PULUMI_HOOK_NAME $PULUMI_HOOK_ID" >> ` + log
+	project := p.GetProject()
+	project.Options = &workspace.ProjectOptions{
+		Hooks: []workspace.ProjectHook{
+			{Type: "pkgA:m:typA", BeforeCreate: record, AfterCreate: record},
+			{Name: "resB", BeforeDelete: record + " && exit 1"},
+		},
+	}
+	readLog := func() []string {
+		bytes, err := os.ReadFile(log)
+		require.NoError(t, err)
+		return strings.Split(strings.TrimSpace(string(bytes)), "\n")
+	}
+
+	// Hooks are not run during a preview.
+	_, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, true, p.BackendClient, nil)
+	assert.Nil(t, res)
+	_, err := os.Stat(log)
+	assert.True(t, os.IsNotExist(err))
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Equal(t, []string{
+		"before-create resA ",
+		"after-create resA resA-id",
+		"before-create resB ",
+		"after-create resB resB-id",
+	}, readLog())
+
+	// The failing hook fails the delete of resB, which remains in the state.
+	createResources = false
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	assert.NotNil(t, res)
+	assert.Equal(t, "before-delete resB resB-id", readLog()[4])
+	var names []string
+	for _, r := range snap.Resources {
+		names = append(names, string(r.URN.Name()))
+	}
+	assert.Contains(t, names, "resB")
+}
+
+func TestLifecycleHooksAfterApply(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands in this test require a POSIX shell")
+	}
+
+	deletes := 0
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					return resource.ID(urn.Name() + "-id"), news, resource.StatusOK, nil
+				},
+				DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					timeout float64) (resource.Status, error) {
+					deletes++
+					return resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	ins := resource.NewPropertyMapFromMap(map[string]interface{}{"foo": "bar"})
+	createRetained := true
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+			Inputs: ins,
+		})
+		if err != nil || !createRetained {
+			return err
+		}
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+			RetainOnDelete: true,
+		})
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host},
+	}
+
+	log := filepath.Join(t.TempDir(), "hooks.log")
+	record := `echo "$PULUMI_HOOK_EVENT $PULUMI_HOOK_NAME" >> ` + log
+	project := p.GetProject()
+	project.Options = &workspace.ProjectOptions{
+		Hooks: []workspace.ProjectHook{
+			{Name: "resA", AfterUpdate: "exit 1"},
+			{Name: "resB", BeforeDelete: record, AfterDelete: record},
+		},
+	}
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+
+	// A failing after hook fails the update, but the resource is recorded with the inputs it was updated with.
+	ins = resource.NewPropertyMapFromMap(map[string]interface{}{"foo": "baz"})
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	assert.NotNil(t, res)
+	var resA *resource.State
+	for _, r := range snap.Resources {
+		if r.URN.Name() == "resA" {
+			resA = r
+		}
+	}
+	require.NotNil(t, resA)
+	assert.Equal(t, ins, resA.Inputs)
+	assert.Equal(t, ins, resA.Outputs)
+
+	// Hooks are not run for deletes that do not call the provider, such as that of a retained resource.
+	project.Options.Hooks = project.Options.Hooks[1:]
+	createRetained = false
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Len(t, snap.Resources, 2)
+	assert.Equal(t, 0, deletes)
+	_, err := os.Stat(log)
+	assert.True(t, os.IsNotExist(err))
+}
+
+// programHooks is a program's ResourceHooks service, which records the hooks that it runs.
+type programHooks struct {
+	pulumirpc.UnimplementedResourceHooksServer
+
+	m     sync.Mutex
+	calls []string
+	fail  string // the token of a hook that fails.
+}
+
+func (h *programHooks) RunHook(ctx context.Context, req *pulumirpc.RunHookRequest) (*pbempty.Empty, error) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	h.calls = append(h.calls, fmt.Sprintf("%s %s %s %s", req.GetEvent(), resource.URN(req.GetUrn()).Name(),
+		req.GetId(), req.GetInputs().GetFields()["foo"].GetStringValue()))
+	if req.GetToken() == h.fail {
+		return nil, errors.New("hook failed")
+	}
+	return &pbempty.Empty{}, nil
+}
+
+func (h *programHooks) runs() []string {
+	h.m.Lock()
+	defer h.m.Unlock()
+	return append([]string(nil), h.calls...)
+}
+
+func TestProgramLifecycleHooks(t *testing.T) {
+	t.Parallel()
+
+	hooks := &programHooks{}
+	stop := make(chan bool)
+	port, done, err := rpcutil.Serve(0, stop, []func(*grpc.Server) error{
+		func(srv *grpc.Server) error {
+			pulumirpc.RegisterResourceHooksServer(srv, hooks)
+			return nil
+		},
+	}, nil)
+	require.NoError(t, err)
+	defer func() {
+		close(stop)
+		<-done
+	}()
+	target := fmt.Sprintf("127.0.0.1:%d", port)
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					return resource.ID(urn.Name() + "-id"), news, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	foo := "bar"
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+			Inputs: resource.PropertyMap{"foo": resource.NewStringProperty(foo)},
+			Hooks: []resource.ResourceHook{
+				{Event: "before-create", Target: target, Token: "1"},
+				{Event: "after-create", Target: target, Token: "2"},
+				{Event: "before-update", Target: target, Token: "3"},
+				{Event: "after-update", Target: target, Token: "4"},
+			},
+		})
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host},
+	}
+	project := p.GetProject()
+
+	// Hooks are not run during a preview.
+	_, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, true, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Empty(t, hooks.runs())
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Equal(t, []string{
+		"before-create resA  bar",
+		"after-create resA resA-id bar",
+	}, hooks.runs())
+
+	// The failing after hook fails the update, but the result of the update is still recorded.
+	foo, hooks.fail = "baz", "4"
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	assert.NotNil(t, res)
+	assert.Equal(t, []string{
+		"before-create resA  bar",
+		"after-create resA resA-id bar",
+		"before-update resA resA-id baz",
+		"after-update resA resA-id baz",
+	}, hooks.runs())
+	require.Len(t, snap.Resources, 2)
+	assert.Equal(t, "baz", snap.Resources[1].Outputs["foo"].StringValue())
+}
+
+func TestProgramLifecycleHooksRejectDeletes(t *testing.T) {
+	t.Parallel()
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+			Hooks: []resource.ResourceHook{{Event: "before-delete", Target: "127.0.0.1:1", Token: "1"}},
+		})
+		assert.ErrorContains(t, err, "before-delete hooks can only be configured in Pulumi.yaml")
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host},
+	}
+	_, res := TestOp(Update).Run(p.GetProject(), p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	assert.NotNil(t, res)
+}
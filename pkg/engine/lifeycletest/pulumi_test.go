@@ -4151,6 +4151,70 @@ func TestPlannedUpdateChangedStack(t *testing.T) {
 	assert.Equal(t, expected, snap.Resources[1].Outputs)
 }
 
+func TestExplainedPlan(t *testing.T) {
+	t.Parallel()
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{}, nil
+		}),
+	}
+
+	insA := resource.NewPropertyMapFromMap(map[string]interface{}{"foo": "bar"})
+	insB := resource.NewPropertyMapFromMap(map[string]interface{}{"foo": "bar"})
+	protectA, createC := false, false
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+			Inputs:  insA,
+			Protect: protectA,
+		})
+		assert.NoError(t, err)
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+			Inputs: insB,
+		})
+		assert.NoError(t, err)
+		if createC {
+			_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resC", true)
+			assert.NoError(t, err)
+		}
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host, ExperimentalPlans: true},
+	}
+
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+
+	// Generate a plan that updates resA.
+	insA = resource.NewPropertyMapFromMap(map[string]interface{}{"foo": "baz"})
+	plan, res := TestOp(Update).Plan(project, p.GetTarget(t, snap), p.Options, p.BackendClient, nil)
+	assert.Nil(t, res)
+
+	// Change the program in several ways that the plan does not allow, and check that an explained preview reports
+	// all of them at once.
+	insA = resource.NewPropertyMapFromMap(map[string]interface{}{"foo": "qux"})
+	insB = resource.NewPropertyMapFromMap(map[string]interface{}{"foo": "baz"})
+	protectA, createC = true, true
+
+	p.Options.Plan = plan.Clone()
+	p.Options.ExplainPlan = true
+	validate := ExpectDiagMessage(t, "<{%reset%}>the deployment differs from its plan in 5 way(s):\n"+
+		"    urn:pulumi:test::test::pkgA:m:typA::resA\n"+
+		"        inputs: properties changed: ~~foo[{baz}!={qux}]\n"+
+		"        options: protect changed (expected false)\n"+
+		"    urn:pulumi:test::test::pkgA:m:typA::resB\n"+
+		"        inputs: properties changed: =~foo[{baz}]\n"+
+		"        operation: update is not allowed by the plan: this resource is constrained to same\n"+
+		"    urn:pulumi:test::test::pkgA:m:typA::resC\n"+
+		"        operation: create is not allowed by the plan: no steps were expected for this resource<{%reset%}>\n")
+	_, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, true, p.BackendClient, validate)
+	assert.Nil(t, res)
+}
+
 func TestPlannedOutputChanges(t *testing.T) {
 	t.Parallel()
 
@@ -4705,6 +4769,92 @@ func TestRetainOnDelete(t *testing.T) {
 	assert.Len(t, snap.Resources, 0)
 }
 
+func TestDeletedWith(t *testing.T) {
+	t.Parallel()
+
+	var deletesLock sync.Mutex
+	var deleted []resource.URN
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					return resource.ID(urn.Name()), news, resource.StatusOK, nil
+				},
+				DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					timeout float64) (resource.Status, error) {
+					deletesLock.Lock()
+					defer deletesLock.Unlock()
+					deleted = append(deleted, urn)
+					return resource.StatusOK, nil
+				},
+			}, nil
+		}, deploytest.WithoutGrpc),
+	}
+
+	p := &TestPlan{}
+	urnA, urnB := p.NewURN("pkgA:m:typA", "resA", ""), p.NewURN("pkgA:m:typA", "resB", "")
+
+	createA, createB := true, true
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		if createA {
+			_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true)
+			assert.NoError(t, err)
+		}
+
+		if createB {
+			_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+				DeletedWith: urnA,
+			})
+			assert.NoError(t, err)
+		}
+
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+	p.Options = UpdateOptions{Host: host}
+
+	project := p.GetProject()
+
+	// Run an update to create the resources.
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Len(t, snap.Resources, 3)
+	assert.Equal(t, urnA, snap.Resources[2].DeletedWith)
+
+	// Removing only resB must still delete it, as resA is not being deleted.
+	createB = false
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Len(t, snap.Resources, 2)
+	assert.Equal(t, []resource.URN{urnB}, deleted)
+
+	// Once resB is recreated, removing both resources must only delete resA.
+	createB, deleted = true, nil
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Len(t, snap.Resources, 3)
+
+	createA, createB = false, false
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Len(t, snap.Resources, 0)
+	assert.Equal(t, []resource.URN{urnA}, deleted)
+
+	// The same applies to destroy.
+	createA, createB, deleted = true, true, nil
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Len(t, snap.Resources, 3)
+
+	snap, res = TestOp(Destroy).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Len(t, snap.Resources, 0)
+	assert.Equal(t, []resource.URN{urnA}, deleted)
+}
+
 func TestInvalidGetIDReportsUserError(t *testing.T) {
 	t.Parallel()
 
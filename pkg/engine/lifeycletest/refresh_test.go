@@ -2,9 +2,12 @@ package lifecycletest
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/blang/semver"
@@ -15,6 +18,7 @@ import (
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
@@ -80,6 +84,87 @@ func TestParallelRefresh(t *testing.T) {
 	assert.Equal(t, string(snap.Resources[4].URN.Name()), "resD")
 }
 
+func TestParallelRefreshFailures(t *testing.T) {
+	t.Parallel()
+
+	const resourceCount = 4
+
+	// Each read waits until every read is in flight, so the refresh only completes if all of the reads run
+	// concurrently. The read of resB fails, and the others refresh their resource's outputs.
+	var inFlight sync.WaitGroup
+	inFlight.Add(resourceCount)
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				ReadF: func(
+					urn resource.URN, id resource.ID, inputs, state resource.PropertyMap,
+				) (plugin.ReadResult, resource.Status, error) {
+					inFlight.Done()
+					inFlight.Wait()
+
+					if urn.Name() == "resB" {
+						return plugin.ReadResult{}, resource.StatusOK, errors.New("read failed")
+					}
+					return plugin.ReadResult{
+						ID:      id,
+						Inputs:  inputs,
+						Outputs: resource.PropertyMap{"refreshed": resource.NewBoolProperty(true)},
+					}, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		for i := 0; i < resourceCount; i++ {
+			_, _, _, err := monitor.RegisterResource("pkgA:m:typA", fmt.Sprintf("res%c", 'A'+i), true)
+			assert.NoError(t, err)
+		}
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Parallel: resourceCount, Host: host},
+	}
+	resBURN := p.NewURN("pkgA:m:typA", "resB", "")
+
+	p.Steps = []TestStep{{Op: Update}}
+	snap := p.Run(t, nil)
+
+	snap, res := TestOp(Refresh).Run(p.GetProject(), p.GetTarget(t, snap), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			// The failed read is reported on its resource and in a summary of all failed reads.
+			var resourceErrors, summaries int
+			for _, e := range events {
+				if e.Type != DiagEvent {
+					continue
+				}
+				payload := e.Payload().(DiagEventPayload)
+				if payload.Severity != diag.Error {
+					continue
+				}
+				switch {
+				case payload.URN == resBURN:
+					resourceErrors++
+				case strings.Contains(payload.Message, "failed to refresh 1 resource(s)"):
+					assert.Contains(t, payload.Message, string(resBURN))
+					summaries++
+				}
+			}
+			assert.Equal(t, 1, resourceErrors)
+			assert.Equal(t, 1, summaries)
+			return res
+		})
+	assert.NotNil(t, res)
+
+	// The other resources were refreshed despite the failure.
+	for _, r := range snap.Resources[1:] {
+		_, refreshed := r.Outputs["refreshed"]
+		assert.Equal(t, r.URN != resBURN, refreshed, "resource %v", r.URN)
+	}
+}
+
 func TestExternalRefresh(t *testing.T) {
 	t.Parallel()
 
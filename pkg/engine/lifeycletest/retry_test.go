@@ -0,0 +1,140 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil/rpcerror"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func TestRetryTransientFailures(t *testing.T) {
+	t.Parallel()
+
+	// The provider fails each create and update with a throttling error until it has been called failures+1 times.
+	failures, creates, updates := 0, 0, 0
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool) (resource.ID, resource.PropertyMap, resource.Status, error) {
+
+					if creates++; creates <= failures {
+						return "", nil, resource.StatusOK, rpcerror.New(codes.Unavailable, "throttled")
+					}
+					return "created-id", news, resource.StatusOK, nil
+				},
+				UpdateF: func(urn resource.URN, id resource.ID, olds, news resource.PropertyMap, timeout float64,
+					ignoreChanges []string, preview bool) (resource.PropertyMap, resource.Status, error) {
+
+					if updates++; updates <= failures {
+						return nil, resource.StatusOK, rpcerror.New(codes.Unknown, "Rate exceeded")
+					}
+					return news, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	inputs := resource.PropertyMap{"foo": resource.NewStringProperty("bar")}
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+			Inputs: inputs,
+		})
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host},
+	}
+	resURN := p.NewURN("pkgA:m:typA", "resA", "")
+
+	retryNonIdempotent := true
+	project := p.GetProject()
+	project.Options = &workspace.ProjectOptions{
+		Retries: &workspace.ProjectRetries{
+			Default: &workspace.ProjectRetryPolicy{MaxAttempts: 3, InitialDelay: "1ms"},
+		},
+	}
+
+	countRetries := func(events []Event) int {
+		retries := 0
+		for _, e := range events {
+			if e.Type == DiagEvent {
+				p := e.Payload().(DiagEventPayload)
+				if p.URN == resURN && p.Severity == diag.Warning && strings.Contains(p.Message, "retrying") {
+					retries++
+				}
+			}
+		}
+		return retries
+	}
+
+	// Creates are not idempotent, so they are not retried by default.
+	failures = 1
+	_, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			assert.Equal(t, 0, countRetries(events))
+			return res
+		})
+	assert.NotNil(t, res)
+	assert.Equal(t, 1, creates)
+
+	// Once the project opts in, the create is retried until it succeeds.
+	creates = 0
+	project.Options.Retries.Types = map[string]workspace.ProjectRetryPolicy{
+		"pkgA:m:typA": {RetryNonIdempotent: &retryNonIdempotent},
+	}
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			assert.Equal(t, 1, countRetries(events))
+			return res
+		})
+	assert.Nil(t, res)
+	assert.Equal(t, 2, creates)
+
+	// Updates are retried when their error matches a pattern, up to the maximum number of attempts.
+	failures = 2
+	inputs["foo"] = resource.NewStringProperty("baz")
+	project.Options.Retries.Default.Patterns = []string{"(?i)rate exceeded"}
+	_, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			assert.Equal(t, 2, countRetries(events))
+			return res
+		})
+	assert.Nil(t, res)
+	assert.Equal(t, 3, updates)
+
+	// Once the attempts are exhausted, the update fails.
+	updates, failures = 0, 3
+	inputs["foo"] = resource.NewStringProperty("qux")
+	_, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	assert.NotNil(t, res)
+	assert.Equal(t, 3, updates)
+}
@@ -0,0 +1,129 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func TestDefaultTimeouts(t *testing.T) {
+	t.Parallel()
+
+	var lock sync.Mutex
+	timeouts := map[string]float64{}
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					lock.Lock()
+					timeouts[string(urn.Name())] = timeout
+					lock.Unlock()
+					return resource.ID(urn.Name() + "-id"), news, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:db/cluster:Cluster", "resA", true)
+		require.NoError(t, err)
+		_, _, _, err = monitor.RegisterResource("pkgA:db/cluster:Cluster", "resB", true, deploytest.ResourceOptions{
+			CustomTimeouts: &resource.CustomTimeouts{Create: 60},
+		})
+		require.NoError(t, err)
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resC", true)
+		require.NoError(t, err)
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host},
+	}
+	project := p.GetProject()
+	project.Options = &workspace.ProjectOptions{
+		Timeouts: []workspace.ProjectTimeouts{
+			{Type: "pkgA:db/*", Create: "30m"},
+			{Create: "5m", Delete: "10m"},
+		},
+	}
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	// The first matching default wins, and a timeout set by the program takes precedence over the defaults.
+	assert.Equal(t, map[string]float64{"resA": 1800, "resB": 60, "resC": 300}, timeouts)
+
+	// The defaults are recorded in the state of each custom resource.
+	for _, r := range snap.Resources[1:] {
+		assert.Equal(t, float64(600), r.CustomTimeouts.Delete, r.URN)
+	}
+}
+
+func TestTimedOutCreateIsLeftPending(t *testing.T) {
+	t.Parallel()
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					if urn.Name() == "resB" {
+						return "", nil, resource.StatusUnknown, &plugin.TimeoutError{Operation: "create", Timeout: timeout}
+					}
+					return resource.ID(urn.Name() + "-id"), news, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true)
+		if err != nil {
+			return err
+		}
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+			CustomTimeouts: &resource.CustomTimeouts{Create: 60},
+		})
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host},
+	}
+
+	snap, res := TestOp(Update).Run(p.GetProject(), p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	assert.NotNil(t, res)
+
+	// The resource that was created is recorded, and the create that timed out is left pending.
+	require.Len(t, snap.Resources, 2)
+	assert.Equal(t, "resA", string(snap.Resources[1].URN.Name()))
+	require.Len(t, snap.PendingOperations, 1)
+	assert.Equal(t, resource.OperationTypeCreating, snap.PendingOperations[0].Type)
+	assert.Equal(t, "resB", string(snap.PendingOperations[0].Resource.URN.Name()))
+}
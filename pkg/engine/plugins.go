@@ -15,7 +15,9 @@
 package engine
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 
@@ -65,13 +67,24 @@ func (p pluginSet) Values() []workspace.PluginInfo {
 	return plugins
 }
 
+// Resolve returns a copy of this pluginSet in which each plugin that does not have a version is pinned to its newest
+// version in the given plugin lock, if any.
+func (p pluginSet) Resolve(lock *workspace.PluginLock) pluginSet {
+	newSet := newPluginSet()
+	for _, value := range p {
+		newSet.Add(lock.Resolve(value))
+	}
+	return newSet
+}
+
 // newPluginSet creates a new empty pluginSet.
 func newPluginSet() pluginSet {
 	return make(map[string]workspace.PluginInfo)
 }
 
 // gatherPluginsFromProgram inspects the given program and returns the set of plugins that the program requires to
-// function. If the language host does not support this operation, the empty set is returned.
+// function. If the language host does not support this operation, the empty set is returned. Plugins without a version
+// are pinned to their locked versions, as described by resolveLockedPlugins.
 func gatherPluginsFromProgram(plugctx *plugin.Context, prog plugin.ProgInfo) (pluginSet, error) {
 	logging.V(preparePluginLog).Infof("gatherPluginsFromProgram(): gathering plugins from language host")
 	set := newPluginSet()
@@ -90,12 +103,13 @@ func gatherPluginsFromProgram(plugctx *plugin.Context, prog plugin.ProgInfo) (pl
 			plug.Name, plug.Version, plug.PluginDownloadURL)
 		set.Add(plug)
 	}
-	return set, nil
+	return resolveLockedPlugins(plugctx, set)
 }
 
 // gatherPluginsFromSnapshot inspects the snapshot associated with the given Target and returns the set of plugins
 // required to operate on the snapshot. The set of plugins is derived from first-class providers saved in the snapshot
-// and the plugins specified in the deployment manifest.
+// and the plugins specified in the deployment manifest. Plugins without a version are pinned to their locked versions,
+// as described by resolveLockedPlugins.
 func gatherPluginsFromSnapshot(plugctx *plugin.Context, target *deploy.Target) (pluginSet, error) {
 	logging.V(preparePluginLog).Infof("gatherPluginsFromSnapshot(): gathering plugins from snapshot")
 	set := newPluginSet()
@@ -127,16 +141,37 @@ func gatherPluginsFromSnapshot(plugctx *plugin.Context, target *deploy.Target) (
 			PluginDownloadURL: downloadURL,
 		})
 	}
-	return set, nil
+	return resolveLockedPlugins(plugctx, set)
+}
+
+// resolveLockedPlugins pins each plugin in the given set that does not have a version to its locked version, if the
+// project in the context's root directory has a plugin lock file. Pinning the plugin set, rather than only the plugins
+// that are installed, ensures that the locked versions are the ones that are loaded and used as default providers.
+func resolveLockedPlugins(plugctx *plugin.Context, plugins pluginSet) (pluginSet, error) {
+	lock, err := workspace.LoadPluginLock(plugctx.Root)
+	if err != nil {
+		return nil, err
+	}
+	return plugins.Resolve(lock), nil
 }
 
 // ensurePluginsAreInstalled inspects all plugins in the plugin set and, if any plugins are not currently installed,
 // uses the given backend client to install them. Installations are processed in parallel, though
-// ensurePluginsAreInstalled does not return until all installations are completed.
-func ensurePluginsAreInstalled(plugins pluginSet) error {
+// ensurePluginsAreInstalled does not return until all installations are completed. If the project in the context's root
+// directory has a plugin lock file, locked plugins are downloaded from their locked URLs and their tarballs are checked
+// against the lock file's checksums. Resource plugins are not installed if the context replays recorded provider RPCs.
+func ensurePluginsAreInstalled(plugctx *plugin.Context, plugins pluginSet) error {
 	logging.V(preparePluginLog).Infof("ensurePluginsAreInstalled(): beginning")
+	lock, err := workspace.LoadPluginLock(plugctx.Root)
+	if err != nil {
+		return err
+	}
+
 	var installTasks errgroup.Group
 	for _, plug := range plugins.Values() {
+		if plug.Kind == workspace.ResourcePlugin && plugctx.ReplayProvidersDir != "" {
+			continue
+		}
 		_, path, err := workspace.GetPluginPath(plug.Kind, plug.Name, plug.Version)
 		if err == nil && path != "" {
 			logging.V(preparePluginLog).Infof(
@@ -149,23 +184,32 @@ func ensurePluginsAreInstalled(plugins pluginSet) error {
 		installTasks.Go(func() error {
 			logging.V(preparePluginLog).Infof(
 				"ensurePluginsAreInstalled(): plugin %s %s not installed, doing install", info.Name, info.Version)
-			return installPlugin(info)
+			return installPlugin(lock, info)
 		})
 	}
 
-	err := installTasks.Wait()
+	err = installTasks.Wait()
 	logging.V(preparePluginLog).Infof("ensurePluginsAreInstalled(): completed")
 	return err
 }
 
+// isIntegrityError returns true if the given error is an integrity error for a plugin that does not match its plugin
+// lock file, or a signature error for a plugin whose signature the plugin signature policy requires.
+func isIntegrityError(err error) bool {
+	var integrityErr *workspace.IntegrityError
+	var signatureErr *workspace.SignatureError
+	return errors.As(err, &integrityErr) || errors.As(err, &signatureErr)
+}
+
 // ensurePluginsAreLoaded ensures that all of the plugins in the given plugin set that match the given plugin flags are
 // loaded.
 func ensurePluginsAreLoaded(plugctx *plugin.Context, plugins pluginSet, kinds plugin.Flags) error {
 	return plugctx.Host.EnsurePlugins(plugins.Values(), kinds)
 }
 
-// installPlugin installs a plugin from the given backend client.
-func installPlugin(plugin workspace.PluginInfo) error {
+// installPlugin installs a plugin from the given backend client. If the given plugin lock has an artifact for the
+// plugin, the plugin is downloaded from the artifact's URL and checked against its checksum.
+func installPlugin(lock *workspace.PluginLock, plugin workspace.PluginInfo) error {
 	logging.V(preparePluginLog).Infof("installPlugin(%s, %s): beginning install", plugin.Name, plugin.Version)
 	if plugin.Kind == workspace.LanguagePlugin {
 		logging.V(preparePluginLog).Infof(
@@ -185,9 +229,21 @@ func installPlugin(plugin workspace.PluginInfo) error {
 		plugin.Version = version
 	}
 
+	opSy, arch, err := workspace.GetPluginPlatform()
+	if err != nil {
+		return err
+	}
+	artifact, locked := lock.Artifact(plugin, opSy, arch)
+
 	logging.V(preparePluginVerboseLog).Infof(
 		"installPlugin(%s, %s): initiating download", plugin.Name, plugin.Version)
-	stream, size, err := plugin.Download()
+	var stream io.ReadCloser
+	var size int64
+	if locked {
+		stream, size, err = artifact.Download()
+	} else {
+		stream, size, err = plugin.Download()
+	}
 	if err != nil {
 		return err
 	}
@@ -197,10 +253,17 @@ func installPlugin(plugin workspace.PluginInfo) error {
 
 	logging.V(preparePluginVerboseLog).Infof(
 		"installPlugin(%s, %s): extracting tarball to installation directory", plugin.Name, plugin.Version)
-	if err := plugin.Install(stream, false); err != nil {
+	if locked {
+		err = plugin.InstallWithChecksum(stream, artifact.SHA256, false)
+	} else {
+		err = plugin.Install(stream, false)
+	}
+	if err != nil {
+		if isIntegrityError(err) {
+			return err
+		}
 		return fmt.Errorf("installing plugin; run `pulumi plugin install %s %s v%s` to retry manually: %w",
 			plugin.Kind, plugin.Name, plugin.Version, err)
-
 	}
 
 	logging.V(7).Infof("installPlugin(%s, %s): successfully installed", plugin.Name, plugin.Version)
@@ -15,11 +15,15 @@
 package engine
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/blang/semver"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 )
@@ -139,3 +143,27 @@ func TestDefaultProvidersSnapshotOverrides(t *testing.T) {
 	assert.NotNil(t, awsVer)
 	assert.Equal(t, "0.17.0", awsVer.String())
 }
+
+func TestResolveLockedPlugins(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(root, workspace.PluginLockFile),
+		[]byte(`{"plugins": [{"kind": "resource", "name": "aws", "version": "5.1.0"}]}`), 0600)
+	require.NoError(t, err)
+
+	plugins := newPluginSet()
+	plugins.Add(workspace.PluginInfo{Name: "aws", Kind: workspace.ResourcePlugin})
+	plugins.Add(workspace.PluginInfo{Name: "gcp", Kind: workspace.ResourcePlugin, Version: mustMakeVersion("6.0.0")})
+
+	resolved, err := resolveLockedPlugins(&plugin.Context{Root: root}, plugins)
+	require.NoError(t, err)
+	assert.Len(t, resolved, 2)
+
+	// The default provider for an unversioned plugin uses its locked version.
+	defaultProviders := computeDefaultProviderPlugins(resolved, resolved)
+	require.NotNil(t, defaultProviders["aws"].Version)
+	assert.Equal(t, "5.1.0", defaultProviders["aws"].Version.String())
+	require.NotNil(t, defaultProviders["gcp"].Version)
+	assert.Equal(t, "6.0.0", defaultProviders["gcp"].Version.String())
+}
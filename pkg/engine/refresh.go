@@ -74,7 +74,10 @@ func newRefreshSource(client deploy.BackendClient, opts deploymentOptions, proj
 	}
 
 	// Like Update, if we're missing plugins, attempt to download the missing plugins.
-	if err := ensurePluginsAreInstalled(plugins); err != nil {
+	if err := ensurePluginsAreInstalled(plugctx, plugins); err != nil {
+		if isIntegrityError(err) {
+			return nil, err
+		}
 		logging.V(7).Infof("newRefreshSource(): failed to install missing plugins: %v", err)
 	}
 
@@ -0,0 +1,178 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// secretReplacement is the text that is substituted for any secret value found in an event message.
+const secretReplacement = "[secret]"
+
+// secretFilter redacts the plaintext of secret values from the messages carried by stdout, diagnostic, and policy
+// violation events. It is seeded with the decrypted secret configuration and prior state of the target, and it
+// learns about new secrets as resource inputs and outputs resolve over the course of an operation. A nil
+// *secretFilter is valid and filters nothing.
+type secretFilter struct {
+	m        sync.RWMutex
+	secrets  map[string]bool   // the set of secret plaintexts to redact.
+	replacer *strings.Replacer // the replacer for the current set of secrets, or nil if it must be rebuilt.
+}
+
+func newSecretFilter() *secretFilter {
+	return &secretFilter{secrets: make(map[string]bool)}
+}
+
+// addSnapshot adds the plaintext of every secret property in the given snapshot to the set of values to redact.
+func (f *secretFilter) addSnapshot(snap *deploy.Snapshot) {
+	if f == nil || snap == nil {
+		return
+	}
+	for _, res := range snap.Resources {
+		f.addSecretProperties(res.Inputs)
+		f.addSecretProperties(res.Outputs)
+	}
+}
+
+// addSecrets adds the given plaintexts to the set of values to redact. Very short values are ignored in order to
+// avoid redacting large swaths of unrelated text; this mirrors the behavior of logging.CreateFilter.
+func (f *secretFilter) addSecrets(secrets ...string) {
+	if f == nil {
+		return
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	for _, s := range secrets {
+		if len(s) < 3 || f.secrets[s] {
+			continue
+		}
+		f.secrets[s] = true
+		f.replacer = nil
+	}
+}
+
+// addSecretProperties adds the plaintext of every secret value in the given property map to the set of values to
+// redact.
+func (f *secretFilter) addSecretProperties(props resource.PropertyMap) {
+	if f == nil || !resource.NewObjectProperty(props).ContainsSecrets() {
+		return
+	}
+
+	var secrets []string
+	for _, v := range props {
+		secrets = collectSecretStrings(v, false, secrets)
+	}
+	f.addSecrets(secrets...)
+}
+
+// collectSecretStrings appends the string values in v that are nested within a secret to secrets.
+func collectSecretStrings(v resource.PropertyValue, inSecret bool, secrets []string) []string {
+	switch {
+	case v.IsString():
+		if inSecret {
+			secrets = append(secrets, v.StringValue())
+		}
+	case v.IsArray():
+		for _, e := range v.ArrayValue() {
+			secrets = collectSecretStrings(e, inSecret, secrets)
+		}
+	case v.IsObject():
+		for _, e := range v.ObjectValue() {
+			secrets = collectSecretStrings(e, inSecret, secrets)
+		}
+	case v.IsSecret():
+		secrets = collectSecretStrings(v.SecretValue().Element, true, secrets)
+	case v.IsOutput():
+		output := v.OutputValue()
+		if output.Known {
+			secrets = collectSecretStrings(output.Element, inSecret || output.Secret, secrets)
+		}
+	}
+	return secrets
+}
+
+// Filter replaces each secret in msg with a redaction marker.
+func (f *secretFilter) Filter(msg string) string {
+	if f == nil || msg == "" {
+		return msg
+	}
+
+	f.m.RLock()
+	replacer := f.replacer
+	f.m.RUnlock()
+
+	if replacer == nil {
+		f.m.Lock()
+		if f.replacer == nil {
+			f.replacer = f.newReplacer()
+		}
+		replacer = f.replacer
+		f.m.Unlock()
+	}
+
+	return replacer.Replace(msg)
+}
+
+// newReplacer builds a replacer for the current set of secrets. Longer secrets are given priority over shorter ones
+// so that a secret that contains another secret is redacted in full.
+func (f *secretFilter) newReplacer() *strings.Replacer {
+	secrets := make([]string, 0, len(f.secrets))
+	for s := range f.secrets {
+		secrets = append(secrets, s)
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		if len(secrets[i]) != len(secrets[j]) {
+			return len(secrets[i]) > len(secrets[j])
+		}
+		return secrets[i] < secrets[j]
+	})
+
+	oldnew := make([]string, 0, 2*len(secrets))
+	for _, s := range secrets {
+		oldnew = append(oldnew, s, secretReplacement)
+	}
+	return strings.NewReplacer(oldnew...)
+}
+
+// filterEvent redacts secrets from the messages of stdout, diagnostic, and policy violation events. Other events
+// are returned unchanged.
+func (f *secretFilter) filterEvent(e Event) Event {
+	if f == nil {
+		return e
+	}
+
+	switch e.Type {
+	case StdoutColorEvent:
+		p := e.payload.(StdoutEventPayload)
+		p.Message = f.Filter(p.Message)
+		e.payload = p
+	case DiagEvent:
+		p := e.payload.(DiagEventPayload)
+		p.Prefix, p.Message = f.Filter(p.Prefix), f.Filter(p.Message)
+		e.payload = p
+	case PolicyViolationEvent:
+		p := e.payload.(PolicyViolationEventPayload)
+		p.Prefix, p.Message = f.Filter(p.Prefix), f.Filter(p.Message)
+		e.payload = p
+	}
+	return e
+}
@@ -0,0 +1,94 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+func TestSecretFilterRedactsSecrets(t *testing.T) {
+	t.Parallel()
+
+	f := newSecretFilter()
+	f.addSecrets("hunter2", "hunter2-extended", "ab")
+
+	assert.Equal(t, "password is [secret]", f.Filter("password is hunter2"))
+	assert.Equal(t, "password is [secret]", f.Filter("password is hunter2-extended"))
+	assert.Equal(t, "ab is too short to redact", f.Filter("ab is too short to redact"))
+}
+
+func TestSecretFilterLearnsSecretProperties(t *testing.T) {
+	t.Parallel()
+
+	f := newSecretFilter()
+	f.addSnapshot(&deploy.Snapshot{
+		Resources: []*resource.State{{
+			Outputs: resource.PropertyMap{
+				"plain": resource.NewStringProperty("not-a-secret"),
+				"nested": resource.NewObjectProperty(resource.PropertyMap{
+					"token": resource.MakeSecret(resource.NewStringProperty("old-token")),
+				}),
+			},
+		}},
+	})
+	f.addSecretProperties(resource.PropertyMap{
+		"keys": resource.MakeSecret(resource.NewArrayProperty([]resource.PropertyValue{
+			resource.NewStringProperty("key-one"),
+			resource.NewStringProperty("key-two"),
+		})),
+		"output": resource.NewOutputProperty(resource.Output{
+			Element: resource.NewStringProperty("output-secret"),
+			Known:   true,
+			Secret:  true,
+		}),
+	})
+
+	assert.Equal(t, "not-a-secret [secret] [secret] [secret] [secret]",
+		f.Filter("not-a-secret old-token key-one key-two output-secret"))
+}
+
+func TestSecretFilterFiltersEvents(t *testing.T) {
+	t.Parallel()
+
+	f := newSecretFilter()
+	f.addSecrets("hunter2")
+
+	stdout := f.filterEvent(NewEvent(StdoutColorEvent, StdoutEventPayload{
+		Message: "printed hunter2",
+		Color:   colors.Raw,
+	}))
+	assert.Equal(t, "printed [secret]", stdout.Payload().(StdoutEventPayload).Message)
+
+	diagnostic := f.filterEvent(NewEvent(DiagEvent, DiagEventPayload{
+		Prefix:   "hunter2: ",
+		Message:  "logged hunter2",
+		Severity: diag.Info,
+	}))
+	payload := diagnostic.Payload().(DiagEventPayload)
+	assert.Equal(t, "[secret]: ", payload.Prefix)
+	assert.Equal(t, "logged [secret]", payload.Message)
+
+	// A nil filter must leave events untouched.
+	var nilFilter *secretFilter
+	unfiltered := nilFilter.filterEvent(NewEvent(StdoutColorEvent, StdoutEventPayload{Message: "hunter2"}))
+	assert.Equal(t, "hunter2", unfiltered.Payload().(StdoutEventPayload).Message)
+}
@@ -150,6 +150,15 @@ type UpdateOptions struct {
 
 	// true if experimental plans should be generated.
 	ExperimentalPlans bool
+
+	// true if a preview should report every way in which it differs from Plan rather than failing on the first.
+	ExplainPlan bool
+
+	// the directory in which to record the RPCs made to resource providers, if any.
+	RecordProviders string
+
+	// the directory of recorded provider RPCs to replay instead of starting resource providers, if any.
+	ReplayProviders string
 }
 
 // ResourceChanges contains the aggregate resource changes by operation type.
@@ -243,9 +252,10 @@ func installPlugins(
 	//
 	// Note that this is purely a best-effort thing. If we can't install missing plugins, just proceed; we'll fail later
 	// with an error message indicating exactly what plugins are missing. If `returnInstallErrors` is set, then return
-	// the error.
-	if err := ensurePluginsAreInstalled(allPlugins); err != nil {
-		if returnInstallErrors {
+	// the error. Plugins that fail the integrity checks of the project's plugin lock file or their signature checks are
+	// always an error.
+	if err := ensurePluginsAreInstalled(plugctx, allPlugins); err != nil {
+		if returnInstallErrors || isIntegrityError(err) {
 			return nil, nil, err
 		}
 		logging.V(7).Infof("newUpdateSource(): failed to install missing plugins: %v", err)
@@ -605,6 +615,16 @@ func (acts *updateActions) OnResourceStepPost(
 		}
 	}
 
+	// If the step's provider operation timed out, it may still be in progress. Leave the operation pending in the
+	// checkpoint rather than recording an outcome that may be wrong.
+	var timeoutErr *plugin.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		acts.Opts.Diag.Warningf(diag.RawMessage(step.URN(), fmt.Sprintf(
+			"the %s has been left pending in the stack's state; run `pulumi state pending ls` to inspect it",
+			timeoutErr.Operation)))
+		return nil
+	}
+
 	// Write out the current snapshot. Note that even if a failure has occurred, we should still have a
 	// safe checkpoint.  Note that any error that occurs when writing the checkpoint trumps the error
 	// reported above.
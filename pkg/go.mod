@@ -155,6 +155,7 @@ require (
 )
 
 require (
+	filippo.io/age v1.0.0
 	github.com/pulumi/pulumi-java/pkg v0.1.0
 	github.com/pulumi/pulumi-yaml v0.3.0
 	github.com/shirou/gopsutil/v3 v3.22.3
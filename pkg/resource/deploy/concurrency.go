@@ -0,0 +1,111 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// ConcurrencyLimits holds the maximum number of steps that may be applied concurrently for specific packages and
+// resource types. Packages and types without a limit are only bounded by the deployment's degree of parallelism.
+type ConcurrencyLimits struct {
+	Packages map[tokens.Package]int // the limits for all resources in a package.
+	Types    map[tokens.Type]int    // the limits for resources of a specific type.
+}
+
+// NewConcurrencyLimits converts the concurrency settings of a project into the concurrency limits for a deployment.
+func NewConcurrencyLimits(concurrency *workspace.ProjectConcurrency) (ConcurrencyLimits, error) {
+	if concurrency == nil {
+		return ConcurrencyLimits{}, nil
+	}
+
+	var limits ConcurrencyLimits
+	if len(concurrency.Packages) != 0 {
+		limits.Packages = make(map[tokens.Package]int, len(concurrency.Packages))
+		for pkg, limit := range concurrency.Packages {
+			if limit <= 0 {
+				return ConcurrencyLimits{}, fmt.Errorf("limit for package %v must be positive", pkg)
+			}
+			limits.Packages[tokens.Package(pkg)] = limit
+		}
+	}
+	if len(concurrency.Types) != 0 {
+		limits.Types = make(map[tokens.Type]int, len(concurrency.Types))
+		for typ, limit := range concurrency.Types {
+			if limit <= 0 {
+				return ConcurrencyLimits{}, fmt.Errorf("limit for type %v must be positive", typ)
+			}
+			limits.Types[tokens.Type(typ)] = limit
+		}
+	}
+	return limits, nil
+}
+
+// concurrencyLimiter enforces a deployment's concurrency limits. Each limit is a semaphore whose capacity is the
+// limit; a step holds a slot in the semaphores for its type and package while it is applied.
+type concurrencyLimiter struct {
+	packages map[tokens.Package]chan struct{}
+	types    map[tokens.Type]chan struct{}
+}
+
+func newConcurrencyLimiter(limits ConcurrencyLimits) *concurrencyLimiter {
+	limiter := &concurrencyLimiter{
+		packages: make(map[tokens.Package]chan struct{}, len(limits.Packages)),
+		types:    make(map[tokens.Type]chan struct{}, len(limits.Types)),
+	}
+	for pkg, limit := range limits.Packages {
+		limiter.packages[pkg] = make(chan struct{}, limit)
+	}
+	for typ, limit := range limits.Types {
+		limiter.types[typ] = make(chan struct{}, limit)
+	}
+	return limiter
+}
+
+// acquire blocks until a step on a resource of the given type may be applied. It returns a function that releases
+// the step's slots, or false if the context was canceled while waiting.
+//
+// Slots are always acquired in the same order--type first, then package--so that steps waiting on one another's
+// slots cannot deadlock.
+func (l *concurrencyLimiter) acquire(ctx context.Context, typ tokens.Type) (func(), bool) {
+	var held []chan struct{}
+	release := func() {
+		for _, sem := range held {
+			<-sem
+		}
+	}
+
+	sems := []chan struct{}{l.types[typ]}
+	if len(l.packages) != 0 && tokens.Token(typ).HasModuleMember() {
+		sems = append(sems, l.packages[typ.Package()])
+	}
+	for _, sem := range sems {
+		if sem == nil {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+			held = append(held, sem)
+		case <-ctx.Done():
+			release()
+			return nil, false
+		}
+	}
+	return release, true
+}
@@ -0,0 +1,84 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func TestNewConcurrencyLimits(t *testing.T) {
+	t.Parallel()
+
+	limits, err := NewConcurrencyLimits(nil)
+	require.NoError(t, err)
+	assert.Empty(t, limits.Packages)
+	assert.Empty(t, limits.Types)
+
+	limits, err = NewConcurrencyLimits(&workspace.ProjectConcurrency{
+		Packages: map[string]int{"aws": 50},
+		Types:    map[string]int{"saas:index:Widget": 2},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[tokens.Package]int{"aws": 50}, limits.Packages)
+	assert.Equal(t, map[tokens.Type]int{"saas:index:Widget": 2}, limits.Types)
+
+	_, err = NewConcurrencyLimits(&workspace.ProjectConcurrency{Types: map[string]int{"saas:index:Widget": 0}})
+	assert.EqualError(t, err, "limit for type saas:index:Widget must be positive")
+}
+
+func TestConcurrencyLimiter(t *testing.T) {
+	t.Parallel()
+
+	limiter := newConcurrencyLimiter(ConcurrencyLimits{
+		Packages: map[tokens.Package]int{"pkgA": 2},
+		Types:    map[tokens.Type]int{"pkgA:m:typA": 1},
+	})
+	ctx := context.Background()
+
+	// Types without a limit of their own are still bounded by their package's limit.
+	releaseA, ok := limiter.acquire(ctx, "pkgA:m:typA")
+	require.True(t, ok)
+	releaseB, ok := limiter.acquire(ctx, "pkgA:m:typB")
+	require.True(t, ok)
+
+	// Unlimited packages are never blocked.
+	releaseC, ok := limiter.acquire(ctx, "pkgB:m:typA")
+	require.True(t, ok)
+	releaseC()
+
+	// Once the package's limit is reached, further steps wait until the context is canceled.
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, ok = limiter.acquire(canceled, "pkgA:m:typB")
+	assert.False(t, ok)
+
+	// Releasing a slot for typB frees up the package, but typA is still limited by its own limit.
+	releaseB()
+	_, ok = limiter.acquire(canceled, "pkgA:m:typA")
+	assert.False(t, ok)
+	assert.Len(t, limiter.packages["pkgA"], 1)
+
+	releaseA()
+	releaseA, ok = limiter.acquire(ctx, "pkgA:m:typA")
+	require.True(t, ok)
+	releaseA()
+}
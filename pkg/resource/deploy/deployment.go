@@ -64,6 +64,16 @@ type Options struct {
 	DisableResourceReferences bool           // true to disable resource reference support.
 	DisableOutputValues       bool           // true to disable output value support.
 	ExperimentalPlans         bool           // true to enable experimental plan support.
+	Retries                   RetryOptions   // the policies for retrying steps that fail with transient errors.
+
+	// the limits on the number of concurrent steps for specific packages and resource types.
+	ConcurrencyLimits ConcurrencyLimits
+	// the commands that are run before and after operations on specific resources.
+	Hooks LifecycleHooks
+	// true to report every difference from the plan at the end of a preview rather than failing on the first.
+	ExplainPlan bool
+	// the default custom timeouts of resources whose types match a pattern.
+	DefaultTimeouts DefaultTimeouts
 }
 
 // DegreeOfParallelism returns the degree of parallelism that should be used during the
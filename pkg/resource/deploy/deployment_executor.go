@@ -335,6 +335,10 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 				}
 
 				err := fmt.Errorf("expected resource operations for %v but none were seen", urn)
+				if ex.stepGen.explainingPlan() {
+					ex.stepGen.violatePlan(urn, PlanViolationOperation, err.Error())
+					continue
+				}
 				logging.V(4).Infof("deploymentExecutor.Execute(...): error handling event: %v", err)
 				ex.reportError(urn, err)
 				res = result.Bail()
@@ -342,6 +346,12 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 		}
 	}
 
+	// If the plan is being explained, report every way in which the deployment differs from it at once.
+	if res == nil && len(ex.stepGen.planViolations) != 0 {
+		ex.reportError("", errors.New(explainPlanViolations(ex.stepGen.planViolations)))
+		res = result.Bail()
+	}
+
 	if res != nil && res.IsBail() {
 		return nil, res
 	}
@@ -573,13 +583,25 @@ func (ex *deploymentExecutor) refresh(callerCtx context.Context, opts Options, p
 		}
 	}
 
-	// Fire up a worker pool and issue each refresh in turn.
+	// Fire up a worker pool and issue each refresh in turn. A failed refresh does not stop the others, so that every
+	// failure can be summarized below.
 	ctx, cancel := context.WithCancel(callerCtx)
-	stepExec := newStepExecutor(ctx, cancel, ex.deployment, opts, preview, true)
+	stepExec := newStepExecutor(ctx, cancel, ex.deployment, opts, preview, true /*continueOnError*/)
 	stepExec.ExecuteParallel(steps)
 	stepExec.SignalCompletion()
 	stepExec.WaitForCompletion()
 
+	// Each failed refresh has already been reported on its resource. Summarize them so that they are easy to find in
+	// the output of a refresh of a large stack.
+	if failed := stepExec.FailedURNs(); len(failed) != 0 {
+		var msg strings.Builder
+		fmt.Fprintf(&msg, "failed to refresh %d resource(s):", len(failed))
+		for _, urn := range failed {
+			fmt.Fprintf(&msg, "\n    %v", urn)
+		}
+		ex.reportError("", errors.New(msg.String()))
+	}
+
 	ex.rebuildBaseState(resourceToStep, true /*refresh*/)
 
 	// NOTE: we use the presence of an error in the caller context in order to distinguish caller-initiated
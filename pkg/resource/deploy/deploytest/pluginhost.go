@@ -378,6 +378,9 @@ func (host *pluginHost) LogStatus(sev diag.Severity, urn resource.URN, msg strin
 func (host *pluginHost) Analyzer(nm tokens.QName) (plugin.Analyzer, error) {
 	return host.PolicyAnalyzer(nm, "", nil)
 }
+func (host *pluginHost) DefaultProvider(pkg tokens.Package, version *semver.Version) (plugin.Provider, error) {
+	return host.Provider(pkg, version)
+}
 func (host *pluginHost) CloseProvider(provider plugin.Provider) error {
 	host.m.Lock()
 	defer host.m.Unlock()
@@ -100,6 +100,8 @@ type ResourceOptions struct {
 	ImportID              resource.ID
 	CustomTimeouts        *resource.CustomTimeouts
 	RetainOnDelete        bool
+	DeletedWith           resource.URN
+	Hooks                 []resource.ResourceHook
 	SupportsPartialValues *bool
 	Remote                bool
 	Providers             map[string]string
@@ -167,6 +169,14 @@ func (rm *ResourceMonitor) RegisterResource(t tokens.Type, name string, custom b
 	if opts.SupportsPartialValues != nil {
 		supportsPartialValues = *opts.SupportsPartialValues
 	}
+	var hooks []*pulumirpc.RegisterResourceRequest_ResourceHook
+	for _, hook := range opts.Hooks {
+		hooks = append(hooks, &pulumirpc.RegisterResourceRequest_ResourceHook{
+			Event:  hook.Event,
+			Target: hook.Target,
+			Token:  hook.Token,
+		})
+	}
 	requestInput := &pulumirpc.RegisterResourceRequest{
 		Type:                       string(t),
 		Name:                       name,
@@ -192,6 +202,8 @@ func (rm *ResourceMonitor) RegisterResource(t tokens.Type, name string, custom b
 		Providers:                  opts.Providers,
 		PluginDownloadURL:          opts.PluginDownloadURL,
 		RetainOnDelete:             opts.RetainOnDelete,
+		DeletedWith:                string(opts.DeletedWith),
+		Hooks:                      hooks,
 	}
 
 	// submit request
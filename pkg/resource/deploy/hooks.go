@@ -0,0 +1,261 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"google.golang.org/grpc"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil/rpcerror"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+// HookEvent identifies the point in a resource's lifecycle at which a hook runs.
+type HookEvent string
+
+const (
+	HookBeforeCreate HookEvent = "before-create"
+	HookAfterCreate  HookEvent = "after-create"
+	HookBeforeUpdate HookEvent = "before-update"
+	HookAfterUpdate  HookEvent = "after-update"
+	HookBeforeDelete HookEvent = "before-delete"
+	HookAfterDelete  HookEvent = "after-delete"
+)
+
+// LifecycleHook is a set of commands that run before and after the operations on the custom resources it matches.
+type LifecycleHook struct {
+	Type     tokens.Type          // the type of the resources the hook applies to, or empty for all types.
+	Name     tokens.QName         // the name of the resources the hook applies to, or empty for all names.
+	Commands map[HookEvent]string // the commands to run for each event.
+}
+
+// LifecycleHooks holds the lifecycle hooks for a deployment.
+type LifecycleHooks struct {
+	Dir   string          // the directory in which hook commands are run.
+	Hooks []LifecycleHook // the hooks, in the order in which they are run.
+}
+
+// NewLifecycleHooks converts the hooks of a project into the lifecycle hooks for a deployment. Hook commands are run
+// in the given directory.
+func NewLifecycleHooks(dir string, hooks []workspace.ProjectHook) (LifecycleHooks, error) {
+	result := LifecycleHooks{Dir: dir}
+	for i, h := range hooks {
+		if h.Type != "" {
+			if _, err := tokens.ParseTypeToken(h.Type); err != nil {
+				return LifecycleHooks{}, fmt.Errorf("hook %d: %w", i, err)
+			}
+		}
+
+		commands := map[HookEvent]string{}
+		for event, command := range map[HookEvent]string{
+			HookBeforeCreate: h.BeforeCreate,
+			HookAfterCreate:  h.AfterCreate,
+			HookBeforeUpdate: h.BeforeUpdate,
+			HookAfterUpdate:  h.AfterUpdate,
+			HookBeforeDelete: h.BeforeDelete,
+			HookAfterDelete:  h.AfterDelete,
+		} {
+			if command != "" {
+				commands[event] = command
+			}
+		}
+		if len(commands) == 0 {
+			return LifecycleHooks{}, fmt.Errorf("hook %d has no commands", i)
+		}
+
+		result.Hooks = append(result.Hooks, LifecycleHook{
+			Type:     tokens.Type(h.Type),
+			Name:     tokens.QName(h.Name),
+			Commands: commands,
+		})
+	}
+	return result, nil
+}
+
+// hookEvents returns the events that surround the application of a step with the given op, if any.
+func hookEvents(op StepOp) (before HookEvent, after HookEvent, ok bool) {
+	switch op {
+	case OpCreate, OpCreateReplacement:
+		return HookBeforeCreate, HookAfterCreate, true
+	case OpUpdate:
+		return HookBeforeUpdate, HookAfterUpdate, true
+	case OpDelete, OpDeleteReplaced:
+		return HookBeforeDelete, HookAfterDelete, true
+	default:
+		return "", "", false
+	}
+}
+
+// stepHookEvents returns the events that surround the application of the given step, if any. Deletes that do not
+// call the resource's provider, such as those of retained or external resources, have no hooks.
+func stepHookEvents(step Step) (before HookEvent, after HookEvent, ok bool) {
+	if del, isDelete := step.(*DeleteStep); isDelete && !del.deletesResource() {
+		return "", "", false
+	}
+	return hookEvents(step.Op())
+}
+
+// commandsFor returns the commands to run for the given event on the given resource.
+func (h LifecycleHooks) commandsFor(event HookEvent, res *resource.State) []string {
+	if !res.Custom {
+		return nil
+	}
+
+	var commands []string
+	for _, hook := range h.Hooks {
+		if hook.Type != "" && hook.Type != res.Type {
+			continue
+		}
+		if hook.Name != "" && hook.Name != res.URN.Name() {
+			continue
+		}
+		if command, ok := hook.Commands[event]; ok {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// run runs a hook command for the given event on the given resource and returns its combined output. The command is
+// run by the system shell, with the event and the resource's URN, type, name and ID in its environment.
+func (h LifecycleHooks) run(ctx context.Context, command string, event HookEvent,
+	res *resource.State) (string, error) {
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Dir = h.Dir
+	cmd.Env = append(os.Environ(),
+		"PULUMI_HOOK_EVENT="+string(event),
+		"PULUMI_HOOK_URN="+string(res.URN),
+		"PULUMI_HOOK_TYPE="+string(res.Type),
+		"PULUMI_HOOK_NAME="+string(res.URN.Name()),
+		"PULUMI_HOOK_ID="+string(res.ID))
+
+	output, err := cmd.CombinedOutput()
+	return strings.TrimSpace(string(output)), err
+}
+
+// parseResourceHooks converts the hooks of a RegisterResourceRequest into the hooks of a goal. A program may only
+// register hooks that run before and after the create and update of a custom resource: resources that a program no
+// longer declares are deleted after the program has exited, when it can no longer run them.
+func parseResourceHooks(custom bool,
+	hooks []*pulumirpc.RegisterResourceRequest_ResourceHook) ([]resource.ResourceHook, error) {
+
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+	if !custom {
+		return nil, fmt.Errorf("hooks can only be registered for custom resources")
+	}
+
+	result := make([]resource.ResourceHook, len(hooks))
+	for i, h := range hooks {
+		switch HookEvent(h.GetEvent()) {
+		case HookBeforeCreate, HookAfterCreate, HookBeforeUpdate, HookAfterUpdate:
+		case HookBeforeDelete, HookAfterDelete:
+			return nil, fmt.Errorf("%s hooks can only be configured in Pulumi.yaml", h.GetEvent())
+		default:
+			return nil, fmt.Errorf("unknown hook event %q", h.GetEvent())
+		}
+		if h.GetTarget() == "" {
+			return nil, fmt.Errorf("%s hook has no target", h.GetEvent())
+		}
+		result[i] = resource.ResourceHook{Event: h.GetEvent(), Target: h.GetTarget(), Token: h.GetToken()}
+	}
+	return result, nil
+}
+
+// hookState returns the state of the resource of a step that the step's hooks are given. An update's new state does
+// not have the resource's ID until the update is applied, so its before hooks are given the ID of the old state.
+func hookState(step Step) *resource.State {
+	res := step.Res()
+	if update, ok := step.(*UpdateStep); ok && res.ID == "" {
+		withID := *res
+		withID.ID = update.Old().ID
+		return &withID
+	}
+	return res
+}
+
+// programHooks returns the hooks that the program registered for the given event on the resource of a step.
+func programHooks(event HookEvent, step Step) []resource.ResourceHook {
+	var reg RegisterResourceEvent
+	switch step := step.(type) {
+	case *CreateStep:
+		reg = step.reg
+	case *UpdateStep:
+		reg = step.reg
+	}
+	if reg == nil || reg.Goal() == nil {
+		return nil
+	}
+
+	var hooks []resource.ResourceHook
+	for _, hook := range reg.Goal().Hooks {
+		if HookEvent(hook.Event) == event {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks
+}
+
+// runProgramHook asks the program that registered a hook to run it for the given event on the given resource. The
+// program is sent the resource's URN and ID and its inputs and outputs, with secrets revealed.
+func runProgramHook(ctx context.Context, hook resource.ResourceHook, event HookEvent, res *resource.State) error {
+	conn, err := grpc.DialContext(ctx, hook.Target, grpc.WithInsecure(), rpcutil.GrpcChannelOptions())
+	if err != nil {
+		return fmt.Errorf("connecting to the program: %w", err)
+	}
+	defer contract.IgnoreClose(conn)
+
+	opts := plugin.MarshalOptions{Label: fmt.Sprintf("ResourceHooks.RunHook(%s)", res.URN), SkipNulls: true}
+	inputs, err := plugin.MarshalProperties(res.Inputs, opts)
+	if err != nil {
+		return err
+	}
+	outputs, err := plugin.MarshalProperties(res.Outputs, opts)
+	if err != nil {
+		return err
+	}
+
+	_, err = pulumirpc.NewResourceHooksClient(conn).RunHook(ctx, &pulumirpc.RunHookRequest{
+		Token:   hook.Token,
+		Event:   string(event),
+		Urn:     string(res.URN),
+		Id:      string(res.ID),
+		Inputs:  inputs,
+		Outputs: outputs,
+	})
+	if err != nil {
+		return rpcerror.Convert(err)
+	}
+	return nil
+}
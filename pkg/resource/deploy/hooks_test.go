@@ -0,0 +1,107 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+func TestNewLifecycleHooks(t *testing.T) {
+	t.Parallel()
+
+	hooks, err := NewLifecycleHooks("/project", []workspace.ProjectHook{
+		{Type: "pkgA:m:typA", BeforeDelete: "./drain.sh"},
+		{Name: "cache", AfterCreate: "./warm.sh", AfterUpdate: "./warm.sh"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/project", hooks.Dir)
+	require.Len(t, hooks.Hooks, 2)
+	assert.Equal(t, map[HookEvent]string{HookBeforeDelete: "./drain.sh"}, hooks.Hooks[0].Commands)
+	assert.Equal(t, map[HookEvent]string{HookAfterCreate: "./warm.sh", HookAfterUpdate: "./warm.sh"},
+		hooks.Hooks[1].Commands)
+
+	_, err = NewLifecycleHooks("", []workspace.ProjectHook{{Type: "typA", BeforeCreate: "true"}})
+	assert.Error(t, err)
+
+	_, err = NewLifecycleHooks("", []workspace.ProjectHook{{Type: "pkgA:m:typA"}})
+	assert.EqualError(t, err, "hook 0 has no commands")
+}
+
+func TestLifecycleHookCommands(t *testing.T) {
+	t.Parallel()
+
+	hooks, err := NewLifecycleHooks("", []workspace.ProjectHook{
+		{Type: "pkgA:m:typA", BeforeDelete: "drain"},
+		{Name: "resB", BeforeDelete: "log"},
+	})
+	require.NoError(t, err)
+
+	resA := &resource.State{Type: "pkgA:m:typA", URN: "urn:pulumi:stack::proj::pkgA:m:typA::resA", Custom: true}
+	resB := &resource.State{Type: "pkgA:m:typA", URN: "urn:pulumi:stack::proj::pkgA:m:typA::resB", Custom: true}
+	resC := &resource.State{Type: "pkgA:m:typB", URN: "urn:pulumi:stack::proj::pkgA:m:typB::resC", Custom: true}
+	comp := &resource.State{Type: "pkgA:m:typA", URN: "urn:pulumi:stack::proj::pkgA:m:typA::comp"}
+
+	assert.Equal(t, []string{"drain"}, hooks.commandsFor(HookBeforeDelete, resA))
+	assert.Equal(t, []string{"drain", "log"}, hooks.commandsFor(HookBeforeDelete, resB))
+	assert.Empty(t, hooks.commandsFor(HookBeforeDelete, resC))
+	assert.Empty(t, hooks.commandsFor(HookAfterDelete, resA))
+	assert.Empty(t, hooks.commandsFor(HookBeforeDelete, comp))
+
+	before, after, ok := hookEvents(OpCreateReplacement)
+	assert.True(t, ok)
+	assert.Equal(t, HookBeforeCreate, before)
+	assert.Equal(t, HookAfterCreate, after)
+	_, _, ok = hookEvents(OpSame)
+	assert.False(t, ok)
+}
+
+func TestParseResourceHooks(t *testing.T) {
+	t.Parallel()
+
+	hook := func(event, target string) *pulumirpc.RegisterResourceRequest_ResourceHook {
+		return &pulumirpc.RegisterResourceRequest_ResourceHook{Event: event, Target: target, Token: "1"}
+	}
+
+	hooks, err := parseResourceHooks(true, []*pulumirpc.RegisterResourceRequest_ResourceHook{
+		hook("before-create", "127.0.0.1:1"),
+		hook("after-update", "127.0.0.1:1"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []resource.ResourceHook{
+		{Event: "before-create", Target: "127.0.0.1:1", Token: "1"},
+		{Event: "after-update", Target: "127.0.0.1:1", Token: "1"},
+	}, hooks)
+
+	hooks, err = parseResourceHooks(false, nil)
+	require.NoError(t, err)
+	assert.Nil(t, hooks)
+
+	// Programs can't register delete hooks, since they have exited by the time most resources are deleted.
+	_, err = parseResourceHooks(true, []*pulumirpc.RegisterResourceRequest_ResourceHook{hook("after-delete", "a")})
+	assert.EqualError(t, err, "after-delete hooks can only be configured in Pulumi.yaml")
+	_, err = parseResourceHooks(true, []*pulumirpc.RegisterResourceRequest_ResourceHook{hook("on-create", "a")})
+	assert.EqualError(t, err, `unknown hook event "on-create"`)
+	_, err = parseResourceHooks(true, []*pulumirpc.RegisterResourceRequest_ResourceHook{hook("after-create", "")})
+	assert.EqualError(t, err, "after-create hook has no target")
+	_, err = parseResourceHooks(false, []*pulumirpc.RegisterResourceRequest_ResourceHook{hook("after-create", "a")})
+	assert.EqualError(t, err, "hooks can only be registered for custom resources")
+}
@@ -1,6 +1,7 @@
 package deploy
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -268,14 +269,47 @@ func (rp *ResourcePlan) diffStringSets(a, b []string) (message string, changed b
 	return message, true
 }
 
-// This is similar to ResourcePlan.checkGoal but for the case we're we don't have a goal saved.
-// This simple checks that we're not changing anything.
-func checkMissingPlan(
-	oldState *resource.State,
-	newInputs resource.PropertyMap,
-	programGoal *resource.Goal) error {
+// PlanViolationKind identifies the part of a resource's plan that a PlanViolation concerns.
+type PlanViolationKind string
+
+const (
+	PlanViolationOperation PlanViolationKind = "operation" // the resource's operations differ from those planned.
+	PlanViolationInputs    PlanViolationKind = "inputs"    // the resource's inputs differ from those planned.
+	PlanViolationOptions   PlanViolationKind = "options"   // the resource's options differ from those planned.
+)
+
+// PlanViolation describes a way in which a deployment differs from its plan.
+type PlanViolation struct {
+	URN     resource.URN      // the URN of the resource that differs from its plan.
+	Kind    PlanViolationKind // the part of the resource's plan that differs.
+	Message string            // a description of the difference.
+}
+
+// explainPlanViolations returns a report of the given plan violations grouped by resource.
+func explainPlanViolations(violations []PlanViolation) string {
+	sorted := make([]PlanViolation, len(violations))
+	copy(sorted, violations)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].URN != sorted[j].URN {
+			return sorted[i].URN < sorted[j].URN
+		}
+		return sorted[i].Kind < sorted[j].Kind
+	})
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "the deployment differs from its plan in %d way(s):", len(sorted))
+	for i, v := range sorted {
+		if i == 0 || v.URN != sorted[i-1].URN {
+			fmt.Fprintf(&sb, "\n    %v", v.URN)
+		}
+		fmt.Fprintf(&sb, "\n        %v: %v", v.Kind, v.Message)
+	}
+	return sb.String()
+}
 
-	// We new up a fake ResourcePlan that matches the old state and then simply call checkGoal on it.
+// missingResourcePlan returns a fake ResourcePlan that matches the old state. This is used to check resources that
+// have no saved plan, and simply checks that we're not changing anything.
+func missingResourcePlan(oldState *resource.State) *ResourcePlan {
 	goal := &GoalPlan{
 		Type:                    oldState.Type,
 		Name:                    oldState.URN.Name(),
@@ -296,8 +330,7 @@ func checkMissingPlan(
 		CustomTimeouts:          oldState.CustomTimeouts,
 	}
 
-	rp := ResourcePlan{Goal: goal}
-	return rp.checkGoal(oldState.Inputs, newInputs, programGoal)
+	return &ResourcePlan{Goal: goal}
 }
 
 func checkDiff(olds, news resource.PropertyMap, planDiff PlanDiff) error {
@@ -475,14 +508,34 @@ func (rp *ResourcePlan) checkGoal(
 	newInputs resource.PropertyMap,
 	programGoal *resource.Goal) error {
 
+	if violations := rp.goalViolations("", oldInputs, newInputs, programGoal); len(violations) != 0 {
+		return errors.New(violations[0].Message)
+	}
+	return nil
+}
+
+// goalViolations returns every way in which the program's goal for the resource with the given URN differs from the
+// goal recorded in the plan.
+func (rp *ResourcePlan) goalViolations(
+	urn resource.URN,
+	oldInputs resource.PropertyMap,
+	newInputs resource.PropertyMap,
+	programGoal *resource.Goal) []PlanViolation {
+
 	contract.Assert(programGoal != nil)
 	// rp.Goal may be nil, but if it isn't Type and Name should match
 	contract.Assert(rp.Goal == nil || rp.Goal.Type == programGoal.Type)
 	contract.Assert(rp.Goal == nil || rp.Goal.Name == programGoal.Name)
 
+	var violations []PlanViolation
+	violate := func(kind PlanViolationKind, format string, args ...interface{}) {
+		violations = append(violations, PlanViolation{URN: urn, Kind: kind, Message: fmt.Sprintf(format, args...)})
+	}
+
 	if rp.Goal == nil {
 		// If the plan goal is nil it expected a delete
-		return fmt.Errorf("resource unexpectedly not deleted")
+		violate(PlanViolationOperation, "resource unexpectedly not deleted")
+		return violations
 	}
 
 	// Check that either both resources are custom resources or both are component resources.
@@ -492,7 +545,8 @@ func (rp *ResourcePlan) checkGoal(
 		if !rp.Goal.Custom {
 			expected = "component"
 		}
-		return fmt.Errorf("resource kind changed (expected %v)", expected)
+		violate(PlanViolationOptions, "resource kind changed (expected %v)", expected)
+		return violations
 	}
 
 	// Check that the provider is identical.
@@ -501,25 +555,22 @@ func (rp *ResourcePlan) checkGoal(
 		// case here.
 		expected, err := providers.ParseReference(rp.Goal.Provider)
 		if err != nil {
-			return fmt.Errorf("failed to parse provider reference %v: %w", rp.Goal.Provider, err)
-		}
-		actual, err := providers.ParseReference(programGoal.Provider)
-		if err != nil {
-			return fmt.Errorf("failed to parse provider reference %v: %w", programGoal.Provider, err)
-		}
-		if expected.URN() != actual.URN() || expected.ID() != providers.UnknownID {
-			return fmt.Errorf("provider changed (expected %v)", rp.Goal.Provider)
+			violate(PlanViolationOptions, "failed to parse provider reference %v: %v", rp.Goal.Provider, err)
+		} else if actual, err := providers.ParseReference(programGoal.Provider); err != nil {
+			violate(PlanViolationOptions, "failed to parse provider reference %v: %v", programGoal.Provider, err)
+		} else if expected.URN() != actual.URN() || expected.ID() != providers.UnknownID {
+			violate(PlanViolationOptions, "provider changed (expected %v)", rp.Goal.Provider)
 		}
 	}
 
 	// Check that the parent is identical.
 	if programGoal.Parent != rp.Goal.Parent {
-		return fmt.Errorf("parent changed (expected %v)", rp.Goal.Parent)
+		violate(PlanViolationOptions, "parent changed (expected %v)", rp.Goal.Parent)
 	}
 
 	// Check that the protect bit is identical.
 	if programGoal.Protect != rp.Goal.Protect {
-		return fmt.Errorf("protect changed (expected %v)", rp.Goal.Protect)
+		violate(PlanViolationOptions, "protect changed (expected %v)", rp.Goal.Protect)
 	}
 
 	// Check that the DBR bit is identical.
@@ -528,66 +579,73 @@ func (rp *ResourcePlan) checkGoal(
 		// OK
 	case rp.Goal.DeleteBeforeReplace != nil && programGoal.DeleteBeforeReplace != nil:
 		if *rp.Goal.DeleteBeforeReplace != *programGoal.DeleteBeforeReplace {
-			return fmt.Errorf("deleteBeforeReplace changed (expected %v)", *rp.Goal.DeleteBeforeReplace)
+			violate(PlanViolationOptions, "deleteBeforeReplace changed (expected %v)", *rp.Goal.DeleteBeforeReplace)
 		}
 	default:
 		expected := "no value"
 		if rp.Goal.DeleteBeforeReplace != nil {
 			expected = fmt.Sprintf("%v", *rp.Goal.DeleteBeforeReplace)
 		}
-		return fmt.Errorf("deleteBeforeReplace changed (expected %v)", expected)
+		violate(PlanViolationOptions, "deleteBeforeReplace changed (expected %v)", expected)
 	}
 
 	// Check that the import ID is identical.
 	if rp.Goal.ID != programGoal.ID {
-		return fmt.Errorf("importID changed (expected %v)", rp.Goal.ID)
+		violate(PlanViolationOptions, "importID changed (expected %v)", rp.Goal.ID)
 	}
 
 	// Check that the timeouts are identical.
-	switch {
-	case rp.Goal.CustomTimeouts.Create != programGoal.CustomTimeouts.Create:
-		return fmt.Errorf("create timeout changed (expected %v)", rp.Goal.CustomTimeouts.Create)
-	case rp.Goal.CustomTimeouts.Update != programGoal.CustomTimeouts.Update:
-		return fmt.Errorf("update timeout changed (expected %v)", rp.Goal.CustomTimeouts.Update)
-	case rp.Goal.CustomTimeouts.Delete != programGoal.CustomTimeouts.Delete:
-		return fmt.Errorf("delete timeout changed (expected %v)", rp.Goal.CustomTimeouts.Delete)
+	if rp.Goal.CustomTimeouts.Create != programGoal.CustomTimeouts.Create {
+		violate(PlanViolationOptions, "create timeout changed (expected %v)", rp.Goal.CustomTimeouts.Create)
+	}
+	if rp.Goal.CustomTimeouts.Update != programGoal.CustomTimeouts.Update {
+		violate(PlanViolationOptions, "update timeout changed (expected %v)", rp.Goal.CustomTimeouts.Update)
+	}
+	if rp.Goal.CustomTimeouts.Delete != programGoal.CustomTimeouts.Delete {
+		violate(PlanViolationOptions, "delete timeout changed (expected %v)", rp.Goal.CustomTimeouts.Delete)
 	}
 
 	// Check that the ignoreChanges sets are identical.
 	if message, changed := rp.diffStringSets(rp.Goal.IgnoreChanges, programGoal.IgnoreChanges); changed {
-		return fmt.Errorf("ignoreChanges changed: %v", message)
+		violate(PlanViolationOptions, "ignoreChanges changed: %v", message)
 	}
 
 	// Check that the additionalSecretOutputs sets are identical.
 	if message, changed := rp.diffPropertyKeys(
 		rp.Goal.AdditionalSecretOutputs, programGoal.AdditionalSecretOutputs); changed {
-		return fmt.Errorf("additionalSecretOutputs changed: %v", message)
+		violate(PlanViolationOptions, "additionalSecretOutputs changed: %v", message)
 	}
 
 	// Check that the alias sets are identical.
 	if message, changed := rp.diffURNs(rp.Goal.Aliases, programGoal.Aliases); changed {
-		return fmt.Errorf("aliases changed: %v", message)
+		violate(PlanViolationOptions, "aliases changed: %v", message)
 	}
 
 	// Check that the dependencies match.
 	if message, changed := rp.diffURNs(rp.Goal.Dependencies, programGoal.Dependencies); changed {
-		return fmt.Errorf("dependencies changed: %v", message)
+		violate(PlanViolationOptions, "dependencies changed: %v", message)
 	}
 
 	// Check that the property diffs meet the constraints set in the plan
 	if err := checkDiff(oldInputs, newInputs, rp.Goal.InputDiff); err != nil {
-		return err
+		violate(PlanViolationInputs, "%v", err)
 	}
 
 	// Check that the property dependencies match. Note that because it is legal for a property that is unknown in the
 	// plan to be unset in the program, we allow the omission of a property from the program's dependency set.
-	for k, urns := range rp.Goal.PropertyDependencies {
-		if programDeps, ok := programGoal.PropertyDependencies[k]; ok {
+	keys := make([]string, 0, len(rp.Goal.PropertyDependencies))
+	for k := range rp.Goal.PropertyDependencies {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		urns := rp.Goal.PropertyDependencies[resource.PropertyKey(k)]
+		if programDeps, ok := programGoal.PropertyDependencies[resource.PropertyKey(k)]; ok {
 			if message, changed := rp.diffURNs(urns, programDeps); changed {
-				return fmt.Errorf("dependencies for %v changed: %v", k, message)
+				violate(PlanViolationOptions, "dependencies for %v changed: %v", k, message)
 			}
 		}
 	}
 
-	return nil
+	return violations
 }
@@ -97,13 +97,18 @@ type Registry struct {
 
 var _ plugin.Provider = (*Registry)(nil)
 
-func loadProvider(pkg tokens.Package, version *semver.Version, host plugin.Host,
+// loadProvider loads the plugin for the provider resource with the given URN. Only default providers may attach to a
+// pooled provider process, as explicit providers may be configured differently from the stack.
+func loadProvider(urn resource.URN, pkg tokens.Package, version *semver.Version, host plugin.Host,
 	builtins plugin.Provider) (plugin.Provider, error) {
 
 	if builtins != nil && pkg == builtins.Pkg() {
 		return builtins, nil
 	}
 
+	if IsDefaultProvider(urn) {
+		return host.DefaultProvider(pkg, version)
+	}
 	return host.Provider(pkg, version)
 }
 
@@ -146,7 +151,7 @@ func NewRegistry(host plugin.Host, prev []*resource.State, isPreview bool,
 		if err != nil {
 			return nil, fmt.Errorf("could not parse version for %v provider '%v': %v", providerPkg, urn, err)
 		}
-		provider, err := loadProvider(providerPkg, version, host, builtins)
+		provider, err := loadProvider(urn, providerPkg, version, host, builtins)
 		if err != nil {
 			return nil, fmt.Errorf("could not load plugin for %v provider '%v': %v", providerPkg, urn, err)
 		}
@@ -264,7 +269,7 @@ func (r *Registry) Check(urn resource.URN, olds, news resource.PropertyMap,
 	if err != nil {
 		return nil, []plugin.CheckFailure{{Property: "version", Reason: err.Error()}}, nil
 	}
-	provider, err := loadProvider(GetProviderPackage(urn.Type()), version, r.host, r.builtins)
+	provider, err := loadProvider(urn, GetProviderPackage(urn.Type()), version, r.host, r.builtins)
 	if err != nil {
 		return nil, nil, err
 	}
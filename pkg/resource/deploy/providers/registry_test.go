@@ -31,9 +31,10 @@ import (
 )
 
 type testPluginHost struct {
-	t             *testing.T
-	provider      func(pkg tokens.Package, version *semver.Version) (plugin.Provider, error)
-	closeProvider func(provider plugin.Provider) error
+	t               *testing.T
+	provider        func(pkg tokens.Package, version *semver.Version) (plugin.Provider, error)
+	defaultProvider func(pkg tokens.Package, version *semver.Version) (plugin.Provider, error)
+	closeProvider   func(provider plugin.Provider) error
 }
 
 func (host *testPluginHost) SignalCancellation() error {
@@ -65,6 +66,12 @@ func (host *testPluginHost) ListAnalyzers() []plugin.Analyzer {
 func (host *testPluginHost) Provider(pkg tokens.Package, version *semver.Version) (plugin.Provider, error) {
 	return host.provider(pkg, version)
 }
+func (host *testPluginHost) DefaultProvider(pkg tokens.Package, version *semver.Version) (plugin.Provider, error) {
+	if host.defaultProvider != nil {
+		return host.defaultProvider(pkg, version)
+	}
+	return host.provider(pkg, version)
+}
 func (host *testPluginHost) CloseProvider(provider plugin.Provider) error {
 	return host.closeProvider(provider)
 }
@@ -326,6 +333,31 @@ func TestNewRegistryOldState(t *testing.T) {
 	}
 }
 
+func TestNewRegistryOldStateDefaultProviders(t *testing.T) {
+	t.Parallel()
+
+	olds := []*resource.State{
+		newProviderState("pkgA", "default", "id1", false, nil),
+		newProviderState("pkgA", "explicit", "id2", false, nil),
+	}
+	loaders := []*providerLoader{
+		newSimpleLoader(t, "pkgA", "", nil),
+	}
+	host := newPluginHost(t, loaders).(*testPluginHost)
+
+	// Only default providers are loaded through the host's DefaultProvider.
+	defaults := 0
+	host.defaultProvider = func(pkg tokens.Package, version *semver.Version) (plugin.Provider, error) {
+		defaults++
+		return host.provider(pkg, version)
+	}
+
+	r, err := NewRegistry(host, olds, false, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, r)
+	assert.Equal(t, 1, defaults)
+}
+
 func TestNewRegistryOldStateNoProviders(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,240 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil/rpcerror"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+const (
+	// DefaultRetryMaxAttempts is the number of attempts made by a retry policy that does not specify a maximum.
+	DefaultRetryMaxAttempts = 3
+	// DefaultRetryInitialDelay is the delay before the first retry of a policy that does not specify one.
+	DefaultRetryInitialDelay = time.Second
+	// DefaultRetryMaxDelay is the maximum delay between retries of a policy that does not specify one.
+	DefaultRetryMaxDelay = 30 * time.Second
+)
+
+// DefaultRetryCodes are the gRPC codes that are retryable under a policy that specifies neither codes nor patterns.
+var DefaultRetryCodes = []codes.Code{codes.Unavailable, codes.ResourceExhausted}
+
+// RetryPolicy describes when and how often a step that fails with a transient error is retried.
+type RetryPolicy struct {
+	MaxAttempts        int              // the maximum number of attempts, including the first.
+	InitialDelay       time.Duration    // the delay before the first retry; each subsequent delay doubles.
+	MaxDelay           time.Duration    // the maximum delay between attempts.
+	Codes              []codes.Code     // the gRPC codes of retryable errors.
+	Patterns           []*regexp.Regexp // patterns matching the messages of retryable errors.
+	RetryNonIdempotent bool             // true if steps that are not idempotent may be retried.
+}
+
+// RetryOptions holds the retry policies for a deployment.
+type RetryOptions struct {
+	Default *RetryPolicy                 // the policy for resources whose type has no specific policy, if any.
+	Types   map[tokens.Type]*RetryPolicy // the policies for specific resource types.
+}
+
+// NewRetryOptions converts the retry settings of a project into the retry options for a deployment.
+func NewRetryOptions(retries *workspace.ProjectRetries) (RetryOptions, error) {
+	if retries == nil {
+		return RetryOptions{}, nil
+	}
+
+	var defaultPolicy workspace.ProjectRetryPolicy
+	var opts RetryOptions
+	if retries.Default != nil {
+		defaultPolicy = *retries.Default
+		policy, err := newRetryPolicy(defaultPolicy)
+		if err != nil {
+			return RetryOptions{}, fmt.Errorf("default retry policy: %w", err)
+		}
+		opts.Default = policy
+	}
+	if len(retries.Types) != 0 {
+		opts.Types = make(map[tokens.Type]*RetryPolicy, len(retries.Types))
+		for typ, p := range retries.Types {
+			policy, err := newRetryPolicy(mergeRetryPolicies(defaultPolicy, p))
+			if err != nil {
+				return RetryOptions{}, fmt.Errorf("retry policy for %v: %w", typ, err)
+			}
+			opts.Types[tokens.Type(typ)] = policy
+		}
+	}
+	return opts, nil
+}
+
+// mergeRetryPolicies returns the default policy overridden by the fields that are set in the given policy.
+func mergeRetryPolicies(defaults, p workspace.ProjectRetryPolicy) workspace.ProjectRetryPolicy {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = defaults.MaxAttempts
+	}
+	if p.InitialDelay == "" {
+		p.InitialDelay = defaults.InitialDelay
+	}
+	if p.MaxDelay == "" {
+		p.MaxDelay = defaults.MaxDelay
+	}
+	if p.Codes == nil {
+		p.Codes = defaults.Codes
+	}
+	if p.Patterns == nil {
+		p.Patterns = defaults.Patterns
+	}
+	if p.RetryNonIdempotent == nil {
+		p.RetryNonIdempotent = defaults.RetryNonIdempotent
+	}
+	return p
+}
+
+func newRetryPolicy(p workspace.ProjectRetryPolicy) (*RetryPolicy, error) {
+	policy := &RetryPolicy{
+		MaxAttempts:  p.MaxAttempts,
+		InitialDelay: DefaultRetryInitialDelay,
+		MaxDelay:     DefaultRetryMaxDelay,
+	}
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = DefaultRetryMaxAttempts
+	} else if policy.MaxAttempts < 0 {
+		return nil, fmt.Errorf("maxAttempts must not be negative")
+	}
+
+	var err error
+	if p.InitialDelay != "" {
+		if policy.InitialDelay, err = time.ParseDuration(p.InitialDelay); err != nil {
+			return nil, fmt.Errorf("invalid initialDelay: %w", err)
+		}
+	}
+	if p.MaxDelay != "" {
+		if policy.MaxDelay, err = time.ParseDuration(p.MaxDelay); err != nil {
+			return nil, fmt.Errorf("invalid maxDelay: %w", err)
+		}
+	}
+
+	for _, name := range p.Codes {
+		code, ok := parseCode(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown gRPC code %q", name)
+		}
+		policy.Codes = append(policy.Codes, code)
+	}
+	for _, pattern := range p.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		policy.Patterns = append(policy.Patterns, re)
+	}
+	if len(policy.Codes) == 0 && len(policy.Patterns) == 0 {
+		policy.Codes = DefaultRetryCodes
+	}
+
+	if p.RetryNonIdempotent != nil {
+		policy.RetryNonIdempotent = *p.RetryNonIdempotent
+	}
+	return policy, nil
+}
+
+// parseCode parses the name of a gRPC code, e.g. "Unavailable" or "RESOURCE_EXHAUSTED".
+func parseCode(name string) (codes.Code, bool) {
+	normalized := strings.ReplaceAll(strings.ToLower(name), "_", "")
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if strings.ToLower(c.String()) == normalized {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// policyFor returns the retry policy for resources of the given type, or nil if they are not retried.
+func (o RetryOptions) policyFor(typ tokens.Type) *RetryPolicy {
+	if policy, ok := o.Types[typ]; ok {
+		return policy
+	}
+	return o.Default
+}
+
+// shouldRetry returns true if a step that failed with the given status and error on the given attempt should be
+// attempted again.
+func (p *RetryPolicy) shouldRetry(step Step, status resource.Status, err error, attempt int) bool {
+	if p == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+	// A partial failure means the operation took effect, so it must not be repeated.
+	if status == resource.StatusPartialFailure {
+		return false
+	}
+	// A timed out operation may still be in progress, so it must not be repeated.
+	var timeoutErr *plugin.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return false
+	}
+	if !isIdempotent(step.Op()) && !p.RetryNonIdempotent {
+		return false
+	}
+	return p.retryable(err)
+}
+
+// retryable returns true if the given error matches one of the policy's codes or patterns.
+func (p *RetryPolicy) retryable(err error) bool {
+	if rpcErr, ok := rpcerror.FromError(err); ok {
+		for _, code := range p.Codes {
+			if rpcErr.Code() == code {
+				return true
+			}
+		}
+	}
+	msg := err.Error()
+	for _, re := range p.Patterns {
+		if re.MatchString(msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns the delay before the given retry, starting at 1.
+func (p *RetryPolicy) delay(retry int) time.Duration {
+	delay := p.InitialDelay
+	for i := 1; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// isIdempotent returns true if a step with the given op may be safely repeated after a failure. Creates are not
+// idempotent, as a failed create may nevertheless have created a resource that the engine does not know about.
+func isIdempotent(op StepOp) bool {
+	switch op {
+	case OpCreate, OpCreateReplacement:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil/rpcerror"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func TestNewRetryOptions(t *testing.T) {
+	t.Parallel()
+
+	opts, err := NewRetryOptions(nil)
+	require.NoError(t, err)
+	assert.Nil(t, opts.policyFor("pkgA:m:typA"))
+
+	retryNonIdempotent := true
+	opts, err = NewRetryOptions(&workspace.ProjectRetries{
+		Default: &workspace.ProjectRetryPolicy{MaxDelay: "5s", Patterns: []string{"throttl"}},
+		Types: map[string]workspace.ProjectRetryPolicy{
+			"pkgA:m:typA": {
+				MaxAttempts:        5,
+				Codes:              []string{"RESOURCE_EXHAUSTED", "unavailable"},
+				RetryNonIdempotent: &retryNonIdempotent,
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	policy := opts.policyFor("pkgA:m:typB")
+	require.NotNil(t, policy)
+	assert.Equal(t, DefaultRetryMaxAttempts, policy.MaxAttempts)
+	assert.Empty(t, policy.Codes)
+	assert.False(t, policy.RetryNonIdempotent)
+
+	// Type policies inherit the fields they do not set from the default policy.
+	policy = opts.policyFor("pkgA:m:typA")
+	require.NotNil(t, policy)
+	assert.Equal(t, 5, policy.MaxAttempts)
+	assert.Equal(t, 5*time.Second, policy.MaxDelay)
+	assert.Equal(t, []codes.Code{codes.ResourceExhausted, codes.Unavailable}, policy.Codes)
+	assert.Len(t, policy.Patterns, 1)
+	assert.True(t, policy.RetryNonIdempotent)
+
+	_, err = NewRetryOptions(&workspace.ProjectRetries{
+		Types: map[string]workspace.ProjectRetryPolicy{"pkgA:m:typA": {Codes: []string{"Throttled"}}},
+	})
+	assert.EqualError(t, err, `retry policy for pkgA:m:typA: unknown gRPC code "Throttled"`)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy, err := newRetryPolicy(workspace.ProjectRetryPolicy{
+		MaxAttempts:  4,
+		InitialDelay: "1s",
+		MaxDelay:     "3s",
+		Patterns:     []string{"(?i)rate exceeded"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Second, policy.delay(1))
+	assert.Equal(t, 2*time.Second, policy.delay(2))
+	assert.Equal(t, 3*time.Second, policy.delay(3))
+
+	update := &UpdateStep{}
+	create := &CreateStep{}
+	throttled := errors.New("Rate exceeded")
+	assert.True(t, policy.shouldRetry(update, resource.StatusOK, throttled, 1))
+	assert.False(t, policy.shouldRetry(update, resource.StatusOK, throttled, 4))
+	assert.False(t, policy.shouldRetry(update, resource.StatusPartialFailure, throttled, 1))
+	assert.False(t, policy.shouldRetry(update, resource.StatusOK, errors.New("bad input"), 1))
+	assert.False(t, policy.shouldRetry(create, resource.StatusOK, throttled, 1))
+
+	policy.Codes = []codes.Code{codes.Unavailable}
+	assert.True(t, policy.retryable(rpcerror.New(codes.Unavailable, "try again")))
+	assert.False(t, policy.retryable(rpcerror.New(codes.InvalidArgument, "try again")))
+}
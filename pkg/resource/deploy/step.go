@@ -267,25 +267,32 @@ func (s *CreateStep) Apply(preview bool) (resource.Status, StepCompleteFunc, err
 // DeleteStep is a mutating step that deletes an existing resource. If `old` is marked "External",
 // DeleteStep is a no-op.
 type DeleteStep struct {
-	deployment *Deployment     // the current deployment.
-	old        *resource.State // the state of the existing resource.
-	replacing  bool            // true if part of a replacement.
+	deployment     *Deployment           // the current deployment.
+	otherDeletions map[resource.URN]bool // the resources that are deleted in the same operation as this one.
+	old            *resource.State       // the state of the existing resource.
+	replacing      bool                  // true if part of a replacement.
 }
 
 var _ Step = (*DeleteStep)(nil)
 
-func NewDeleteStep(deployment *Deployment, old *resource.State) Step {
+// NewDeleteStep creates a step that deletes old. otherDeletions is the set of resources that are deleted in the same
+// operation, which is consulted when the step is applied to determine whether the resource will be deleted along
+// with the resource named by its DeletedWith option. It may be nil.
+func NewDeleteStep(deployment *Deployment, otherDeletions map[resource.URN]bool, old *resource.State) Step {
 	contract.Assert(old != nil)
 	contract.Assert(old.URN != "")
 	contract.Assert(old.ID != "" || !old.Custom)
 	contract.Assert(!old.Custom || old.Provider != "" || providers.IsProviderType(old.Type))
 	return &DeleteStep{
-		deployment: deployment,
-		old:        old,
+		deployment:     deployment,
+		otherDeletions: otherDeletions,
+		old:            old,
 	}
 }
 
-func NewDeleteReplacementStep(deployment *Deployment, old *resource.State, pendingReplace bool) Step {
+func NewDeleteReplacementStep(deployment *Deployment, otherDeletions map[resource.URN]bool, old *resource.State,
+	pendingReplace bool) Step {
+
 	contract.Assert(old != nil)
 	contract.Assert(old.URN != "")
 	contract.Assert(old.ID != "" || !old.Custom)
@@ -306,9 +313,10 @@ func NewDeleteReplacementStep(deployment *Deployment, old *resource.State, pendi
 	contract.Assert(pendingReplace != old.Delete)
 	old.PendingReplacement = pendingReplace
 	return &DeleteStep{
-		deployment: deployment,
-		old:        old,
-		replacing:  true,
+		deployment:     deployment,
+		otherDeletions: otherDeletions,
+		old:            old,
+		replacing:      true,
 	}
 }
 
@@ -345,15 +353,7 @@ func (s *DeleteStep) Apply(preview bool) (resource.Status, StepCompleteFunc, err
 			"`pulumi state unprotect '%s'`", s.old.URN, s.old.URN)
 	}
 
-	if preview {
-		// Do nothing in preview
-	} else if s.old.External {
-		// Deleting an External resource is a no-op, since Pulumi does not own the lifecycle.
-	} else if s.old.RetainOnDelete {
-		// Deleting a "drop on delete" is a no-op as the user has explicitly asked us to not delete the resource.
-	} else if s.old.Custom {
-		// Not preview and not external and not Drop and is custom, do the actual delete
-
+	if !preview && s.deletesResource() {
 		// Invoke the Delete RPC function for this provider:
 		prov, err := getProvider(s)
 		if err != nil {
@@ -368,6 +368,28 @@ func (s *DeleteStep) Apply(preview bool) (resource.Status, StepCompleteFunc, err
 	return resource.StatusOK, func() {}, nil
 }
 
+// deletesResource returns true if applying the step asks the resource's provider to delete the resource.
+func (s *DeleteStep) deletesResource() bool {
+	switch {
+	case !s.replacing && s.old.Protect:
+		// Protected resources are not deleted.
+		return false
+	case s.old.External:
+		// Deleting an External resource is a no-op, since Pulumi does not own the lifecycle.
+		return false
+	case s.old.RetainOnDelete:
+		// Deleting a "drop on delete" is a no-op as the user has explicitly asked us to not delete the resource.
+		return false
+	case s.old.DeletedWith != "" && s.otherDeletions[s.old.DeletedWith]:
+		// Deleting a resource whose "deleted with" resource is also being deleted is a no-op, as deleting that
+		// resource deletes this one too.
+		return false
+	default:
+		// Only custom resources are deleted by their provider.
+		return s.old.Custom
+	}
+}
+
 type RemovePendingReplaceStep struct {
 	deployment *Deployment     // the current deployment.
 	old        *resource.State // the state of the existing resource.
@@ -783,7 +805,7 @@ func (s *RefreshStep) Apply(preview bool) (resource.Status, StepCompleteFunc, er
 		s.new = resource.NewState(s.old.Type, s.old.URN, s.old.Custom, s.old.Delete, resourceID, inputs, outputs,
 			s.old.Parent, s.old.Protect, s.old.External, s.old.Dependencies, initErrors, s.old.Provider,
 			s.old.PropertyDependencies, s.old.PendingReplacement, s.old.AdditionalSecretOutputs, s.old.Aliases,
-			&s.old.CustomTimeouts, s.old.ImportID, s.old.SequenceNumber, s.old.RetainOnDelete)
+			&s.old.CustomTimeouts, s.old.ImportID, s.old.SequenceNumber, s.old.RetainOnDelete, s.old.DeletedWith)
 	} else {
 		s.new = nil
 	}
@@ -926,7 +948,7 @@ func (s *ImportStep) Apply(preview bool) (resource.Status, StepCompleteFunc, err
 	s.old = resource.NewState(s.new.Type, s.new.URN, s.new.Custom, false, s.new.ID, read.Inputs, read.Outputs,
 		s.new.Parent, s.new.Protect, false, s.new.Dependencies, s.new.InitErrors, s.new.Provider,
 		s.new.PropertyDependencies, false, nil, nil, &s.new.CustomTimeouts, s.new.ImportID,
-		s.new.SequenceNumber, s.new.RetainOnDelete)
+		s.new.SequenceNumber, s.new.RetainOnDelete, s.new.DeletedWith)
 
 	// If this step came from an import deployment, we need to fetch any required inputs from the state.
 	if s.planned {
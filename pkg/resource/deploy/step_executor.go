@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
@@ -86,11 +87,12 @@ type incomingChain struct {
 // resolved, we (the engine) can assume that any chain given to us by the step generator is already
 // ready to execute.
 type stepExecutor struct {
-	deployment      *Deployment // The deployment currently being executed.
-	opts            Options     // The options for this current deployment.
-	preview         bool        // Whether or not we are doing a preview.
-	pendingNews     sync.Map    // Resources that have been created but are pending a RegisterResourceOutputs.
-	continueOnError bool        // True if we want to continue the deployment after a step error.
+	deployment      *Deployment         // The deployment currently being executed.
+	opts            Options             // The options for this current deployment.
+	preview         bool                // Whether or not we are doing a preview.
+	pendingNews     sync.Map            // Resources that have been created but are pending a RegisterResourceOutputs.
+	continueOnError bool                // True if we want to continue the deployment after a step error.
+	limiter         *concurrencyLimiter // Enforces the concurrency limits for specific packages and types.
 
 	workers        sync.WaitGroup     // WaitGroup tracking the worker goroutines that are owned by this step executor.
 	incomingChains chan incomingChain // Incoming chains that we are to execute
@@ -98,6 +100,9 @@ type stepExecutor struct {
 	ctx      context.Context    // cancellation context for the current deployment.
 	cancel   context.CancelFunc // CancelFunc that cancels the above context.
 	sawError atomic.Value       // atomic boolean indicating whether or not the step excecutor saw that there was an error.
+
+	failedLock sync.Mutex     // Lock protecting failedURNs.
+	failedURNs []resource.URN // The URNs of the resources whose steps failed, in the order in which they failed.
 }
 
 //
@@ -218,6 +223,13 @@ func (se *stepExecutor) Errored() bool {
 	return se.sawError.Load().(bool)
 }
 
+// FailedURNs returns the URNs of the resources whose steps failed, in the order in which they failed.
+func (se *stepExecutor) FailedURNs() []resource.URN {
+	se.failedLock.Lock()
+	defer se.failedLock.Unlock()
+	return append([]resource.URN(nil), se.failedURNs...)
+}
+
 // SignalCompletion signals to the stepExecutor that there are no more chains left to execute. All worker
 // threads will terminate as soon as they retire all of the work they are currently executing.
 func (se *stepExecutor) SignalCompletion() {
@@ -249,8 +261,20 @@ func (se *stepExecutor) executeChain(workerID int, chain chain) {
 		default:
 		}
 
-		if err := se.executeStep(workerID, step); err != nil {
+		// Wait until the concurrency limits for the step's package and type allow it to run.
+		release, ok := se.limiter.acquire(se.ctx, step.Type())
+		if !ok {
+			se.log(workerID, "step %v on %v canceled while waiting for a concurrency slot", step.Op(), step.URN())
+			return
+		}
+
+		err := se.executeStep(workerID, step)
+		release()
+		if err != nil {
 			se.log(workerID, "step %v on %v failed, signalling cancellation", step.Op(), step.URN())
+			se.failedLock.Lock()
+			se.failedURNs = append(se.failedURNs, step.URN())
+			se.failedLock.Unlock()
 			se.cancelDueToError()
 			if err != errStepApplyFailed {
 				// Step application errors are recorded by the OnResourceStepPost callback. This is confusing,
@@ -299,7 +323,7 @@ func (se *stepExecutor) executeStep(workerID int, step Step) error {
 	}
 
 	se.log(workerID, "applying step %v on %v (preview %v)", step.Op(), step.URN(), se.preview)
-	status, stepComplete, err := step.Apply(se.preview)
+	status, stepComplete, err := se.applyStepWithHooks(step)
 
 	if err == nil {
 		// If we have a state object, and this is a create or update, remember it, as we may need to update it later.
@@ -342,6 +366,13 @@ func (se *stepExecutor) executeStep(workerID int, step Step) error {
 		}
 	}
 
+	// The after hooks run once OnResourceStepPost has saved the results of the step, so that a failing hook fails the
+	// step without discarding the effects of the provider operation that it follows.
+	var hookErr error
+	if err == nil {
+		hookErr = se.runAfterHooks(step)
+	}
+
 	// Calling stepComplete allows steps that depend on this step to continue. OnResourceStepPost saved the results
 	// of the step in the snapshot, so we are ready to go.
 	if stepComplete != nil {
@@ -353,10 +384,76 @@ func (se *stepExecutor) executeStep(workerID int, step Step) error {
 		se.log(workerID, "step %v on %v failed with an error: %v", step.Op(), step.URN(), err)
 		return errStepApplyFailed
 	}
+	if hookErr != nil {
+		se.log(workerID, "step %v on %v failed after hook: %v", step.Op(), step.URN(), hookErr)
+		return hookErr
+	}
+
+	return nil
+}
+
+// applyStepWithHooks applies a step, running the before hooks for its resource first. A failing before hook fails the
+// step without applying it.
+func (se *stepExecutor) applyStepWithHooks(step Step) (resource.Status, StepCompleteFunc, error) {
+	if before, _, ok := stepHookEvents(step); ok && !se.preview {
+		if err := se.runHooks(before, step); err != nil {
+			return resource.StatusOK, nil, err
+		}
+	}
+	return se.applyStep(step)
+}
+
+// runAfterHooks runs the after hooks for the resource of a step that has been applied.
+func (se *stepExecutor) runAfterHooks(step Step) error {
+	if _, after, ok := stepHookEvents(step); ok && !se.preview {
+		return se.runHooks(after, step)
+	}
+	return nil
+}
 
+// runHooks runs the hooks for the given event on the resource of a step: first the hook commands, reporting the output
+// of each command as an informational message on the resource, and then the hooks that the program registered.
+func (se *stepExecutor) runHooks(event HookEvent, step Step) error {
+	res := hookState(step)
+	for _, command := range se.opts.Hooks.commandsFor(event, res) {
+		output, err := se.opts.Hooks.run(se.ctx, command, event, res)
+		if output != "" {
+			se.deployment.Diag().Infof(diag.RawMessage(res.URN, output))
+		}
+		if err != nil {
+			return fmt.Errorf("%s hook `%s` failed: %w", event, command, err)
+		}
+	}
+	for _, hook := range programHooks(event, step) {
+		if err := runProgramHook(se.ctx, hook, event, res); err != nil {
+			return fmt.Errorf("%s hook in the program failed: %w", event, err)
+		}
+	}
 	return nil
 }
 
+// applyStep applies a step. If the step fails with an error that is retryable under the retry policy for its
+// resource type, it is attempted again after a delay, and each retry is reported as a warning on the resource.
+func (se *stepExecutor) applyStep(step Step) (resource.Status, StepCompleteFunc, error) {
+	policy := se.opts.Retries.policyFor(step.Type())
+	for attempt := 1; ; attempt++ {
+		status, stepComplete, err := step.Apply(se.preview)
+		if err == nil || !policy.shouldRetry(step, status, err, attempt) {
+			return status, stepComplete, err
+		}
+
+		delay := policy.delay(attempt)
+		se.deployment.Diag().Warningf(diag.RawMessage(step.URN(), fmt.Sprintf(
+			"%s failed (attempt %d of %d), retrying in %v: %v", step.Op(), attempt, policy.MaxAttempts, delay, err)))
+
+		select {
+		case <-se.ctx.Done():
+			return status, stepComplete, err
+		case <-time.After(delay):
+		}
+	}
+}
+
 // log is a simple logging helper for the step executor.
 func (se *stepExecutor) log(workerID int, msg string, args ...interface{}) {
 	if logging.V(stepExecutorLogLevel) {
@@ -430,6 +527,7 @@ func newStepExecutor(ctx context.Context, cancel context.CancelFunc, deployment
 		opts:            opts,
 		preview:         preview,
 		continueOnError: continueOnError,
+		limiter:         newConcurrencyLimiter(opts.ConcurrencyLimits),
 		incomingChains:  make(chan incomingChain),
 		ctx:             ctx,
 		cancel:          cancel,
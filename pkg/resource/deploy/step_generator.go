@@ -66,6 +66,51 @@ type stepGenerator struct {
 
 	// a map from old names (aliased URNs) to the new URN that aliased to them.
 	aliased map[resource.URN]resource.URN
+
+	// the ways in which the deployment differs from its plan, if the plan is being explained.
+	planViolations []PlanViolation
+}
+
+// explainingPlan returns true if differences from the deployment's plan are collected rather than treated as errors.
+func (sg *stepGenerator) explainingPlan() bool {
+	return sg.deployment.plan != nil && sg.deployment.preview && sg.opts.ExplainPlan
+}
+
+// violatePlan records a way in which the deployment differs from its plan.
+func (sg *stepGenerator) violatePlan(urn resource.URN, kind PlanViolationKind, message string) {
+	sg.planViolations = append(sg.planViolations, PlanViolation{URN: urn, Kind: kind, Message: message})
+}
+
+// checkStepAllowed checks the given step against its resource's plan and consumes the planned operation it uses.
+// The step is always allowed when the plan is being explained; any difference is recorded instead.
+func (sg *stepGenerator) checkStepAllowed(s Step) result.Result {
+	var err error
+	if resourcePlan, ok := sg.deployment.plan.ResourcePlans[s.URN()]; ok {
+		if len(resourcePlan.Ops) == 0 {
+			err = fmt.Errorf("%v is not allowed by the plan: no more steps were expected for this resource", s.Op())
+		} else {
+			// We remove the Op from the list before doing the constraint check. This is because we look at Ops at the
+			// end to see if any expected operations didn't attempt to happen. This op has been attempted, it just
+			// might fail its constraint.
+			constraint := resourcePlan.Ops[0]
+			resourcePlan.Ops = resourcePlan.Ops[1:]
+
+			if !s.Op().ConstrainedTo(constraint) {
+				err = fmt.Errorf("%v is not allowed by the plan: this resource is constrained to %v", s.Op(), constraint)
+			}
+		}
+	} else if !s.Op().ConstrainedTo(OpSame) {
+		err = fmt.Errorf("%v is not allowed by the plan: no steps were expected for this resource", s.Op())
+	}
+
+	if err == nil {
+		return nil
+	}
+	if sg.explainingPlan() {
+		sg.violatePlan(s.URN(), PlanViolationOperation, err.Error())
+		return nil
+	}
+	return result.FromError(err)
 }
 
 func (sg *stepGenerator) isTargetedUpdate() bool {
@@ -134,6 +179,7 @@ func (sg *stepGenerator) GenerateReadSteps(event ReadResourceEvent) ([]Step, res
 		"",    /* importID */
 		1,     /* sequenceNumber */
 		false, /* retainOnDelete */
+		"",    /* deletedWith */
 	)
 	old, hasOld := sg.deployment.Olds()[urn]
 
@@ -196,20 +242,8 @@ func (sg *stepGenerator) GenerateSteps(event RegisterResourceEvent) ([]Step, res
 	// Check each proposed step against the relevant resource plan, if any
 	for _, s := range steps {
 		if sg.deployment.plan != nil {
-			if resourcePlan, ok := sg.deployment.plan.ResourcePlans[s.URN()]; ok {
-				if len(resourcePlan.Ops) == 0 {
-					return nil, result.Errorf("%v is not allowed by the plan: no more steps were expected for this resource", s.Op())
-				}
-
-				constraint := resourcePlan.Ops[0]
-				if !s.Op().ConstrainedTo(constraint) {
-					return nil, result.Errorf("%v is not allowed by the plan: this resource is constrained to %v", s.Op(), constraint)
-				}
-				resourcePlan.Ops = resourcePlan.Ops[1:]
-			} else {
-				if !s.Op().ConstrainedTo(OpSame) {
-					return nil, result.Errorf("%v is not allowed by the plan: no steps were expected for this resource", s.Op())
-				}
+			if res := sg.checkStepAllowed(s); res != nil {
+				return nil, res
 			}
 		}
 
@@ -336,11 +370,17 @@ func (sg *stepGenerator) generateSteps(event RegisterResourceEvent) ([]Step, res
 		inputs = processedInputs
 	}
 
+	// Fill in any custom timeouts that the program left unset from the deployment's defaults.
+	timeouts := goal.CustomTimeouts
+	if goal.Custom {
+		timeouts = sg.opts.DefaultTimeouts.apply(goal.Type, timeouts)
+	}
+
 	// Produce a new state object that we'll build up as operations are performed.  Ultimately, this is what will
 	// get serialized into the checkpoint file.
 	new := resource.NewState(goal.Type, urn, goal.Custom, false, "", inputs, nil, goal.Parent, goal.Protect, false,
 		goal.Dependencies, goal.InitErrors, goal.Provider, goal.PropertyDependencies, false,
-		goal.AdditionalSecretOutputs, alias, &goal.CustomTimeouts, "", 1, goal.RetainOnDelete)
+		goal.AdditionalSecretOutputs, alias, &timeouts, "", 1, goal.RetainOnDelete, goal.DeletedWith)
 	if hasOld {
 		new.SequenceNumber = old.SequenceNumber
 	}
@@ -481,14 +521,17 @@ func (sg *stepGenerator) generateSteps(event RegisterResourceEvent) ([]Step, res
 	// We don't check plans if the resource is invalid, it's going to fail anyway.
 	if !invalid && sg.deployment.plan != nil {
 		resourcePlan, ok := sg.deployment.plan.ResourcePlans[urn]
-		if !ok {
-			if old == nil {
-				// We could error here, but we'll trigger an error later on anyway that Create isn't valid here
-			} else if err := checkMissingPlan(old, inputs, goal); err != nil {
-				return nil, result.FromError(fmt.Errorf("resource %s violates plan: %w", urn, err))
-			}
-		} else {
-			if err := resourcePlan.checkGoal(oldInputs, inputs, goal); err != nil {
+		planInputs := oldInputs
+		if !ok && old != nil {
+			resourcePlan, planInputs = missingResourcePlan(old), old.Inputs
+		}
+		// If there's no plan and no old state we could error here, but we'll trigger an error later on anyway that
+		// Create isn't valid here
+		if resourcePlan != nil {
+			if sg.explainingPlan() {
+				sg.planViolations = append(sg.planViolations,
+					resourcePlan.goalViolations(urn, planInputs, inputs, goal)...)
+			} else if err := resourcePlan.checkGoal(planInputs, inputs, goal); err != nil {
 				return nil, result.FromError(fmt.Errorf("resource %s violates plan: %w", urn, err))
 			}
 		}
@@ -800,6 +843,10 @@ func (sg *stepGenerator) generateStepsFromDiff(
 				//
 				// To do this, we'll utilize the dependency information contained in the snapshot if it is
 				// trustworthy, which is interpreted by the DependencyGraph type.
+				// The resources deleted by this replacement. This is filled in before the steps are returned, so it
+				// is complete by the time any of them are applied.
+				deletions := map[resource.URN]bool{urn: true}
+
 				var steps []Step
 				if sg.opts.TrustDependencies {
 					toReplace, res := sg.calculateDependentReplacements(old)
@@ -832,15 +879,17 @@ func (sg *stepGenerator) generateStepsFromDiff(
 						logging.V(7).Infof("Planner decided to delete '%v' due to dependence on condemned resource '%v'",
 							dependentResource.URN, urn)
 
-						steps = append(steps, NewDeleteReplacementStep(sg.deployment, dependentResource, true))
+						steps = append(steps,
+							NewDeleteReplacementStep(sg.deployment, deletions, dependentResource, true))
 						// Mark the condemned resource as deleted. We won't know until later in the deployment whether
 						// or not we're going to be replacing this resource.
 						sg.deletes[dependentResource.URN] = true
+						deletions[dependentResource.URN] = true
 					}
 				}
 
 				return append(steps,
-					NewDeleteReplacementStep(sg.deployment, old, true),
+					NewDeleteReplacementStep(sg.deployment, deletions, old, true),
 					NewReplaceStep(sg.deployment, old, new, diff.ReplaceKeys, diff.ChangedKeys, diff.DetailedDiff, false),
 					NewCreateReplacementStep(
 						sg.deployment, event, old, new, diff.ReplaceKeys, diff.ChangedKeys, diff.DetailedDiff, false),
@@ -881,6 +930,17 @@ func (sg *stepGenerator) GenerateDeletes(targetsOpt map[resource.URN]bool) ([]St
 	// To compute the deletion list, we must walk the list of old resources *backwards*.  This is because the list is
 	// stored in dependency order, and earlier elements are possibly leaf nodes for later elements.  We must not delete
 	// dependencies prior to their dependent nodes.
+	// The resources deleted by this operation. This includes resources that have already been deleted by a replacement
+	// and is completed once the final set of deletes is known, which is before any of the steps that refer to it are
+	// applied.
+	deletions := make(map[resource.URN]bool)
+	for urn := range sg.deletes {
+		deletions[urn] = true
+	}
+	for urn := range sg.replaces {
+		deletions[urn] = true
+	}
+
 	var dels []Step
 	if prev := sg.deployment.prev; prev != nil {
 		for i := len(prev.Resources) - 1; i >= 0; i-- {
@@ -916,7 +976,7 @@ func (sg *stepGenerator) GenerateDeletes(targetsOpt map[resource.URN]bool) ([]St
 
 				logging.V(7).Infof("Planner decided to delete '%v' due to replacement", res.URN)
 				sg.deletes[res.URN] = true
-				dels = append(dels, NewDeleteReplacementStep(sg.deployment, res, false))
+				dels = append(dels, NewDeleteReplacementStep(sg.deployment, deletions, res, false))
 			} else if _, aliased := sg.aliased[res.URN]; !sg.sames[res.URN] && !sg.updates[res.URN] && !sg.replaces[res.URN] &&
 				!sg.reads[res.URN] && !aliased {
 				// NOTE: we deliberately do not check sg.deletes here, as it is possible for us to issue multiple
@@ -924,7 +984,7 @@ func (sg *stepGenerator) GenerateDeletes(targetsOpt map[resource.URN]bool) ([]St
 				logging.V(7).Infof("Planner decided to delete '%v'", res.URN)
 				sg.deletes[res.URN] = true
 				if !res.PendingReplacement {
-					dels = append(dels, NewDeleteStep(sg.deployment, res))
+					dels = append(dels, NewDeleteStep(sg.deployment, deletions, res))
 				} else {
 					dels = append(dels, NewRemovePendingReplaceStep(sg.deployment, res))
 				}
@@ -935,24 +995,8 @@ func (sg *stepGenerator) GenerateDeletes(targetsOpt map[resource.URN]bool) ([]St
 	// Check each proposed delete against the relevant resource plan
 	for _, s := range dels {
 		if sg.deployment.plan != nil {
-			if resourcePlan, ok := sg.deployment.plan.ResourcePlans[s.URN()]; ok {
-				if len(resourcePlan.Ops) == 0 {
-					return nil, result.Errorf("%v is not allowed by the plan: no more steps were expected for this resource", s.Op())
-				}
-
-				constraint := resourcePlan.Ops[0]
-				// We remove the Op from the list before doing the constraint check.
-				// This is because we look at Ops at the end to see if any expected operations didn't attempt to happen.
-				// This op has been attempted, it just might fail its constraint.
-				resourcePlan.Ops = resourcePlan.Ops[1:]
-
-				if !s.Op().ConstrainedTo(constraint) {
-					return nil, result.Errorf("%v is not allowed by the plan: this resource is constrained to %v", s.Op(), constraint)
-				}
-			} else {
-				if !s.Op().ConstrainedTo(OpSame) {
-					return nil, result.Errorf("%v is not allowed by the plan: no steps were expected for this resource", s.Op())
-				}
+			if res := sg.checkStepAllowed(s); res != nil {
+				return nil, res
 			}
 		}
 
@@ -987,6 +1031,10 @@ func (sg *stepGenerator) GenerateDeletes(targetsOpt map[resource.URN]bool) ([]St
 		dels = filtered
 	}
 
+	for _, step := range dels {
+		deletions[step.URN()] = true
+	}
+
 	deletingUnspecifiedTarget := false
 	for _, step := range dels {
 		urn := step.URN()
@@ -1113,6 +1161,8 @@ func (sg *stepGenerator) determineAllowedResourcesToDeleteFromTargets(
 // called at the start of a deployment in order to find all resources that are pending deletion from the previous
 // deployment.
 func (sg *stepGenerator) GeneratePendingDeletes() []Step {
+	deletions := make(map[resource.URN]bool)
+
 	var dels []Step
 	if prev := sg.deployment.prev; prev != nil {
 		logging.V(7).Infof("stepGenerator.GeneratePendingDeletes(): scanning previous snapshot for pending deletes")
@@ -1122,7 +1172,8 @@ func (sg *stepGenerator) GeneratePendingDeletes() []Step {
 				logging.V(7).Infof(
 					"stepGenerator.GeneratePendingDeletes(): resource (%v, %v) is pending deletion", res.URN, res.ID)
 				sg.pendingDeletes[res] = true
-				dels = append(dels, NewDeleteStep(sg.deployment, res))
+				deletions[res.URN] = true
+				dels = append(dels, NewDeleteStep(sg.deployment, deletions, res))
 			}
 		}
 	}
@@ -0,0 +1,102 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// DefaultTimeout sets the default custom timeouts of the custom resources whose types match a pattern.
+type DefaultTimeout struct {
+	Type     *regexp.Regexp          // the pattern matched against resource types, or nil to match all types.
+	Timeouts resource.CustomTimeouts // the default timeouts, in seconds; zero leaves a timeout unset.
+}
+
+// DefaultTimeouts holds the default custom timeouts for a deployment, in the order in which they are applied.
+type DefaultTimeouts []DefaultTimeout
+
+// NewDefaultTimeouts converts the timeouts of a project into the default custom timeouts for a deployment.
+func NewDefaultTimeouts(timeouts []workspace.ProjectTimeouts) (DefaultTimeouts, error) {
+	var result DefaultTimeouts
+	for i, t := range timeouts {
+		var d DefaultTimeout
+		if t.Type != "" {
+			d.Type = typePattern(t.Type)
+		}
+
+		for _, field := range []struct {
+			name  string
+			value string
+			dest  *float64
+		}{
+			{"create", t.Create, &d.Timeouts.Create},
+			{"update", t.Update, &d.Timeouts.Update},
+			{"delete", t.Delete, &d.Timeouts.Delete},
+		} {
+			if field.value == "" {
+				continue
+			}
+			seconds, err := generateTimeoutInSeconds(field.value)
+			if err != nil {
+				return nil, fmt.Errorf("timeouts %d: %w", i, err)
+			}
+			if seconds <= 0 {
+				return nil, fmt.Errorf("timeouts %d: %s timeout must be positive", i, field.name)
+			}
+			*field.dest = seconds
+		}
+		if !d.Timeouts.IsNotEmpty() {
+			return nil, fmt.Errorf("timeouts %d sets no timeouts", i)
+		}
+
+		result = append(result, d)
+	}
+	return result, nil
+}
+
+// typePattern compiles a type pattern, in which "*" matches any sequence of characters, into a regular expression.
+func typePattern(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// apply returns the given custom timeouts of a resource with the given type, with each unset timeout replaced by its
+// default, if any. The first matching default that sets a timeout wins.
+func (d DefaultTimeouts) apply(typ tokens.Type, timeouts resource.CustomTimeouts) resource.CustomTimeouts {
+	for _, def := range d {
+		if def.Type != nil && !def.Type.MatchString(string(typ)) {
+			continue
+		}
+		if timeouts.Create == 0 {
+			timeouts.Create = def.Timeouts.Create
+		}
+		if timeouts.Update == 0 {
+			timeouts.Update = def.Timeouts.Update
+		}
+		if timeouts.Delete == 0 {
+			timeouts.Delete = def.Timeouts.Delete
+		}
+	}
+	return timeouts
+}
@@ -0,0 +1,77 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func TestNewDefaultTimeouts(t *testing.T) {
+	t.Parallel()
+
+	defaults, err := NewDefaultTimeouts(nil)
+	require.NoError(t, err)
+	assert.Empty(t, defaults)
+
+	_, err = NewDefaultTimeouts([]workspace.ProjectTimeouts{{Type: "aws:*"}})
+	assert.EqualError(t, err, "timeouts 0 sets no timeouts")
+
+	_, err = NewDefaultTimeouts([]workspace.ProjectTimeouts{{Create: "1m"}, {Update: "soon"}})
+	assert.EqualError(t, err, "timeouts 1: unable to parse customTimeout Value soon")
+
+	_, err = NewDefaultTimeouts([]workspace.ProjectTimeouts{{Delete: "-1m"}})
+	assert.EqualError(t, err, "timeouts 0: delete timeout must be positive")
+}
+
+func TestDefaultTimeoutsApply(t *testing.T) {
+	t.Parallel()
+
+	defaults, err := NewDefaultTimeouts([]workspace.ProjectTimeouts{
+		{Type: "aws:rds/*", Create: "40m", Update: "1h"},
+		{Type: "aws:*:Bucket", Delete: "2m"},
+		{Create: "10m", Delete: "5m"},
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		typ      string
+		timeouts resource.CustomTimeouts
+		expected resource.CustomTimeouts
+	}{
+		{
+			"aws:rds/cluster:Cluster",
+			resource.CustomTimeouts{},
+			resource.CustomTimeouts{Create: 2400, Update: 3600, Delete: 300},
+		},
+		{
+			"aws:rds/cluster:Cluster",
+			resource.CustomTimeouts{Create: 60},
+			resource.CustomTimeouts{Create: 60, Update: 3600, Delete: 300},
+		},
+		{"aws:s3/bucket:Bucket", resource.CustomTimeouts{}, resource.CustomTimeouts{Create: 600, Delete: 120}},
+		{"gcp:storage/bucket:Bucket", resource.CustomTimeouts{}, resource.CustomTimeouts{Create: 600, Delete: 300}},
+		{"xaws:rds/cluster:Cluster", resource.CustomTimeouts{}, resource.CustomTimeouts{Create: 600, Delete: 300}},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, defaults.apply(tokens.Type(c.typ), c.timeouts), c.typ)
+	}
+}
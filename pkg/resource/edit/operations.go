@@ -21,6 +21,7 @@ import (
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
 	"github.com/pulumi/pulumi/pkg/v3/resource/graph"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 )
@@ -96,6 +97,93 @@ func LocateResource(snap *deploy.Snapshot, urn resource.URN) []*resource.State {
 	return resources
 }
 
+// LocatePendingOperations returns all pending operations in the given snapshot on resources with the given URN.
+func LocatePendingOperations(snap *deploy.Snapshot, urn resource.URN) []resource.Operation {
+	if snap == nil {
+		return nil
+	}
+
+	var ops []resource.Operation
+	for _, op := range snap.PendingOperations {
+		if op.Resource.URN == urn {
+			ops = append(ops, op)
+		}
+	}
+
+	return ops
+}
+
+// RemovePendingOperation removes the pending operation on the given resource from the snapshot. This does not change
+// the resources in the snapshot: removing a pending create records that the resource was never created, or that it
+// has since been deleted by hand.
+func RemovePendingOperation(snap *deploy.Snapshot, res *resource.State) error {
+	contract.Require(snap != nil, "snap")
+	contract.Require(res != nil, "res")
+
+	for i, op := range snap.PendingOperations {
+		if op.Resource == res {
+			snap.PendingOperations = append(snap.PendingOperations[:i:i], snap.PendingOperations[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no pending operation found for resource %q", res.URN)
+}
+
+// ImportPendingCreate resolves a pending create on the given resource by recording that the resource was created with
+// the given live state, as read from its provider. If the pending create was replacing a resource with the same URN,
+// the replaced resource is marked for deletion.
+func ImportPendingCreate(snap *deploy.Snapshot, res *resource.State, live plugin.ReadResult) error {
+	contract.Require(snap != nil, "snap")
+	contract.Require(res != nil, "res")
+
+	if live.Outputs == nil {
+		return fmt.Errorf("resource %q with ID %q does not exist", res.URN, live.ID)
+	}
+
+	oldResources, oldPendingOperations := snap.Resources, snap.PendingOperations
+	if err := RemovePendingOperation(snap, res); err != nil {
+		return err
+	}
+
+	copied := *res
+	created := &copied
+	created.ID = live.ID
+	created.Outputs = live.Outputs
+	if live.Inputs != nil {
+		created.Inputs = live.Inputs
+	}
+	created.Delete = false
+
+	// If a live resource with the same URN exists, the pending create was a replacement. Mark the replaced resource
+	// for deletion and place its replacement before it, as the engine would have done.
+	resources := make([]*resource.State, 0, len(snap.Resources)+1)
+	var replaced *resource.State
+	for _, r := range snap.Resources {
+		if replaced == nil && r.URN == res.URN && !r.Delete {
+			replaced = r
+			resources = append(resources, created)
+		}
+		resources = append(resources, r)
+	}
+	if replaced == nil {
+		resources = append(resources, created)
+	} else {
+		replaced.Delete = true
+	}
+	snap.Resources = resources
+
+	if err := snap.VerifyIntegrity(); err != nil {
+		snap.Resources, snap.PendingOperations = oldResources, oldPendingOperations
+		if replaced != nil {
+			replaced.Delete = false
+		}
+		return fmt.Errorf("importing %q would produce an invalid snapshot: %w", res.URN, err)
+	}
+
+	return nil
+}
+
 // RenameStack changes the `stackName` component of every URN in a snapshot. In addition, it rewrites the name of
 // the root Stack resource itself. May optionally change the project/package name as well.
 func RenameStack(snap *deploy.Snapshot, newName tokens.Name, newProject tokens.PackageName) error {
@@ -15,6 +15,7 @@
 package edit
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -24,6 +25,7 @@ import (
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
 	"github.com/pulumi/pulumi/pkg/v3/version"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 
 	"github.com/stretchr/testify/assert"
@@ -279,6 +281,117 @@ func TestLocateResourceExact(t *testing.T) {
 	assert.Contains(t, resList, a)
 }
 
+func TestLocatePendingOperations(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA)
+	snap := NewSnapshot([]*resource.State{pA, a})
+	snap.PendingOperations = []resource.Operation{
+		resource.NewOperation(a, resource.OperationTypeUpdating),
+		resource.NewOperation(b, resource.OperationTypeCreating),
+	}
+
+	ops := LocatePendingOperations(snap, b.URN)
+	assert.Equal(t, []resource.Operation{resource.NewOperation(b, resource.OperationTypeCreating)}, ops)
+	assert.Empty(t, LocatePendingOperations(snap, NewResource("c", pA).URN))
+	assert.Empty(t, LocatePendingOperations(nil, b.URN))
+}
+
+func TestRemovePendingOperation(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA)
+	snap := NewSnapshot([]*resource.State{pA, a})
+	snap.PendingOperations = []resource.Operation{
+		resource.NewOperation(a, resource.OperationTypeUpdating),
+		resource.NewOperation(b, resource.OperationTypeCreating),
+	}
+
+	err := RemovePendingOperation(snap, b)
+	assert.NoError(t, err)
+	assert.Equal(t, []resource.Operation{resource.NewOperation(a, resource.OperationTypeUpdating)},
+		snap.PendingOperations)
+	assert.Equal(t, []*resource.State{pA, a}, snap.Resources)
+
+	err = RemovePendingOperation(snap, b)
+	assert.Error(t, err)
+}
+
+func TestImportPendingCreate(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA, a.URN)
+	b.Inputs = resource.PropertyMap{"foo": resource.NewStringProperty("bar")}
+	snap := NewSnapshot([]*resource.State{pA, a})
+	snap.PendingOperations = []resource.Operation{resource.NewOperation(b, resource.OperationTypeCreating)}
+
+	err := ImportPendingCreate(snap, b, plugin.ReadResult{
+		ID:      "b-id",
+		Outputs: resource.PropertyMap{"foo": resource.NewStringProperty("bar"), "arn": resource.NewStringProperty("b")},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, snap.PendingOperations)
+	assert.Len(t, snap.Resources, 3)
+
+	imported := snap.Resources[2]
+	assert.Equal(t, b.URN, imported.URN)
+	assert.Equal(t, resource.ID("b-id"), imported.ID)
+	assert.Equal(t, b.Inputs, imported.Inputs)
+	assert.Equal(t, resource.NewStringProperty("b"), imported.Outputs["arn"])
+	assert.Equal(t, []resource.URN{a.URN}, imported.Dependencies)
+}
+
+func TestImportPendingCreateReplacement(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	a.ID = "a-old"
+	b := NewResource("b", pA, a.URN)
+	aNew := NewResource("a", pA)
+	snap := NewSnapshot([]*resource.State{pA, a, b})
+	snap.PendingOperations = []resource.Operation{resource.NewOperation(aNew, resource.OperationTypeCreating)}
+
+	err := ImportPendingCreate(snap, aNew, plugin.ReadResult{ID: "a-new", Outputs: resource.PropertyMap{}})
+	assert.NoError(t, err)
+	assert.Empty(t, snap.PendingOperations)
+	assert.Len(t, snap.Resources, 4)
+
+	// The replacement is placed before the resource it replaces, which is marked for deletion.
+	assert.Equal(t, resource.ID("a-new"), snap.Resources[1].ID)
+	assert.False(t, snap.Resources[1].Delete)
+	assert.Equal(t, a, snap.Resources[2])
+	assert.True(t, a.Delete)
+	assert.Equal(t, b, snap.Resources[3])
+}
+
+func TestFailedImportPendingCreate(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA, a.URN)
+	snap := NewSnapshot([]*resource.State{pA})
+	ops := []resource.Operation{resource.NewOperation(b, resource.OperationTypeCreating)}
+	snap.PendingOperations = ops
+
+	// A resource that does not exist cannot be imported.
+	err := ImportPendingCreate(snap, b, plugin.ReadResult{ID: "b-id"})
+	assert.EqualError(t, err, fmt.Sprintf("resource %q with ID %q does not exist", b.URN, "b-id"))
+
+	// A resource whose dependencies are missing from the snapshot cannot be imported, and the snapshot is unchanged.
+	err = ImportPendingCreate(snap, b, plugin.ReadResult{ID: "b-id", Outputs: resource.PropertyMap{}})
+	assert.Error(t, err)
+	assert.Equal(t, ops, snap.PendingOperations)
+	assert.Equal(t, []*resource.State{pA}, snap.Resources)
+}
+
 func TestRenameStack(t *testing.T) {
 	t.Parallel()
 
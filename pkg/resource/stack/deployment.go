@@ -167,6 +167,18 @@ func SerializeDeployment(snap *deploy.Snapshot, sm secrets.Manager, showSecrets
 func DeserializeUntypedDeployment(
 	deployment *apitype.UntypedDeployment, secretsProv SecretsProvider) (*deploy.Snapshot, error) {
 
+	v3deployment, err := UpgradeUntypedDeployment(deployment)
+	if err != nil {
+		return nil, err
+	}
+	return DeserializeDeploymentV3(*v3deployment, secretsProv)
+}
+
+// UpgradeUntypedDeployment unmarshals an untyped deployment and migrates it to a DeploymentV3. Unlike
+// DeserializeUntypedDeployment, it does not decrypt the deployment's secrets, and so does not require access to its
+// secrets provider. UpgradeUntypedDeployment will return an error if the untyped deployment's version is not within
+// the range `DeploymentSchemaVersionCurrent` and `DeploymentSchemaVersionOldestSupported`.
+func UpgradeUntypedDeployment(deployment *apitype.UntypedDeployment) (*apitype.DeploymentV3, error) {
 	contract.Require(deployment != nil, "deployment")
 	switch {
 	case deployment.Version > apitype.DeploymentSchemaVersionCurrent:
@@ -197,8 +209,7 @@ func DeserializeUntypedDeployment(
 	default:
 		contract.Failf("unrecognized version: %d", deployment.Version)
 	}
-
-	return DeserializeDeploymentV3(v3deployment, secretsProv)
+	return &v3deployment, nil
 }
 
 // DeserializeDeploymentV3 deserializes a typed DeploymentV3 into a `deploy.Snapshot`.
@@ -323,6 +334,7 @@ func SerializeResource(res *resource.State, enc config.Encrypter, showSecrets bo
 		ImportID:                res.ImportID,
 		SequenceNumber:          res.SequenceNumber,
 		RetainOnDelete:          res.RetainOnDelete,
+		DeletedWith:             res.DeletedWith,
 	}
 
 	if res.CustomTimeouts.IsNotEmpty() {
@@ -504,7 +516,7 @@ func DeserializeResource(res apitype.ResourceV3, dec config.Decrypter, enc confi
 		res.Type, res.URN, res.Custom, res.Delete, res.ID,
 		inputs, outputs, res.Parent, res.Protect, res.External, res.Dependencies, res.InitErrors, res.Provider,
 		res.PropertyDependencies, res.PendingReplacement, res.AdditionalSecretOutputs, res.Aliases, res.CustomTimeouts,
-		res.ImportID, res.SequenceNumber, res.RetainOnDelete), nil
+		res.ImportID, res.SequenceNumber, res.RetainOnDelete, res.DeletedWith), nil
 }
 
 func DeserializeOperation(op apitype.OperationV2, dec config.Decrypter,
@@ -0,0 +1,184 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package age implements support for a secrets manager whose data key is encrypted to one or more age recipients.
+package age
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+)
+
+// Type is the type of secrets managed by this secrets provider
+const Type = "age"
+
+// URLScheme is the scheme of secrets provider URLs that select the age secrets provider, e.g.
+// `age://age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p`.
+const URLScheme = "age://"
+
+// IdentityFileEnvVar is the environment variable that holds the path of the age identity file used to decrypt the
+// data key.
+const IdentityFileEnvVar = "PULUMI_AGE_IDENTITY_FILE"
+
+type ageSecretsManagerState struct {
+	Recipients   []string `json:"recipients"`
+	EncryptedKey []byte   `json:"encryptedkey"`
+}
+
+// IsAgeSecretsProvider returns true if the given secrets provider URL selects the age secrets provider.
+func IsAgeSecretsProvider(secretsProvider string) bool {
+	return strings.HasPrefix(secretsProvider, URLScheme)
+}
+
+// ParseRecipients parses the recipients from an age secrets provider URL of the form
+// `age://<recipient>[,<recipient>...]`.
+func ParseRecipients(secretsProvider string) ([]string, error) {
+	if !IsAgeSecretsProvider(secretsProvider) {
+		return nil, fmt.Errorf("%q is not an age secrets provider URL", secretsProvider)
+	}
+
+	var recipients []string
+	for _, r := range strings.Split(strings.TrimPrefix(secretsProvider, URLScheme), ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			recipients = append(recipients, r)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("an age secrets provider URL must list at least one recipient")
+	}
+	return recipients, nil
+}
+
+// NewAgeSecretsManagerFromState deserialize configuration from state and returns a secrets manager that uses the
+// identity named by PULUMI_AGE_IDENTITY_FILE to decrypt the data key used for envelope encryption of secrets values.
+func NewAgeSecretsManagerFromState(state json.RawMessage) (secrets.Manager, error) {
+	var s ageSecretsManagerState
+	if err := json.Unmarshal(state, &s); err != nil {
+		return nil, fmt.Errorf("unmarshalling state: %w", err)
+	}
+
+	return NewAgeSecretsManager(s.Recipients, s.EncryptedKey)
+}
+
+// GenerateNewDataKey generates a new DataKey seeded by a fresh random 32-byte key and encrypted to the given age
+// recipients.
+func GenerateNewDataKey(recipients []string) ([]byte, error) {
+	plaintextDataKey := make([]byte, 32)
+	_, err := rand.Read(plaintextDataKey)
+	if err != nil {
+		return nil, err
+	}
+	return encryptDataKey(plaintextDataKey, recipients)
+}
+
+// ReencryptDataKey decrypts the given data key using the identity named by PULUMI_AGE_IDENTITY_FILE and encrypts it
+// to the given recipients. This allows the set of recipients to change without re-encrypting any secret values.
+func ReencryptDataKey(encryptedDataKey []byte, recipients []string) ([]byte, error) {
+	plaintextDataKey, err := decryptDataKey(encryptedDataKey)
+	if err != nil {
+		return nil, err
+	}
+	return encryptDataKey(plaintextDataKey, recipients)
+}
+
+// NewAgeSecretsManager returns a secrets manager that uses the identity named by PULUMI_AGE_IDENTITY_FILE to decrypt
+// a data key used for envelope encryption of secrets values. The data key must have been encrypted to the given
+// recipients.
+func NewAgeSecretsManager(recipients []string, encryptedDataKey []byte) (*Manager, error) {
+	plaintextDataKey, err := decryptDataKey(encryptedDataKey)
+	if err != nil {
+		return nil, err
+	}
+	crypter := config.NewSymmetricCrypter(plaintextDataKey)
+	return &Manager{
+		crypter: crypter,
+		state: ageSecretsManagerState{
+			Recipients:   recipients,
+			EncryptedKey: encryptedDataKey,
+		},
+	}, nil
+}
+
+func encryptDataKey(plaintextDataKey []byte, recipients []string) ([]byte, error) {
+	parsed, err := age.ParseRecipients(strings.NewReader(strings.Join(recipients, "\n")))
+	if err != nil {
+		return nil, fmt.Errorf("parsing age recipients: %w", err)
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, parsed...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(plaintextDataKey); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decryptDataKey(encryptedDataKey []byte) ([]byte, error) {
+	identityFile := os.Getenv(IdentityFileEnvVar)
+	if identityFile == "" {
+		return nil, fmt.Errorf("%s must be set to the path of an age identity file in order to decrypt secrets",
+			IdentityFileEnvVar)
+	}
+
+	f, err := os.Open(identityFile)
+	if err != nil {
+		return nil, fmt.Errorf("opening age identity file: %w", err)
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing age identity file %s: %w", identityFile, err)
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(encryptedDataKey), identities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, fmt.Errorf("the identities in %s are not recipients of this stack's data key", identityFile)
+		}
+		return nil, fmt.Errorf("decrypting data key: %w", err)
+	}
+	return ioutil.ReadAll(r)
+}
+
+// Manager is the secrets.Manager implementation for age recipients
+type Manager struct {
+	state   ageSecretsManagerState
+	crypter config.Crypter
+}
+
+func (m *Manager) Type() string                         { return Type }
+func (m *Manager) State() interface{}                   { return m.state }
+func (m *Manager) Encrypter() (config.Encrypter, error) { return m.crypter, nil }
+func (m *Manager) Decrypter() (config.Decrypter, error) { return m.crypter, nil }
+func (m *Manager) EncryptedKey() []byte                 { return m.state.EncryptedKey }
+func (m *Manager) Recipients() []string                 { return m.state.Recipients }
@@ -0,0 +1,132 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package age
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeIdentity generates a new age identity, writes it to a file in a temporary directory, and returns the path of
+// the file along with the identity's recipient.
+func writeIdentity(t *testing.T) (string, string) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "identity.txt")
+	err = ioutil.WriteFile(path, []byte(identity.String()+"\n"), 0600)
+	require.NoError(t, err)
+
+	return path, identity.Recipient().String()
+}
+
+func setIdentityFile(path string) func() {
+	old, had := os.LookupEnv(IdentityFileEnvVar)
+	os.Setenv(IdentityFileEnvVar, path)
+	return func() {
+		if had {
+			os.Setenv(IdentityFileEnvVar, old)
+		} else {
+			os.Unsetenv(IdentityFileEnvVar)
+		}
+	}
+}
+
+func TestParseRecipients(t *testing.T) {
+	t.Parallel()
+
+	recipients, err := ParseRecipients("age://age1a, age1b,")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"age1a", "age1b"}, recipients)
+
+	_, err = ParseRecipients("age://")
+	assert.Error(t, err)
+
+	_, err = ParseRecipients("awskms://alias/key")
+	assert.Error(t, err)
+}
+
+//nolint:paralleltest // mutates environment variables
+func TestAgeManagerRoundTrip(t *testing.T) {
+	aliceFile, alice := writeIdentity(t)
+	bobFile, bob := writeIdentity(t)
+
+	reset := setIdentityFile(aliceFile)
+	defer reset()
+
+	dataKey, err := GenerateNewDataKey([]string{alice})
+	require.NoError(t, err)
+
+	manager, err := NewAgeSecretsManager([]string{alice}, dataKey)
+	require.NoError(t, err)
+
+	enc, err := manager.Encrypter()
+	require.NoError(t, err)
+	ciphertext, err := enc.EncryptValue("hunter2")
+	require.NoError(t, err)
+
+	// The state must list the recipients, and must be enough to reconstruct the manager.
+	state, err := json.Marshal(manager.State())
+	require.NoError(t, err)
+	restored, err := NewAgeSecretsManagerFromState(state)
+	require.NoError(t, err)
+	assert.Equal(t, []string{alice}, restored.(*Manager).Recipients())
+
+	dec, err := restored.Decrypter()
+	require.NoError(t, err)
+	plaintext, err := dec.DecryptValue(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", plaintext)
+
+	// Bob is not yet a recipient of the data key.
+	os.Setenv(IdentityFileEnvVar, bobFile)
+	_, err = NewAgeSecretsManager([]string{alice}, dataKey)
+	assert.Error(t, err)
+
+	// Adding Bob only requires re-encrypting the data key, after which he can decrypt the existing ciphertext.
+	os.Setenv(IdentityFileEnvVar, aliceFile)
+	dataKey, err = ReencryptDataKey(dataKey, []string{alice, bob})
+	require.NoError(t, err)
+
+	os.Setenv(IdentityFileEnvVar, bobFile)
+	manager, err = NewAgeSecretsManager([]string{alice, bob}, dataKey)
+	require.NoError(t, err)
+	dec, err = manager.Decrypter()
+	require.NoError(t, err)
+	plaintext, err = dec.DecryptValue(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", plaintext)
+}
+
+//nolint:paralleltest // mutates environment variables
+func TestAgeManagerRequiresIdentityFile(t *testing.T) {
+	_, alice := writeIdentity(t)
+
+	reset := setIdentityFile("")
+	defer reset()
+
+	dataKey, err := GenerateNewDataKey([]string{alice})
+	require.NoError(t, err)
+
+	_, err = NewAgeSecretsManager([]string{alice}, dataKey)
+	assert.ErrorContains(t, err, IdentityFileEnvVar)
+}
@@ -36,51 +36,7 @@ import (
 
 const Type = "passphrase"
 
-var ErrIncorrectPassphrase = errors.New("incorrect passphrase")
-
-// given a passphrase and an encryption state, construct a Crypter from it. Our encryption
-// state value is a version tag followed by version specific state information. Presently, we only have one version
-// we support (`v1`) which is AES-256-GCM using a key derived from a passphrase using 1,000,000 iterations of PDKDF2
-// using SHA256.
-func symmetricCrypterFromPhraseAndState(phrase string, state string) (config.Crypter, error) {
-	splits := strings.SplitN(state, ":", 3)
-	if len(splits) != 3 {
-		return nil, errors.New("malformed state value")
-	}
-
-	if splits[0] != "v1" {
-		return nil, errors.New("unknown state version")
-	}
-
-	salt, err := base64.StdEncoding.DecodeString(splits[1])
-	if err != nil {
-		return nil, err
-	}
-
-	decrypter := config.NewSymmetricCrypterFromPassphrase(phrase, salt)
-	decrypted, err := decrypter.DecryptValue(state[indexN(state, ":", 2)+1:])
-	if err != nil || decrypted != "pulumi" {
-		return nil, ErrIncorrectPassphrase
-	}
-
-	return decrypter, nil
-}
-
-func indexN(s string, substr string, n int) int {
-	contract.Require(n > 0, "n")
-	scratch := s
-
-	for i := n; i > 0; i-- {
-		idx := strings.Index(scratch, substr)
-		if i == -1 {
-			return -1
-		}
-
-		scratch = scratch[idx+1:]
-	}
-
-	return len(s) - (len(scratch) + len(substr))
-}
+var ErrIncorrectPassphrase = config.ErrIncorrectPassphrase
 
 type localSecretsManagerState struct {
 	Salt string `json:"salt"`
@@ -146,7 +102,7 @@ func NewPassphaseSecretsManager(phrase string, state string) (secrets.Manager, e
 	}
 
 	// Wasn't in the cache so try to construct it and add it if there's no error.
-	crypter, err := symmetricCrypterFromPhraseAndState(phrase, state)
+	crypter, err := config.NewSymmetricCrypterFromPassphraseState(phrase, state)
 	if err != nil {
 		return nil, err
 	}
@@ -26,6 +26,7 @@ import (
 	"path/filepath"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdestroy"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optimport"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optrefresh"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
@@ -1012,6 +1013,20 @@ func ExampleStack_Refresh_streamingProgress() {
 	stack.Refresh(ctx, optrefresh.ProgressStreams(progressStreams...))
 }
 
+func ExampleStack_ImportResources() {
+	ctx := context.Background()
+	stackName := FullyQualifiedStackName("org", "project", "stack")
+	// select an existing stack and import an existing bucket into it
+	stack, _ := SelectStackLocalSource(ctx, stackName, filepath.Join(".", "program"))
+	res, _ := stack.ImportResources(ctx, []ImportResource{{
+		Type: "aws:s3/bucket:Bucket",
+		Name: "bucket",
+		ID:   "my-existing-bucket",
+	}}, optimport.Language("typescript"))
+	// add the generated code to the program
+	fmt.Println(res.GeneratedCode)
+}
+
 func ExampleStack_GetAllConfig() {
 	ctx := context.Background()
 	stackName := FullyQualifiedStackName("org", "project", "stack")
@@ -0,0 +1,481 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// ConfigCrypterFunc returns the crypter used to encrypt and decrypt the secret configuration of the named stack.
+// It is passed the stack's current settings, which identify its secrets provider.
+type ConfigCrypterFunc func(
+	ctx context.Context, stackName string, settings *workspace.ProjectStack) (config.Crypter, error)
+
+// ConfigCrypter sets the function that LocalConfig uses to encrypt and decrypt secret configuration values. Without
+// it, only passphrase-encrypted configuration is handled in process; the secrets of stacks that use other secrets
+// providers are encrypted and decrypted by the Pulumi CLI.
+func ConfigCrypter(fn ConfigCrypterFunc) LocalWorkspaceOption {
+	return localWorkspaceOption(func(lo *localWorkspaceOptions) {
+		lo.ConfigCrypter = fn
+	})
+}
+
+// LocalConfig provides in-process access to the configuration held in a stack's settings file
+// (Pulumi.<stack>.yaml). Unlike the GetConfig and SetConfig family of Workspace methods, LocalConfig does not invoke
+// the Pulumi CLI, supports path-based access to structured values (equivalent to `pulumi config --path`), and
+// batches changes in memory until Save is called.
+//
+// Secret values are encrypted and decrypted with the stack's secrets provider. Passphrase-encrypted stacks are
+// supported in process using PULUMI_CONFIG_PASSPHRASE or PULUMI_CONFIG_PASSPHRASE_FILE from the workspace's
+// environment variables, as are stacks for which a ConfigCrypter workspace option is given. The secrets of other
+// stacks, such as those that use the Pulumi Service or a cloud KMS, are encrypted and decrypted by the Pulumi CLI in
+// batches: GetAll and the first Get decrypt every secret in the stack's settings with a single CLI invocation, and
+// SetAll encrypts all of its secret values with another. Each call to Set with a secret value invokes the CLI.
+type LocalConfig struct {
+	ws        *LocalWorkspace
+	stackName string
+	project   tokens.PackageName
+	settings  *workspace.ProjectStack
+	crypter   config.Crypter // the in-process crypter for the stack's secrets, if there is one.
+	cli       *cliCrypter    // the CLI-backed crypter for the stack's secrets, if there is no in-process crypter.
+}
+
+// LocalConfig loads the configuration of the named stack from its settings file for in-process reading and
+// writing. See LocalConfig for details.
+func (l *LocalWorkspace) LocalConfig(ctx context.Context, stackName string) (*LocalConfig, error) {
+	proj, err := l.ProjectSettings(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read project settings")
+	}
+	settings, err := l.StackSettings(ctx, stackName)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Config == nil {
+		settings.Config = make(config.Map)
+	}
+
+	return &LocalConfig{
+		ws:        l,
+		stackName: stackName,
+		project:   proj.Name,
+		settings:  settings,
+	}, nil
+}
+
+// Get returns the value associated with the given key. If path is true, the key is treated as a property path
+// (e.g. `data.nested[0].key`) into a structured configuration value. Secret values are returned decrypted.
+func (c *LocalConfig) Get(ctx context.Context, key string, path bool) (ConfigValue, error) {
+	k, err := c.parseKey(key)
+	if err != nil {
+		return ConfigValue{}, err
+	}
+
+	v, ok, err := c.settings.Config.Get(k, path)
+	if err != nil {
+		return ConfigValue{}, err
+	}
+	if !ok {
+		return ConfigValue{}, errors.Errorf("configuration key '%s' not found for stack '%s'", key, c.stackName)
+	}
+	return c.decrypt(ctx, v)
+}
+
+// GetAll returns the complete configuration of the stack, keyed by fully-qualified configuration key. Secret values
+// are returned decrypted.
+func (c *LocalConfig) GetAll(ctx context.Context) (ConfigMap, error) {
+	if err := c.prefetchSecrets(ctx); err != nil {
+		return nil, err
+	}
+
+	result := make(ConfigMap, len(c.settings.Config))
+	for k, v := range c.settings.Config {
+		val, err := c.decrypt(ctx, v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read config value %s", k)
+		}
+		result[k.String()] = val
+	}
+	return result, nil
+}
+
+// Set sets the value associated with the given key, encrypting it if it is secret. If path is true, the key is
+// treated as a property path into a structured configuration value, and any intermediate objects or arrays are
+// created as necessary.
+func (c *LocalConfig) Set(ctx context.Context, key string, val ConfigValue, path bool) error {
+	k, err := c.parseKey(key)
+	if err != nil {
+		return err
+	}
+
+	v := config.NewValue(val.Value)
+	if val.Secret {
+		ciphertexts, err := c.encrypt(ctx, []string{val.Value})
+		if err != nil {
+			return err
+		}
+		v = config.NewSecureValue(ciphertexts[0])
+	}
+
+	return c.settings.Config.Set(k, v, path)
+}
+
+// SetAll sets each of the values in the given map. If path is true, each key is treated as a property path. The
+// secret values are encrypted together.
+func (c *LocalConfig) SetAll(ctx context.Context, cfg ConfigMap, path bool) error {
+	keys := make([]string, 0, len(cfg))
+	var plaintexts []string
+	for k, v := range cfg {
+		keys = append(keys, k)
+		if v.Secret {
+			plaintexts = append(plaintexts, v.Value)
+		}
+	}
+	sort.Strings(keys)
+
+	// Encrypt the secrets first so that the configuration is left unchanged if they can't be encrypted.
+	ciphertexts := map[string]string{}
+	if len(plaintexts) != 0 {
+		encrypted, err := c.encrypt(ctx, plaintexts)
+		if err != nil {
+			return err
+		}
+		for i, plaintext := range plaintexts {
+			ciphertexts[plaintext] = encrypted[i]
+		}
+	}
+
+	for _, key := range keys {
+		k, err := c.parseKey(key)
+		if err != nil {
+			return err
+		}
+		v := config.NewValue(cfg[key].Value)
+		if cfg[key].Secret {
+			v = config.NewSecureValue(ciphertexts[cfg[key].Value])
+		}
+		if err = c.settings.Config.Set(k, v, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove removes the value associated with the given key. If path is true, the key is treated as a property path
+// into a structured configuration value.
+func (c *LocalConfig) Remove(key string, path bool) error {
+	k, err := c.parseKey(key)
+	if err != nil {
+		return err
+	}
+	return c.settings.Config.Remove(k, path)
+}
+
+// Save writes any changes back to the stack's settings file.
+func (c *LocalConfig) Save(ctx context.Context) error {
+	return c.ws.SaveStackSettings(ctx, c.stackName, c.settings)
+}
+
+// parseKey parses a configuration key. As with the CLI, a key with no namespace is treated as belonging to the
+// current project.
+func (c *LocalConfig) parseKey(key string) (config.Key, error) {
+	if !strings.Contains(key, tokens.TokenDelimiter) {
+		key = fmt.Sprintf("%s:%s", c.project, key)
+	}
+	return config.ParseKey(key)
+}
+
+func (c *LocalConfig) decrypt(ctx context.Context, v config.Value) (ConfigValue, error) {
+	if !v.Secure() {
+		raw, err := v.Value(config.NopDecrypter)
+		if err != nil {
+			return ConfigValue{}, err
+		}
+		return ConfigValue{Value: raw}, nil
+	}
+
+	var decrypter config.Decrypter
+	crypter, err := c.getCrypter(ctx)
+	switch {
+	case err != nil:
+		return ConfigValue{}, err
+	case crypter != nil:
+		decrypter = crypter
+	default:
+		// Decrypt every secret that the stack holds at once, rather than invoking the CLI for each value.
+		if err = c.prefetchSecrets(ctx); err != nil {
+			return ConfigValue{}, err
+		}
+		decrypter = c.cli
+	}
+
+	raw, err := v.Value(decrypter)
+	if err != nil {
+		return ConfigValue{}, errors.Wrap(err, "unable to decrypt config value")
+	}
+	return ConfigValue{Value: raw, Secret: true}, nil
+}
+
+// encrypt encrypts the given plaintexts with the stack's secrets provider.
+func (c *LocalConfig) encrypt(ctx context.Context, plaintexts []string) ([]string, error) {
+	crypter, err := c.getCrypter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if crypter == nil {
+		return c.cli.encryptAll(ctx, plaintexts)
+	}
+
+	ciphertexts := make([]string, len(plaintexts))
+	for i, plaintext := range plaintexts {
+		if ciphertexts[i], err = crypter.EncryptValue(plaintext); err != nil {
+			return nil, errors.Wrap(err, "unable to encrypt config value")
+		}
+	}
+	return ciphertexts, nil
+}
+
+// prefetchSecrets decrypts every secret in the stack's settings with the CLI, if the stack's secrets can't be
+// decrypted in process.
+func (c *LocalConfig) prefetchSecrets(ctx context.Context) error {
+	crypter, err := c.getCrypter(ctx)
+	if err != nil || crypter != nil {
+		return err
+	}
+
+	var ciphertexts []string
+	for _, v := range c.settings.Config {
+		if !v.Secure() {
+			continue
+		}
+		// Decrypting a value with the nop decrypter yields the ciphertexts of its secure values.
+		secure, err := v.SecureValues(config.NopDecrypter)
+		if err != nil {
+			return err
+		}
+		ciphertexts = append(ciphertexts, secure...)
+	}
+	return c.cli.decryptAll(ctx, ciphertexts)
+}
+
+// getCrypter returns the in-process crypter for the stack's secrets provider, creating it on first use. If the
+// stack's secrets can only be encrypted and decrypted by the CLI, getCrypter returns nil and sets c.cli.
+func (c *LocalConfig) getCrypter(ctx context.Context) (config.Crypter, error) {
+	if c.crypter != nil || c.cli != nil {
+		return c.crypter, nil
+	}
+
+	var crypter config.Crypter
+	var err error
+	switch {
+	case c.ws.configCrypter != nil:
+		crypter, err = c.ws.configCrypter(ctx, c.stackName, c.settings)
+	case c.settings.EncryptionSalt != "":
+		crypter, err = c.newPassphraseCrypter()
+	default:
+		c.cli = c.newCLICrypter()
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.crypter = crypter
+	return crypter, nil
+}
+
+// newPassphraseCrypter creates a crypter for a stack that uses the passphrase secrets provider. The passphrase is
+// read from the workspace's environment variables, falling back to the environment of the current process.
+func (c *LocalConfig) newPassphraseCrypter() (config.Crypter, error) {
+	getenv := func(key string) (string, bool) {
+		if v, ok := c.ws.envvars[key]; ok {
+			return v, true
+		}
+		return os.LookupEnv(key)
+	}
+
+	phrase, ok := getenv("PULUMI_CONFIG_PASSPHRASE")
+	if !ok {
+		phraseFile, ok := getenv("PULUMI_CONFIG_PASSPHRASE_FILE")
+		if !ok || phraseFile == "" {
+			return nil, errors.New("unable to decrypt secrets: " +
+				"PULUMI_CONFIG_PASSPHRASE or PULUMI_CONFIG_PASSPHRASE_FILE must be set")
+		}
+		b, err := ioutil.ReadFile(phraseFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read PULUMI_CONFIG_PASSPHRASE_FILE")
+		}
+		phrase = strings.TrimSpace(string(b))
+	}
+
+	return config.NewSymmetricCrypterFromPassphraseState(phrase, c.settings.EncryptionSalt)
+}
+
+// cliCrypter encrypts and decrypts the secrets of a stack whose secrets provider can only be used by the Pulumi CLI.
+// Each batch of values is encrypted or decrypted by a single CLI invocation against a scratch settings file that has
+// the stack's secrets provider settings, and the plaintext of each ciphertext is cached so that it is only sent to the
+// CLI once. As a Decrypter, a cliCrypter only decrypts the ciphertexts that it has already encrypted or decrypted.
+type cliCrypter struct {
+	ws         *LocalWorkspace
+	stackName  string
+	project    tokens.PackageName
+	settings   *workspace.ProjectStack
+	plaintexts map[string]string // the plaintext of each ciphertext, keyed by ciphertext.
+}
+
+// newCLICrypter creates a CLI-backed crypter for the stack's secrets.
+func (c *LocalConfig) newCLICrypter() *cliCrypter {
+	return &cliCrypter{
+		ws:         c.ws,
+		stackName:  c.stackName,
+		project:    c.project,
+		settings:   c.settings,
+		plaintexts: map[string]string{},
+	}
+}
+
+func (c *cliCrypter) DecryptValue(ciphertext string) (string, error) {
+	plaintext, ok := c.plaintexts[ciphertext]
+	if !ok {
+		return "", errors.New("secret was not decrypted by the Pulumi CLI")
+	}
+	return plaintext, nil
+}
+
+func (c *cliCrypter) BulkDecrypt(ciphertexts []string) (map[string]string, error) {
+	return config.DefaultBulkDecrypt(c, ciphertexts)
+}
+
+// decryptAll decrypts the given ciphertexts with the CLI, unless they have already been decrypted.
+func (c *cliCrypter) decryptAll(ctx context.Context, ciphertexts []string) error {
+	var keys []config.Key
+	scratch, seen := c.scratchSettings(), map[string]bool{}
+	for _, ciphertext := range ciphertexts {
+		if _, ok := c.plaintexts[ciphertext]; ok || seen[ciphertext] {
+			continue
+		}
+		seen[ciphertext] = true
+
+		key := config.MustMakeKey(string(c.project), fmt.Sprintf("secret%d", len(keys)))
+		scratch.Config[key] = config.NewSecureValue(ciphertext)
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	stdout, _, err := c.run(ctx, scratch, "config", "--show-secrets", "--json")
+	if err != nil {
+		return errors.Wrap(err, "unable to decrypt config values")
+	}
+	var values ConfigMap
+	if err = json.Unmarshal([]byte(stdout), &values); err != nil {
+		return errors.Wrap(err, "unable to unmarshal config values")
+	}
+	for _, key := range keys {
+		ciphertext, err := scratch.Config[key].Value(config.NopDecrypter)
+		contract.AssertNoError(err)
+		v, ok := values[key.String()]
+		if !ok {
+			return errors.Errorf("the Pulumi CLI did not decrypt config value %s", key)
+		}
+		c.plaintexts[ciphertext] = v.Value
+	}
+	return nil
+}
+
+// encryptAll encrypts the given plaintexts with the CLI. If the CLI initializes the stack's secrets provider in the
+// process, the provider's settings are copied to the stack's settings.
+func (c *cliCrypter) encryptAll(ctx context.Context, plaintexts []string) ([]string, error) {
+	args := []string{"config", "set-all"}
+	keys := make([]config.Key, len(plaintexts))
+	for i, plaintext := range plaintexts {
+		keys[i] = config.MustMakeKey(string(c.project), fmt.Sprintf("secret%d", i))
+		args = append(args, "--secret", fmt.Sprintf("%s=%s", keys[i], plaintext))
+	}
+
+	_, saved, err := c.run(ctx, c.scratchSettings(), args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to encrypt config values")
+	}
+
+	ciphertexts := make([]string, len(plaintexts))
+	for i, key := range keys {
+		v, ok := saved.Config[key]
+		if !ok || !v.Secure() {
+			return nil, errors.Errorf("the Pulumi CLI did not encrypt config value %s", key)
+		}
+		ciphertext, err := v.Value(config.NopDecrypter)
+		contract.AssertNoError(err)
+		ciphertexts[i], c.plaintexts[ciphertext] = ciphertext, plaintexts[i]
+	}
+	c.settings.SecretsProvider = saved.SecretsProvider
+	c.settings.EncryptedKey = saved.EncryptedKey
+	c.settings.EncryptionSalt = saved.EncryptionSalt
+	return ciphertexts, nil
+}
+
+// scratchSettings returns settings with the stack's secrets provider settings and no configuration.
+func (c *cliCrypter) scratchSettings() *workspace.ProjectStack {
+	return &workspace.ProjectStack{
+		SecretsProvider: c.settings.SecretsProvider,
+		EncryptedKey:    c.settings.EncryptedKey,
+		EncryptionSalt:  c.settings.EncryptionSalt,
+		Config:          config.Map{},
+	}
+}
+
+// run runs a `pulumi config` command for the stack against a scratch settings file holding the given settings, and
+// returns the command's standard output and the settings file as the command left it.
+func (c *cliCrypter) run(
+	ctx context.Context, scratch *workspace.ProjectStack, args ...string) (string, *workspace.ProjectStack, error) {
+
+	dir, err := ioutil.TempDir("", "pulumi-config-")
+	if err != nil {
+		return "", nil, err
+	}
+	defer contract.IgnoreError(os.RemoveAll(dir))
+
+	path := filepath.Join(dir, "Pulumi.stack.yaml")
+	if err = scratch.Save(path); err != nil {
+		return "", nil, err
+	}
+
+	args = append(args, "--stack", c.stackName, "--config-file", path)
+	stdout, stderr, code, err := c.ws.runPulumiCmdSync(ctx, args...)
+	if err != nil {
+		return "", nil, newAutoError(err, stdout, stderr, code)
+	}
+
+	saved, err := workspace.LoadProjectStack(path)
+	if err != nil {
+		return "", nil, err
+	}
+	return stdout, saved, nil
+}
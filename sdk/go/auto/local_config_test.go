@@ -0,0 +1,166 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auto
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// newLocalConfigTestWorkspace creates a workspace in a temporary directory that holds a single passphrase-encrypted
+// stack named "dev".
+func newLocalConfigTestWorkspace(t *testing.T, passphrase string) *LocalWorkspace {
+	ctx := context.Background()
+
+	salt := make([]byte, 8)
+	_, err := cryptorand.Read(salt)
+	require.NoError(t, err)
+	check, err := config.NewSymmetricCrypterFromPassphrase(passphrase, salt).EncryptValue("pulumi")
+	require.NoError(t, err)
+
+	ws := &LocalWorkspace{
+		workDir: t.TempDir(),
+		envvars: map[string]string{"PULUMI_CONFIG_PASSPHRASE": passphrase},
+	}
+	err = ws.SaveProjectSettings(ctx, &workspace.Project{
+		Name:    "testproj",
+		Runtime: workspace.NewProjectRuntimeInfo("go", nil),
+	})
+	require.NoError(t, err)
+	err = ws.SaveStackSettings(ctx, "dev", &workspace.ProjectStack{
+		EncryptionSalt: "v1:" + base64.StdEncoding.EncodeToString(salt) + ":" + check,
+	})
+	require.NoError(t, err)
+	return ws
+}
+
+func TestLocalConfigRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ws := newLocalConfigTestWorkspace(t, "password")
+
+	cfg, err := ws.LocalConfig(ctx, "dev")
+	require.NoError(t, err)
+	require.NoError(t, cfg.SetAll(ctx, ConfigMap{
+		"region":                {Value: "us-west-2"},
+		"db.users[0].name":      {Value: "admin"},
+		"db.users[0].password":  {Value: "hunter2", Secret: true},
+		"aws:profile":           {Value: "prod"},
+		"tags.environment":      {Value: "production"},
+		"tags.owner":            {Value: "platform"},
+		"tags[\"cost-center\"]": {Value: "42"},
+	}, true /*path*/))
+	require.NoError(t, cfg.Remove("tags.owner", true /*path*/))
+	require.NoError(t, cfg.Save(ctx))
+
+	// The secret must be encrypted at rest.
+	settings, err := ws.StackSettings(ctx, "dev")
+	require.NoError(t, err)
+	db, ok, err := settings.Config.Get(config.MustMakeKey("testproj", "db"), false /*path*/)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.True(t, db.Secure())
+	ciphertext, err := db.Value(config.NopDecrypter)
+	require.NoError(t, err)
+	assert.NotContains(t, ciphertext, "hunter2")
+
+	// Re-read the configuration from disk.
+	cfg, err = ws.LocalConfig(ctx, "dev")
+	require.NoError(t, err)
+
+	password, err := cfg.Get(ctx, "db.users[0].password", true /*path*/)
+	require.NoError(t, err)
+	assert.Equal(t, ConfigValue{Value: "hunter2", Secret: true}, password)
+
+	region, err := cfg.Get(ctx, "testproj:region", false /*path*/)
+	require.NoError(t, err)
+	assert.Equal(t, ConfigValue{Value: "us-west-2"}, region)
+
+	all, err := cfg.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ConfigMap{
+		"aws:profile":     {Value: "prod"},
+		"testproj:region": {Value: "us-west-2"},
+		"testproj:db":     {Value: `{"users":[{"name":"admin","password":"hunter2"}]}`, Secret: true},
+		"testproj:tags":   {Value: `{"cost-center":42,"environment":"production"}`},
+	}, all)
+
+	_, err = cfg.Get(ctx, "tags.owner", true /*path*/)
+	assert.Error(t, err)
+}
+
+func TestLocalConfigIncorrectPassphrase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ws := newLocalConfigTestWorkspace(t, "password")
+	ws.envvars["PULUMI_CONFIG_PASSPHRASE"] = "wrong"
+
+	cfg, err := ws.LocalConfig(ctx, "dev")
+	require.NoError(t, err)
+
+	// Plaintext values do not require the passphrase.
+	require.NoError(t, cfg.Set(ctx, "region", ConfigValue{Value: "us-west-2"}, false /*path*/))
+
+	err = cfg.Set(ctx, "password", ConfigValue{Value: "hunter2", Secret: true}, false /*path*/)
+	assert.EqualError(t, err, "incorrect passphrase")
+}
+
+func TestLocalConfigCrypter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ws := &LocalWorkspace{workDir: t.TempDir()}
+	err := ws.SaveProjectSettings(ctx, &workspace.Project{
+		Name:    "testproj",
+		Runtime: workspace.NewProjectRuntimeInfo("go", nil),
+	})
+	require.NoError(t, err)
+	err = ws.SaveStackSettings(ctx, "dev", &workspace.ProjectStack{SecretsProvider: "awskms://alias/test"})
+	require.NoError(t, err)
+
+	// Without a ConfigCrypter, the secret is encrypted by the CLI, which fails as the stack doesn't exist.
+	cfg, err := ws.LocalConfig(ctx, "dev")
+	require.NoError(t, err)
+	err = cfg.Set(ctx, "password", ConfigValue{Value: "hunter2", Secret: true}, false /*path*/)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to encrypt config values")
+	_, ok := cfg.settings.Config[config.MustMakeKey("testproj", "password")]
+	assert.False(t, ok)
+
+	var calledFor string
+	ws.configCrypter = func(
+		ctx context.Context, stackName string, settings *workspace.ProjectStack) (config.Crypter, error) {
+
+		calledFor = stackName + " " + settings.SecretsProvider
+		return config.NewSymmetricCrypter(make([]byte, config.SymmetricCrypterKeyBytes)), nil
+	}
+	cfg, err = ws.LocalConfig(ctx, "dev")
+	require.NoError(t, err)
+	require.NoError(t, cfg.Set(ctx, "password", ConfigValue{Value: "hunter2", Secret: true}, false /*path*/))
+	password, err := cfg.Get(ctx, "password", false /*path*/)
+	require.NoError(t, err)
+	assert.Equal(t, ConfigValue{Value: "hunter2", Secret: true}, password)
+	assert.Equal(t, "dev awskms://alias/test", calledFor)
+}
@@ -51,6 +51,8 @@ type LocalWorkspace struct {
 	envvars         map[string]string
 	secretsProvider string
 	pulumiVersion   semver.Version
+	configCrypter   ConfigCrypterFunc
+	providerPool    *ProviderPool
 }
 
 var settingsExtensions = []string{".yaml", ".yml", ".json"}
@@ -587,9 +589,11 @@ func NewLocalWorkspace(ctx context.Context, opts ...LocalWorkspaceOption) (Works
 	}
 
 	l := &LocalWorkspace{
-		workDir:    workDir,
-		program:    program,
-		pulumiHome: lwOpts.PulumiHome,
+		workDir:       workDir,
+		program:       program,
+		pulumiHome:    lwOpts.PulumiHome,
+		configCrypter: lwOpts.ConfigCrypter,
+		providerPool:  lwOpts.ProviderPool,
 	}
 
 	// optOut indicates we should skip the version check.
@@ -664,6 +668,10 @@ type localWorkspaceOptions struct {
 	// EnvVars is a map of environment values scoped to the workspace.
 	// These values will be passed to all Workspace and Stack level commands.
 	EnvVars map[string]string
+	// ConfigCrypter returns the crypter LocalConfig uses for the secret configuration of a stack.
+	ConfigCrypter ConfigCrypterFunc
+	// ProviderPool is the pool of provider processes that stack operations use.
+	ProviderPool *ProviderPool
 }
 
 // LocalWorkspaceOption is used to customize and configure a LocalWorkspace at initialization time.
@@ -783,6 +791,15 @@ func EnvVars(envvars map[string]string) LocalWorkspaceOption {
 	})
 }
 
+// Providers is the pool of provider processes that the previews, updates, refreshes and destroys of the workspace's
+// stacks use instead of starting their own processes for the pooled providers. The pool may be shared by many
+// workspaces.
+func Providers(pool *ProviderPool) LocalWorkspaceOption {
+	return localWorkspaceOption(func(lo *localWorkspaceOptions) {
+		lo.ProviderPool = pool
+	})
+}
+
 // NewStackLocalSource creates a Stack backed by a LocalWorkspace created on behalf of the user,
 // from the specified WorkDir. This Workspace will pick up
 // any available Settings files (Pulumi.yaml, Pulumi.<stack>.yaml).
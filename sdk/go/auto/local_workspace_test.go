@@ -19,6 +19,7 @@ import (
 	"context"
 	cryptorand "crypto/rand"
 	"encoding/hex"
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -33,6 +34,7 @@ import (
 
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdestroy"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optimport"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optrefresh"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optremove"
@@ -1251,6 +1253,240 @@ func TestImportExportStack(t *testing.T) {
 	assert.Equal(t, "succeeded", dRes.Summary.Result)
 }
 
+func TestImportResources(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sName := randomStackName()
+	stackName := FullyQualifiedStackName(pulumiOrg, pName, sName)
+
+	// initialize
+	s, err := NewStackInlineSource(ctx, stackName, pName, func(ctx *pulumi.Context) error {
+		return nil
+	})
+	if err != nil {
+		t.Errorf("failed to initialize stack, err: %v", err)
+		t.FailNow()
+	}
+
+	defer func() {
+		// -- pulumi stack rm --
+		err = s.Workspace().RemoveStack(ctx, s.Name())
+		assert.Nil(t, err, "failed to remove stack. Resources have leaked.")
+	}()
+
+	err = s.Workspace().InstallPlugin(ctx, "random", "v4.8.2")
+	if err != nil {
+		t.Errorf("failed to install plugin, err: %v", err)
+		t.FailNow()
+	}
+
+	var importEvents []events.EngineEvent
+	eventChannel := make(chan events.EngineEvent)
+	eventsDone := make(chan bool)
+	go func() {
+		for event := range eventChannel {
+			importEvents = append(importEvents, event)
+		}
+		close(eventsDone)
+	}()
+
+	// -- pulumi import --
+	res, err := s.ImportResources(ctx, []ImportResource{{
+		Type: "random:index/randomId:RandomId",
+		Name: "imported",
+		ID:   "p-9hUg",
+	}}, optimport.Protect(false), optimport.EventStreams(eventChannel))
+	if err != nil {
+		t.Errorf("import failed, err: %v", err)
+		t.FailNow()
+	}
+	<-eventsDone
+
+	assert.Equal(t, "resource-import", res.Summary.Kind)
+	assert.Equal(t, "succeeded", res.Summary.Result)
+	assert.NotEmpty(t, importEvents)
+	if assert.Len(t, res.Resources, 1) {
+		assert.Equal(t, "random:index/randomId:RandomId", string(res.Resources[0].Type))
+		assert.Equal(t, "p-9hUg", string(res.Resources[0].ID))
+		assert.False(t, res.Resources[0].Protect)
+	}
+	assert.Contains(t, res.GeneratedCode, "random.NewRandomId")
+
+	// -- pulumi destroy --
+
+	dRes, err := s.Destroy(ctx)
+	if err != nil {
+		t.Errorf("destroy failed, err: %v", err)
+		t.FailNow()
+	}
+
+	assert.Equal(t, "destroy", dRes.Summary.Kind)
+	assert.Equal(t, "succeeded", dRes.Summary.Result)
+}
+
+type testStateComponent struct {
+	pulumi.ResourceState
+}
+
+func TestLocalConfigCLISecrets(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sName := randomStackName()
+	stackName := FullyQualifiedStackName(pulumiOrg, pName, sName)
+
+	// initialize
+	s, err := NewStackInlineSource(ctx, stackName, pName, func(ctx *pulumi.Context) error { return nil })
+	if err != nil {
+		t.Errorf("failed to initialize stack, err: %v", err)
+		t.FailNow()
+	}
+
+	defer func() {
+		// -- pulumi stack rm --
+		err = s.Workspace().RemoveStack(ctx, s.Name())
+		assert.Nil(t, err, "failed to remove stack. Resources have leaked.")
+	}()
+
+	ws := s.Workspace().(*LocalWorkspace)
+	cfg, err := ws.LocalConfig(ctx, stackName)
+	require.NoError(t, err)
+
+	// Encrypt the secrets with the CLI, whatever the stack's secrets provider.
+	cfg.cli = cfg.newCLICrypter()
+	err = cfg.SetAll(ctx, ConfigMap{
+		"region":            {Value: "us-west-2"},
+		"password":          {Value: "hunter2", Secret: true},
+		"db.users[0].token": {Value: "abc", Secret: true},
+	}, true /*path*/)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Save(ctx))
+
+	expected := ConfigMap{
+		pName + ":region":   {Value: "us-west-2"},
+		pName + ":password": {Value: "hunter2", Secret: true},
+		pName + ":db":       {Value: `{"users":[{"token":"abc"}]}`, Secret: true},
+	}
+
+	// The secrets are encrypted with the stack's secrets provider, so the CLI can read them.
+	all, err := ws.GetAllConfig(ctx, stackName)
+	require.NoError(t, err)
+	assert.Equal(t, expected, all)
+
+	// Decrypt them with the CLI, too.
+	cfg, err = ws.LocalConfig(ctx, stackName)
+	require.NoError(t, err)
+	cfg.cli = cfg.newCLICrypter()
+	all, err = cfg.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, expected, all)
+	password, err := cfg.Get(ctx, "password", false /*path*/)
+	require.NoError(t, err)
+	assert.Equal(t, ConfigValue{Value: "hunter2", Secret: true}, password)
+}
+
+func TestStateEdits(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sName := randomStackName()
+	stackName := FullyQualifiedStackName(pulumiOrg, pName, sName)
+	const componentType = "test:index:Component"
+
+	// initialize
+	s, err := NewStackInlineSource(ctx, stackName, pName, func(ctx *pulumi.Context) error {
+		var a, b, d testStateComponent
+		if err := ctx.RegisterComponentResource(componentType, "a", &a, pulumi.Protect(true)); err != nil {
+			return err
+		}
+		if err := ctx.RegisterComponentResource(componentType, "b", &b, pulumi.Parent(&a)); err != nil {
+			return err
+		}
+		return ctx.RegisterComponentResource(componentType, "d", &d, pulumi.Parent(&a))
+	})
+	if err != nil {
+		t.Errorf("failed to initialize stack, err: %v", err)
+		t.FailNow()
+	}
+
+	defer func() {
+		// -- pulumi stack rm --
+		err = s.Workspace().RemoveStack(ctx, s.Name())
+		assert.Nil(t, err, "failed to remove stack. Resources have leaked.")
+	}()
+
+	// -- pulumi up --
+	_, err = s.Up(ctx)
+	if err != nil {
+		t.Errorf("up failed, err: %v", err)
+		t.FailNow()
+	}
+
+	isComponent := func(res apitype.ResourceV3) bool {
+		return string(res.Type) == componentType
+	}
+	components, err := s.ListResources(ctx, isComponent)
+	if err != nil {
+		t.Errorf("list resources failed, err: %v", err)
+		t.FailNow()
+	}
+	if !assert.Len(t, components, 3) {
+		t.FailNow()
+	}
+	a, b, d := string(components[0].URN), string(components[1].URN), string(components[2].URN)
+
+	// -- pulumi state delete --
+	var protectedErr ResourceProtectedError
+	err = s.DeleteResource(ctx, a, false /*force*/)
+	assert.True(t, errors.As(err, &protectedErr))
+
+	// -- pulumi state unprotect --
+	err = s.UnprotectResource(ctx, a)
+	assert.NoError(t, err)
+
+	var dependenciesErr ResourceHasDependenciesError
+	err = s.DeleteResource(ctx, a, false /*force*/)
+	if assert.True(t, errors.As(err, &dependenciesErr)) {
+		assert.Equal(t, components[1:], dependenciesErr.Dependencies)
+	}
+
+	// -- pulumi state rename --
+	err = s.RenameResource(ctx, b, "c")
+	assert.NoError(t, err)
+	var existsErr ResourceExistsError
+	err = s.RenameResource(ctx, d, "c")
+	if assert.True(t, errors.As(err, &existsErr)) {
+		assert.Equal(t, strings.TrimSuffix(b, "b")+"c", existsErr.URN)
+	}
+
+	components, err = s.ListResources(ctx, isComponent)
+	assert.NoError(t, err)
+	if assert.Len(t, components, 3) {
+		assert.Equal(t, "c", components[1].URN.Name().String())
+	}
+
+	for _, urn := range []string{strings.TrimSuffix(b, "b") + "c", d, a} {
+		err = s.DeleteResource(ctx, urn, false /*force*/)
+		assert.NoError(t, err)
+	}
+
+	var notFoundErr ResourceNotFoundError
+	err = s.DeleteResource(ctx, a, false /*force*/)
+	assert.True(t, errors.As(err, &notFoundErr))
+
+	// -- pulumi destroy --
+
+	dRes, err := s.Destroy(ctx)
+	if err != nil {
+		t.Errorf("destroy failed, err: %v", err)
+		t.FailNow()
+	}
+
+	assert.Equal(t, "destroy", dRes.Summary.Kind)
+	assert.Equal(t, "succeeded", dRes.Summary.Result)
+}
+
 func TestConfigFlagLike(t *testing.T) {
 	t.Parallel()
 
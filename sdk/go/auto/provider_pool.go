@@ -0,0 +1,445 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auto
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// debugProvidersEnv is the environment variable that tells the CLI to attach to running provider processes rather than
+// starting its own.
+const debugProvidersEnv = "PULUMI_DEBUG_PROVIDERS"
+
+// pooledProvidersEnv is the environment variable that tells the CLI to attach its default providers to pooled
+// provider processes. Entries in PULUMI_DEBUG_PROVIDERS take precedence over it.
+const pooledProvidersEnv = "PULUMI_POOLED_PROVIDERS"
+
+// PooledProvider names a resource provider plugin whose processes a ProviderPool keeps running between operations.
+type PooledProvider struct {
+	// Name is the provider's package name, e.g. "aws".
+	Name string
+	// Version is the provider's version, e.g. "5.1.0".
+	Version string
+}
+
+// ProviderPool keeps resource provider plugin processes running between the previews, updates, refreshes and
+// destroys of the stacks of every workspace that uses the pool, so that those operations don't pay the cost of
+// starting their providers each time. Only the providers that the pool is created with are pooled; every other
+// provider is started by each operation as usual. A pool is used by passing it to NewLocalWorkspace with the
+// Providers option, and should be closed once it is no longer needed.
+//
+// Only a stack's default providers are pooled. Each operation attaches its default providers to the processes that it
+// leases from the pool rather than starting its own, and has exclusive use of them until it completes, so concurrent
+// operations never share a process. Explicit provider resources may be configured differently from the stack, so they
+// are always started by the operation as usual. A process is only reused by operations on the same stack that use the
+// same version of the provider, the same provider configuration in the stack's settings, and the same PULUMI_HOME and
+// workspace environment variables, since these hold the provider's credentials. A process is discarded rather than
+// reused if the operation that used it fails.
+//
+// Providers are configured again by every operation that uses them, so a pooled provider must support being
+// configured more than once. Anything that a pooled process writes to stderr while it is leased is appended to the
+// stderr of the operation that leased it.
+//
+// Operations still run the engine in a CLI process of their own: the engine lives in the pulumi/pkg module, which the
+// SDK can't depend on, so there is no in-process engine host to keep warm. The pool only removes the cost of starting
+// providers, which the CLI attaches to through PULUMI_POOLED_PROVIDERS. A provider that the workspace's or the
+// process's PULUMI_DEBUG_PROVIDERS value already attaches to isn't pooled for that operation.
+type ProviderPool struct {
+	providers []PooledProvider
+	start     func(ctx context.Context, host providerHost, key providerKey) (*providerProcess, error)
+
+	m      sync.Mutex
+	idle   map[providerKey][]*providerProcess
+	closed bool
+}
+
+// NewProviderPool creates a pool of the processes of the given providers.
+func NewProviderPool(providers ...PooledProvider) *ProviderPool {
+	return &ProviderPool{
+		providers: providers,
+		start:     startProviderProcess,
+		idle:      map[providerKey][]*providerProcess{},
+	}
+}
+
+// Close stops every idle process in the pool. Processes that are in use are stopped when their operations complete.
+func (p *ProviderPool) Close() error {
+	p.m.Lock()
+	idle := p.idle
+	p.idle, p.closed = map[providerKey][]*providerProcess{}, true
+	p.m.Unlock()
+
+	var result error
+	for _, procs := range idle {
+		for _, proc := range procs {
+			if err := proc.kill(); err != nil && result == nil {
+				result = err
+			}
+		}
+	}
+	return result
+}
+
+// providerKey identifies the processes of a provider that an operation may reuse.
+type providerKey struct {
+	name        string
+	version     string
+	fingerprint string // a hash of the settings that the process must have been started and configured with.
+}
+
+// providerHost describes the workspace and stack that an operation that leases processes from a pool runs in.
+type providerHost struct {
+	stack      string // the fully qualified name of the stack, including its project.
+	workDir    string
+	pulumiHome string
+	envvars    map[string]string
+	install    func(ctx context.Context, name, version string) error
+}
+
+// providerKeys returns the keys of the pooled providers for an operation in the given workspace on a stack with the
+// given configuration. A default provider is configured from the configuration in its package's namespace, and may
+// keep state from the stack that configured it, so a process is only reused for the same stack and configuration.
+func (p *ProviderPool) providerKeys(host providerHost, cfg config.Map) []providerKey {
+	var env []string
+	for k, v := range host.envvars {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+
+	keys := make([]providerKey, len(p.providers))
+	for i, provider := range p.providers {
+		var entries []string
+		for k, v := range cfg {
+			if k.Namespace() == provider.Name {
+				b, err := v.MarshalJSON()
+				contract.AssertNoError(err)
+				entries = append(entries, k.String()+"="+string(b))
+			}
+		}
+		sort.Strings(entries)
+
+		hash := sha256.New()
+		for _, s := range append(append([]string{host.stack, host.pulumiHome}, env...), entries...) {
+			_, err := fmt.Fprintf(hash, "%d:%s", len(s), s)
+			contract.AssertNoError(err)
+		}
+		keys[i] = providerKey{
+			name:        provider.Name,
+			version:     provider.Version,
+			fingerprint: hex.EncodeToString(hash.Sum(nil)),
+		}
+	}
+	return keys
+}
+
+// lease gives an operation exclusive use of a process for each of the given keys, starting new processes if the pool
+// has no idle ones. The operation must release the lease when it completes.
+func (p *ProviderPool) lease(ctx context.Context, host providerHost, keys []providerKey) (*providerLease, error) {
+	lease := &providerLease{pool: p, stderr: &syncBuffer{}}
+	for _, key := range keys {
+		proc := p.takeIdle(key)
+		if proc == nil {
+			var err error
+			if proc, err = p.start(ctx, host, key); err != nil {
+				lease.release(true)
+				return nil, errors.Wrapf(err, "failed to start pooled provider %s %s", key.name, key.version)
+			}
+		}
+		proc.stderr.redirect(lease.stderr)
+		lease.procs = append(lease.procs, proc)
+	}
+	return lease, nil
+}
+
+// takeIdle removes an idle process with the given key from the pool and returns it, or returns nil if there is none.
+func (p *ProviderPool) takeIdle(key providerKey) *providerProcess {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	for procs := p.idle[key]; len(procs) > 0; procs = p.idle[key] {
+		proc := procs[len(procs)-1]
+		p.idle[key] = procs[:len(procs)-1]
+		if !proc.exited() {
+			return proc
+		}
+	}
+	return nil
+}
+
+// put returns a process to the pool, or stops it if it can't be reused.
+func (p *ProviderPool) put(proc *providerProcess, reuse bool) {
+	p.m.Lock()
+	if reuse && !p.closed && !proc.exited() {
+		p.idle[proc.key] = append(p.idle[proc.key], proc)
+		p.m.Unlock()
+		return
+	}
+	p.m.Unlock()
+
+	contract.IgnoreError(proc.kill())
+}
+
+// providerLease is an operation's exclusive use of processes from a pool.
+type providerLease struct {
+	pool   *ProviderPool
+	procs  []*providerProcess
+	stderr *syncBuffer // what the leased processes write to stderr while they are leased.
+}
+
+// env returns the environment variables that make the CLI attach its default providers to the leased processes. The
+// CLI prefers PULUMI_DEBUG_PROVIDERS, so the lease must not hold a process for a provider that the operation's value
+// of it already attaches to; see attachesTo.
+func (l *providerLease) env() []string {
+	if l == nil || len(l.procs) == 0 {
+		return nil
+	}
+
+	entries := make([]string, len(l.procs))
+	for i, proc := range l.procs {
+		entries[i] = fmt.Sprintf("%s@%s:%s", proc.key.name, proc.key.version, proc.port)
+	}
+	return []string{pooledProvidersEnv + "=" + strings.Join(entries, ",")}
+}
+
+// output returns what the leased processes have written to stderr so far.
+func (l *providerLease) output() string {
+	if l == nil {
+		return ""
+	}
+	return l.stderr.String()
+}
+
+// unattachedProviders returns the keys of the providers that the given PULUMI_DEBUG_PROVIDERS value doesn't already
+// attach to.
+func unattachedProviders(debugProviders string, keys []providerKey) []providerKey {
+	var unattached []providerKey
+	for _, key := range keys {
+		if !attachesTo(debugProviders, key) {
+			unattached = append(unattached, key)
+		}
+	}
+	return unattached
+}
+
+// attachesTo returns true if the given PULUMI_DEBUG_PROVIDERS value makes the CLI attach to a process of its own for
+// the provider with the given key, either through an entry for every version of the provider or for the key's
+// version. Such a provider isn't leased from the pool, so that the caller's own process is the one that is used.
+func attachesTo(debugProviders string, key providerKey) bool {
+	for _, entry := range strings.Split(debugProviders, ",") {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		name, version := parts[0], ""
+		if i := strings.Index(name, "@"); i != -1 {
+			name, version = name[:i], name[i+1:]
+		}
+		if name != key.name {
+			continue
+		}
+		if version == "" {
+			return true
+		}
+		v, err := semver.ParseTolerant(version)
+		if err != nil {
+			continue
+		}
+		if kv, err := semver.ParseTolerant(key.version); err == nil && v.EQ(kv) {
+			return true
+		}
+	}
+	return false
+}
+
+// release returns the leased processes to the pool. If the operation failed, the processes are stopped instead, since
+// they may have been left in a bad state.
+func (l *providerLease) release(succeeded bool) {
+	if l == nil {
+		return
+	}
+	for _, proc := range l.procs {
+		proc.stderr.redirect(nil)
+		l.pool.put(proc, succeeded)
+	}
+	l.procs = nil
+}
+
+// syncBuffer is a buffer that is safe to write to from multiple goroutines.
+type syncBuffer struct {
+	m   sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.buf.String()
+}
+
+// processOutput forwards a pooled process's output to the operation that has leased the process, and discards it
+// while the process is idle.
+type processOutput struct {
+	m sync.Mutex
+	w io.Writer
+}
+
+// redirect forwards further output to the given writer, or discards it if the writer is nil.
+func (o *processOutput) redirect(w io.Writer) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	o.w = w
+}
+
+func (o *processOutput) Write(p []byte) (int, error) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	if o.w == nil {
+		return len(p), nil
+	}
+	return o.w.Write(p)
+}
+
+// providerProcess is a running provider process that was started in attach mode.
+type providerProcess struct {
+	key    providerKey
+	port   string
+	stderr *processOutput // where the process's stderr is forwarded to.
+	stop   func() error   // stops the process.
+	done   chan struct{}  // closed once the process has exited.
+}
+
+func (proc *providerProcess) exited() bool {
+	select {
+	case <-proc.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (proc *providerProcess) kill() error {
+	if proc.exited() {
+		return nil
+	}
+	if err := proc.stop(); err != nil {
+		return err
+	}
+	<-proc.done
+	return nil
+}
+
+// startProviderProcess starts a process of the provider with the given key in attach mode, installing the provider
+// first if it isn't installed. The process runs in the workspace's directory with the environment that the CLI would
+// give it.
+func startProviderProcess(ctx context.Context, host providerHost, key providerKey) (*providerProcess, error) {
+	version, err := semver.ParseTolerant(key.version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid version %q", key.version)
+	}
+	info := workspace.PluginInfo{Kind: workspace.ResourcePlugin, Name: key.name, Version: &version}
+	if host.pulumiHome != "" {
+		info.PluginDir = filepath.Join(host.pulumiHome, workspace.PluginDir)
+	}
+	path, err := info.FilePath()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = os.Stat(path); os.IsNotExist(err) {
+		if err = host.install(ctx, key.name, key.version); err != nil {
+			return nil, err
+		}
+	}
+
+	// The process outlives the operation that starts it, so it isn't bound to the operation's context.
+	cmd := exec.Command(path)
+	cmd.Dir = host.workDir
+	cmd.Env = os.Environ()
+	if host.pulumiHome != "" {
+		cmd.Env = append(cmd.Env, pulumiHomeEnv+"="+host.pulumiHome)
+	}
+	for k, v := range host.envvars {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	stderr := &processOutput{}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	proc := &providerProcess{key: key, stderr: stderr, stop: cmd.Process.Kill, done: make(chan struct{})}
+	go func() {
+		contract.IgnoreError(cmd.Wait())
+		close(proc.done)
+	}()
+
+	// A provider that is started without an engine address writes the port that it listens on to stdout.
+	ports := make(chan string, 1)
+	go func() {
+		r := bufio.NewReader(stdout)
+		line, err := r.ReadString('\n')
+		if err != nil {
+			line = ""
+		}
+		ports <- strings.TrimSpace(line)
+
+		// Keep draining stdout so that the provider never blocks writing to it.
+		_, err = io.Copy(ioutil.Discard, r)
+		contract.IgnoreError(err)
+	}()
+
+	select {
+	case proc.port = <-ports:
+	case <-ctx.Done():
+		contract.IgnoreError(proc.kill())
+		return nil, ctx.Err()
+	}
+	if _, err := strconv.Atoi(proc.port); err != nil {
+		contract.IgnoreError(proc.kill())
+		return nil, errors.Errorf("provider did not report a port; got %q", proc.port)
+	}
+	return proc, nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auto
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+)
+
+// newTestProviderPool returns a pool whose processes are fakes, along with the number of processes it has started.
+func newTestProviderPool(providers ...PooledProvider) (*ProviderPool, *int) {
+	pool := NewProviderPool(providers...)
+	started := 0
+	pool.start = func(ctx context.Context, host providerHost, key providerKey) (*providerProcess, error) {
+		if key.version == "0.0.0" {
+			return nil, errors.New("failed to start")
+		}
+		started++
+		proc := &providerProcess{
+			key:    key,
+			port:   strconv.Itoa(started),
+			stderr: &processOutput{},
+			done:   make(chan struct{}),
+		}
+		proc.stop = func() error {
+			close(proc.done)
+			return nil
+		}
+		return proc, nil
+	}
+	return pool, &started
+}
+
+func TestProviderPoolReuse(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	pool, started := newTestProviderPool(PooledProvider{Name: "aws", Version: "5.1.0"})
+	host := providerHost{workDir: t.TempDir(), envvars: map[string]string{"AWS_PROFILE": "a"}}
+	keys := pool.providerKeys(host, nil)
+
+	// Concurrent operations get their own processes.
+	first, err := pool.lease(ctx, host, keys)
+	require.NoError(t, err)
+	second, err := pool.lease(ctx, host, keys)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PULUMI_POOLED_PROVIDERS=aws@5.1.0:1"}, first.env())
+	assert.Equal(t, []string{"PULUMI_POOLED_PROVIDERS=aws@5.1.0:2"}, second.env())
+	assert.Equal(t, 2, *started)
+
+	// A process is reused once its operation succeeds, and stopped if its operation fails.
+	first.release(true)
+	failed := second.procs[0]
+	second.release(false)
+	assert.True(t, failed.exited())
+
+	third, err := pool.lease(ctx, host, keys)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PULUMI_POOLED_PROVIDERS=aws@5.1.0:1"}, third.env())
+	assert.Equal(t, 2, *started)
+
+	// Processes that are in use when the pool is closed are stopped when they are released.
+	require.NoError(t, pool.Close())
+	proc := third.procs[0]
+	assert.False(t, proc.exited())
+	third.release(true)
+	assert.True(t, proc.exited())
+}
+
+func TestProviderPoolIsolation(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := newTestProviderPool(PooledProvider{Name: "aws", Version: "5.1.0"})
+	host := providerHost{stack: "proj/dev", envvars: map[string]string{"AWS_PROFILE": "a"}}
+
+	cfg := func(kvs ...string) config.Map {
+		m := config.Map{}
+		for i := 0; i < len(kvs); i += 2 {
+			m[config.MustMakeKey(kvs[i], kvs[i+1])] = config.NewValue("value")
+		}
+		return m
+	}
+	base := pool.providerKeys(host, cfg("aws", "region", "proj", "name"))
+
+	// Configuration for other packages doesn't matter.
+	assert.Equal(t, base, pool.providerKeys(host, cfg("aws", "region", "proj", "other")))
+
+	// The stack, the provider's configuration, the workspace's environment and PULUMI_HOME do.
+	stack := providerHost{stack: "proj/prod", envvars: host.envvars}
+	assert.NotEqual(t, base, pool.providerKeys(stack, cfg("aws", "region")))
+	assert.NotEqual(t, base, pool.providerKeys(host, cfg("aws", "profile")))
+	other := providerHost{stack: host.stack, envvars: map[string]string{"AWS_PROFILE": "b"}}
+	assert.NotEqual(t, base, pool.providerKeys(other, cfg("aws", "region")))
+	home := providerHost{stack: host.stack, pulumiHome: t.TempDir(), envvars: host.envvars}
+	assert.NotEqual(t, base, pool.providerKeys(home, cfg("aws", "region")))
+}
+
+func TestProviderPoolStartFailure(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := newTestProviderPool(
+		PooledProvider{Name: "aws", Version: "5.1.0"}, PooledProvider{Name: "gcp", Version: "0.0.0"})
+	host := providerHost{}
+
+	_, err := pool.lease(context.Background(), host, pool.providerKeys(host, nil))
+	assert.EqualError(t, err, "failed to start pooled provider gcp 0.0.0: failed to start")
+
+	// The process that had already been leased is returned to the pool.
+	assert.Len(t, pool.idle[pool.providerKeys(host, nil)[0]], 1)
+}
+
+func TestProviderPoolAttachedProviders(t *testing.T) {
+	t.Parallel()
+
+	key := providerKey{name: "aws", version: "5.1.0"}
+	assert.False(t, attachesTo("", key))
+	assert.False(t, attachesTo("gcp:9000,aws@5.2.0:9001", key))
+	assert.True(t, attachesTo("aws:9000", key))
+	assert.True(t, attachesTo("gcp:9000,aws@v5.1.0:9001", key))
+
+	// A provider that the caller already attaches to isn't leased, so the caller's process is the one that is used,
+	// even though the CLI would prefer a versioned entry for a leased process over the caller's unversioned one.
+	pool, started := newTestProviderPool(
+		PooledProvider{Name: "aws", Version: "5.1.0"}, PooledProvider{Name: "gcp", Version: "6.0.0"})
+	host := providerHost{}
+	keys := unattachedProviders("aws:9000", pool.providerKeys(host, nil))
+	lease, err := pool.lease(context.Background(), host, keys)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PULUMI_POOLED_PROVIDERS=gcp@6.0.0:1"}, lease.env())
+	assert.Equal(t, 1, *started)
+}
+
+func TestProviderPoolOutput(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	pool, _ := newTestProviderPool(PooledProvider{Name: "aws", Version: "5.1.0"})
+	host := providerHost{}
+	keys := pool.providerKeys(host, nil)
+
+	// A process's stderr is forwarded to the operation that leases it, and discarded while it is idle.
+	first, err := pool.lease(ctx, host, keys)
+	require.NoError(t, err)
+	proc := first.procs[0]
+	_, err = proc.stderr.Write([]byte("first\n"))
+	require.NoError(t, err)
+	first.release(true)
+	_, err = proc.stderr.Write([]byte("idle\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "first\n", first.output())
+
+	second, err := pool.lease(ctx, host, keys)
+	require.NoError(t, err)
+	require.Equal(t, proc, second.procs[0])
+	_, err = proc.stderr.Write([]byte("second\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "second\n", second.output())
+	assert.Equal(t, "first\n", first.output())
+}
@@ -107,12 +107,14 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/debug"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdestroy"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optimport"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optrefresh"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/constant"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
@@ -288,7 +290,7 @@ func (s *Stack) Preview(ctx context.Context, opts ...optpreview.Option) (Preview
 	defer t.Close()
 	args = append(args, "--event-log", t.Filename)
 
-	stdout, stderr, code, err := s.runPulumiCmdSync(ctx, preOpts.ProgressStreams /* additionalOutput */, args...)
+	stdout, stderr, code, err := s.runPulumiOpSync(ctx, preOpts.ProgressStreams /* additionalOutput */, args...)
 	if err != nil {
 		return res, newAutoError(errors.Wrap(err, "failed to run preview"), stdout, stderr, code)
 	}
@@ -378,7 +380,7 @@ func (s *Stack) Up(ctx context.Context, opts ...optup.Option) (UpResult, error)
 	}
 
 	args = append(args, sharedArgs...)
-	stdout, stderr, code, err := s.runPulumiCmdSync(ctx, upOpts.ProgressStreams, args...)
+	stdout, stderr, code, err := s.runPulumiOpSync(ctx, upOpts.ProgressStreams, args...)
 	if err != nil {
 		return res, newAutoError(errors.Wrap(err, "failed to run update"), stdout, stderr, code)
 	}
@@ -454,7 +456,7 @@ func (s *Stack) Refresh(ctx context.Context, opts ...optrefresh.Option) (Refresh
 		args = append(args, "--event-log", t.Filename)
 	}
 
-	stdout, stderr, code, err := s.runPulumiCmdSync(ctx, refreshOpts.ProgressStreams, args...)
+	stdout, stderr, code, err := s.runPulumiOpSync(ctx, refreshOpts.ProgressStreams, args...)
 	if err != nil {
 		return res, newAutoError(errors.Wrap(err, "failed to refresh stack"), stdout, stderr, code)
 	}
@@ -525,7 +527,7 @@ func (s *Stack) Destroy(ctx context.Context, opts ...optdestroy.Option) (Destroy
 		args = append(args, "--event-log", t.Filename)
 	}
 
-	stdout, stderr, code, err := s.runPulumiCmdSync(ctx, destroyOpts.ProgressStreams, args...)
+	stdout, stderr, code, err := s.runPulumiOpSync(ctx, destroyOpts.ProgressStreams, args...)
 	if err != nil {
 		return res, newAutoError(errors.Wrap(err, "failed to destroy stack"), stdout, stderr, code)
 	}
@@ -549,6 +551,150 @@ func (s *Stack) Destroy(ctx context.Context, opts ...optdestroy.Option) (Destroy
 	return res, nil
 }
 
+// ImportResource describes a resource to import into a stack with Stack.ImportResources. Its fields match those of
+// the resources in the file that `pulumi import --file` reads.
+type ImportResource struct {
+	// Type is the resource's type token, e.g. "aws:s3/bucket:Bucket".
+	Type string `json:"type"`
+	// Name is the resource's name.
+	Name string `json:"name"`
+	// ID is the provider's ID for the resource. Its format is specific to the resource's type.
+	ID string `json:"id"`
+	// Parent (optional) is the name of the resource's parent in the NameTable option.
+	Parent string `json:"parent,omitempty"`
+	// Provider (optional) is the name of the resource's provider in the NameTable option. The default provider for
+	// the resource's type is used if it is not set.
+	Provider string `json:"provider,omitempty"`
+	// Version (optional) is the version of the provider to use for the import.
+	Version string `json:"version,omitempty"`
+	// Properties (optional) are the names of the input properties to import. Every required input property is
+	// imported if it is not set.
+	Properties []string `json:"properties,omitempty"`
+}
+
+// ImportResources imports existing resources into the stack, and generates the code that defines them. The code
+// must be added to the stack's program, or the next update will try to change the resources. The imported resources
+// are protected from deletion unless the Protect option says otherwise.
+// https://www.pulumi.com/docs/reference/cli/pulumi_import/
+func (s *Stack) ImportResources(
+	ctx context.Context,
+	resources []ImportResource,
+	opts ...optimport.Option,
+) (ImportResult, error) {
+	var res ImportResult
+
+	importOpts := &optimport.Options{}
+	for _, o := range opts {
+		o.ApplyOption(importOpts)
+	}
+
+	dir, err := ioutil.TempDir("", "automation-import-")
+	if err != nil {
+		return res, errors.Wrap(err, "failed to create temporary directory")
+	}
+	defer os.RemoveAll(dir)
+
+	if resources == nil {
+		resources = []ImportResource{}
+	}
+	importFile, err := json.Marshal(map[string]interface{}{
+		"nameTable": importOpts.NameTable,
+		"resources": resources,
+	})
+	if err != nil {
+		return res, errors.Wrap(err, "failed to marshal import file")
+	}
+	importFilePath, codePath := filepath.Join(dir, "import.json"), filepath.Join(dir, "code")
+	if err = ioutil.WriteFile(importFilePath, importFile, 0600); err != nil {
+		return res, errors.Wrap(err, "failed to write import file")
+	}
+
+	var args []string
+
+	args = debug.AddArgs(&importOpts.DebugLogOpts, args)
+	args = append(args, "import", "--yes", "--skip-preview", "--file", importFilePath, "--out", codePath)
+	if importOpts.Message != "" {
+		args = append(args, fmt.Sprintf("--message=%q", importOpts.Message))
+	}
+	if importOpts.Protect != nil {
+		args = append(args, fmt.Sprintf("--protect=%t", *importOpts.Protect))
+	}
+	if importOpts.Language != "" {
+		args = append(args, fmt.Sprintf("--language=%s", importOpts.Language))
+	}
+	if importOpts.Parallel > 0 {
+		args = append(args, fmt.Sprintf("--parallel=%d", importOpts.Parallel))
+	}
+	if importOpts.UserAgent != "" {
+		args = append(args, fmt.Sprintf("--exec-agent=%s", importOpts.UserAgent))
+	}
+	if importOpts.Color != "" {
+		args = append(args, fmt.Sprintf("--color=%q", importOpts.Color))
+	}
+	args = append(args, fmt.Sprintf("--exec-kind=%s", constant.ExecKindAutoLocal))
+
+	// Collect the URNs of the imported resources from the event stream.
+	imported := map[string]bool{}
+	eventChannel := make(chan events.EngineEvent)
+	eventsDone := make(chan bool)
+	go func() {
+		for event := range eventChannel {
+			if e := event.ResOutputsEvent; e != nil && e.Metadata.Op == apitype.OpImport {
+				imported[e.Metadata.URN] = true
+			}
+		}
+		close(eventsDone)
+	}()
+
+	eventChannels := []chan<- events.EngineEvent{eventChannel}
+	eventChannels = append(eventChannels, importOpts.EventStreams...)
+
+	t, err := tailLogs("import", eventChannels)
+	if err != nil {
+		return res, errors.Wrap(err, "failed to tail logs")
+	}
+	defer t.Close()
+	args = append(args, "--event-log", t.Filename)
+
+	stdout, stderr, code, err := s.runPulumiOpSync(ctx, importOpts.ProgressStreams, args...)
+	if err != nil {
+		return res, newAutoError(errors.Wrap(err, "failed to import resources"), stdout, stderr, code)
+	}
+
+	// Close the file watcher wait for all events to send
+	t.Close()
+	<-eventsDone
+
+	generatedCode, err := ioutil.ReadFile(codePath)
+	if err != nil {
+		return res, errors.Wrap(err, "failed to read generated code")
+	}
+
+	deployment, err := s.deployment(ctx)
+	if err != nil {
+		return res, errors.Wrap(err, "failed to export stack state")
+	}
+	for _, r := range deployment.Resources {
+		if imported[string(r.URN)] {
+			res.Resources = append(res.Resources, r)
+		}
+	}
+
+	history, err := s.History(ctx, 1 /*pageSize*/, 1 /*page*/)
+	if err != nil {
+		return res, errors.Wrap(err, "failed to import resources")
+	}
+	if len(history) > 0 {
+		res.Summary = history[0]
+	}
+
+	res.StdOut = stdout
+	res.StdErr = stderr
+	res.GeneratedCode = string(generatedCode)
+
+	return res, nil
+}
+
 // Outputs get the current set of Stack outputs from the last Stack.Up().
 func (s *Stack) Outputs(ctx context.Context) (OutputMap, error) {
 	return s.Workspace().StackOutputs(ctx, s.Name())
@@ -795,13 +941,93 @@ func (dr *DestroyResult) GetPermalink() (string, error) {
 	return GetPermalink(dr.StdOut)
 }
 
+// ImportResult contains information about a Stack.ImportResources operation,
+// including the states of the imported resources and the code that defines them.
+type ImportResult struct {
+	StdOut  string
+	StdErr  string
+	Summary UpdateSummary
+	// Resources are the states of the imported resources.
+	Resources []apitype.ResourceV3
+	// GeneratedCode defines the imported resources, in the language of the project or of the Language option.
+	GeneratedCode string
+}
+
+// GetPermalink returns the permalink URL in the Pulumi Console for the import operation.
+func (ir *ImportResult) GetPermalink() (string, error) {
+	return GetPermalink(ir.StdOut)
+}
+
 // secretSentinel represents the CLI response for an output marked as "secret"
 const secretSentinel = "[secret]"
 
+// runPulumiOpSync runs a preview, update, refresh or destroy of the stack. If the stack's workspace has a provider
+// pool, the operation uses processes from the pool for the default providers of the pooled packages, and what those
+// processes write to stderr is appended to the operation's stderr.
+func (s *Stack) runPulumiOpSync(
+	ctx context.Context,
+	additionalOutput []io.Writer,
+	args ...string,
+) (string, string, int, error) {
+	existing, has := s.Workspace().GetEnvVars()[debugProvidersEnv]
+	if !has {
+		existing = os.Getenv(debugProvidersEnv)
+	}
+	lease, err := s.leaseProviders(ctx, existing)
+	if err != nil {
+		return "", "", -1, err
+	}
+
+	stdout, stderr, code, err := s.runPulumiCmdSyncWithEnv(ctx, additionalOutput, lease.env(), args...)
+	stderr += lease.output()
+	lease.release(err == nil)
+	return stdout, stderr, code, err
+}
+
+// leaseProviders leases processes for the operation from the provider pool of the stack's workspace, if it has one.
+// No process is leased for a pooled provider that the operation's PULUMI_DEBUG_PROVIDERS value already attaches to.
+func (s *Stack) leaseProviders(ctx context.Context, debugProviders string) (*providerLease, error) {
+	lw, ok := s.Workspace().(*LocalWorkspace)
+	if !ok || lw.providerPool == nil {
+		return nil, nil
+	}
+
+	// A stack without settings has no configuration; if its settings can't be loaded, the operation will fail anyway.
+	var cfg config.Map
+	if settings, err := lw.StackSettings(ctx, s.Name()); err == nil {
+		cfg = settings.Config
+	}
+
+	// Stacks of different projects may share a name, so processes are keyed on the project as well.
+	stack := s.Name()
+	if project, err := lw.ProjectSettings(ctx); err == nil {
+		stack = string(project.Name) + "/" + stack
+	}
+
+	host := providerHost{
+		stack:      stack,
+		workDir:    lw.WorkDir(),
+		pulumiHome: lw.PulumiHome(),
+		envvars:    lw.GetEnvVars(),
+		install:    lw.InstallPlugin,
+	}
+	keys := unattachedProviders(debugProviders, lw.providerPool.providerKeys(host, cfg))
+	return lw.providerPool.lease(ctx, host, keys)
+}
+
 func (s *Stack) runPulumiCmdSync(
 	ctx context.Context,
 	additionalOutput []io.Writer,
 	args ...string,
+) (string, string, int, error) {
+	return s.runPulumiCmdSyncWithEnv(ctx, additionalOutput, nil, args...)
+}
+
+func (s *Stack) runPulumiCmdSyncWithEnv(
+	ctx context.Context,
+	additionalOutput []io.Writer,
+	additionalEnv []string,
+	args ...string,
 ) (string, string, int, error) {
 	var env []string
 	debugEnv := fmt.Sprintf("%s=%s", "PULUMI_DEBUG_COMMANDS", "true")
@@ -816,6 +1042,7 @@ func (s *Stack) runPulumiCmdSync(
 			env = append(env, strings.Join(e, "="))
 		}
 	}
+	env = append(env, additionalEnv...)
 	additionalArgs, err := s.Workspace().SerializeArgsForOp(ctx, s.Name())
 	if err != nil {
 		return "", "", -1, errors.Wrap(err, "failed to exec command, error getting additional args")
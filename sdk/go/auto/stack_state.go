@@ -0,0 +1,245 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// ResourceNotFoundError is returned by the methods that edit a stack's state if the stack has no resource with the
+// given URN.
+type ResourceNotFoundError struct {
+	URN string
+}
+
+func (e ResourceNotFoundError) Error() string {
+	return fmt.Sprintf("no such resource %q exists in the current state", e.URN)
+}
+
+// ResourceProtectedError is returned by Stack.DeleteResource if the resource is protected and its deletion isn't
+// forced.
+type ResourceProtectedError struct {
+	Resource apitype.ResourceV3
+}
+
+func (e ResourceProtectedError) Error() string {
+	return fmt.Sprintf("can't delete protected resource %q", e.Resource.URN)
+}
+
+// ResourceHasDependenciesError is returned by Stack.DeleteResource if the resource can't be deleted because other
+// resources depend directly or indirectly upon it, or are its children.
+type ResourceHasDependenciesError struct {
+	Resource     apitype.ResourceV3
+	Dependencies []apitype.ResourceV3
+}
+
+func (e ResourceHasDependenciesError) Error() string {
+	return fmt.Sprintf("can't delete resource %q due to dependent resources", e.Resource.URN)
+}
+
+// ResourceExistsError is returned by Stack.RenameResource if the stack already has a resource with the new URN.
+type ResourceExistsError struct {
+	URN string
+}
+
+func (e ResourceExistsError) Error() string {
+	return fmt.Sprintf("a resource with URN %q already exists in the current state", e.URN)
+}
+
+// ListResources returns the resources in the stack's state for which filter returns true, in the order in which
+// they appear in the state. Every resource is returned if filter is nil.
+func (s *Stack) ListResources(
+	ctx context.Context,
+	filter func(apitype.ResourceV3) bool,
+) ([]apitype.ResourceV3, error) {
+	deployment, err := s.deployment(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list resources")
+	}
+
+	var resources []apitype.ResourceV3
+	for _, res := range deployment.Resources {
+		if filter == nil || filter(res) {
+			resources = append(resources, res)
+		}
+	}
+	return resources, nil
+}
+
+// DeleteResource deletes the resource with the given URN from the stack's state, without deleting the resource
+// itself. A resource can't be deleted if other resources depend on it or are its children, in which case a
+// ResourceHasDependenciesError is returned. A protected resource is only deleted if force is true; otherwise a
+// ResourceProtectedError is returned.
+// https://www.pulumi.com/docs/reference/cli/pulumi_state_delete/
+func (s *Stack) DeleteResource(ctx context.Context, urn string, force bool) error {
+	deployment, err := s.deployment(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete resource")
+	}
+	res, err := locateResource(deployment, urn)
+	if err != nil {
+		return err
+	}
+	if res.Protect && !force {
+		return ResourceProtectedError{Resource: res}
+	}
+	if dependencies := dependingOn(deployment, res); len(dependencies) != 0 {
+		return ResourceHasDependenciesError{Resource: res, Dependencies: dependencies}
+	}
+
+	args := []string{"state", "delete", urn, "--yes"}
+	if force {
+		args = append(args, "--force")
+	}
+	stdout, stderr, code, err := s.runPulumiCmdSync(ctx, nil /* additionalOutput */, args...)
+	if err != nil {
+		return newAutoError(errors.Wrap(err, "failed to delete resource"), stdout, stderr, code)
+	}
+	return nil
+}
+
+// UnprotectResource unprotects the resource with the given URN in the stack's state, so that it can be deleted.
+// https://www.pulumi.com/docs/reference/cli/pulumi_state_unprotect/
+func (s *Stack) UnprotectResource(ctx context.Context, urn string) error {
+	deployment, err := s.deployment(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to unprotect resource")
+	}
+	if _, err = locateResource(deployment, urn); err != nil {
+		return err
+	}
+
+	stdout, stderr, code, err := s.runPulumiCmdSync(ctx, nil /* additionalOutput */, "state", "unprotect", urn, "--yes")
+	if err != nil {
+		return newAutoError(errors.Wrap(err, "failed to unprotect resource"), stdout, stderr, code)
+	}
+	return nil
+}
+
+// RenameResource changes the name of the resource with the given URN in the stack's state, and updates the
+// dependencies of other resources on it. The resource's declaration in the program must be renamed to match, or the
+// next update will replace the resource. A ResourceExistsError is returned if the stack already has a resource with
+// the new name.
+// https://www.pulumi.com/docs/reference/cli/pulumi_state_rename/
+func (s *Stack) RenameResource(ctx context.Context, urn, newName string) error {
+	if !resource.URN(urn).IsValid() {
+		return errors.Errorf("invalid URN %q", urn)
+	}
+
+	deployment, err := s.deployment(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to rename resource")
+	}
+	if _, err = locateResource(deployment, urn); err != nil {
+		return err
+	}
+	newURN := string(resource.URN(urn).Rename(newName))
+	if _, err = locateResource(deployment, newURN); err == nil {
+		return ResourceExistsError{URN: newURN}
+	}
+
+	stdout, stderr, code, err := s.runPulumiCmdSync(ctx, nil /* additionalOutput */, "state", "rename", urn, newName,
+		"--yes")
+	if err != nil {
+		return newAutoError(errors.Wrap(err, "failed to rename resource"), stdout, stderr, code)
+	}
+	return nil
+}
+
+// deployment returns the stack's current state.
+func (s *Stack) deployment(ctx context.Context) (apitype.DeploymentV3, error) {
+	var deployment apitype.DeploymentV3
+
+	state, err := s.Export(ctx)
+	if err != nil {
+		return deployment, err
+	}
+	if err = json.Unmarshal(state.Deployment, &deployment); err != nil {
+		return deployment, errors.Wrap(err, "failed to unmarshal stack state")
+	}
+	return deployment, nil
+}
+
+// locateResource returns the resource in the deployment with the given URN. As in the CLI, it is an error for more
+// than one resource to have the URN.
+func locateResource(deployment apitype.DeploymentV3, urn string) (apitype.ResourceV3, error) {
+	var found []apitype.ResourceV3
+	for _, res := range deployment.Resources {
+		if string(res.URN) == urn {
+			found = append(found, res)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return apitype.ResourceV3{}, ResourceNotFoundError{URN: urn}
+	case 1:
+		return found[0], nil
+	default:
+		return apitype.ResourceV3{}, errors.Errorf("URN %q refers to %d resources", urn, len(found))
+	}
+}
+
+// dependingOn returns the resources in the deployment that prevent the given resource from being deleted from it. As
+// in the CLI, these are the resources that depend directly or indirectly on the resource through their dependencies
+// or providers or, if there are none, the resource's children.
+func dependingOn(deployment apitype.DeploymentV3, res apitype.ResourceV3) []apitype.ResourceV3 {
+	// Resources are stored in dependency order, so every resource that depends on res comes after it.
+	start := len(deployment.Resources)
+	for i, candidate := range deployment.Resources {
+		if candidate.URN == res.URN {
+			start = i + 1
+			break
+		}
+	}
+
+	dependents := map[resource.URN]bool{res.URN: true}
+	var dependencies []apitype.ResourceV3
+	for _, candidate := range deployment.Resources[start:] {
+		isDependent := false
+		for _, dep := range candidate.Dependencies {
+			if dependents[dep] {
+				isDependent = true
+				break
+			}
+		}
+		if i := strings.LastIndex(candidate.Provider, "::"); i != -1 && dependents[resource.URN(candidate.Provider[:i])] {
+			isDependent = true
+		}
+		if isDependent {
+			dependents[candidate.URN] = true
+			dependencies = append(dependencies, candidate)
+		}
+	}
+	if len(dependencies) != 0 {
+		return dependencies
+	}
+
+	var children []apitype.ResourceV3
+	for _, candidate := range deployment.Resources {
+		if candidate.Parent == res.URN {
+			children = append(children, candidate)
+		}
+	}
+	return children
+}
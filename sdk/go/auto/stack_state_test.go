@@ -0,0 +1,70 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auto
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+func testStateURN(name string) resource.URN {
+	return resource.URN("urn:pulumi:stack::proj::test:index:Res::" + name)
+}
+
+func TestLocateResource(t *testing.T) {
+	t.Parallel()
+
+	deployment := apitype.DeploymentV3{Resources: []apitype.ResourceV3{
+		{URN: testStateURN("a")},
+		{URN: testStateURN("b"), Delete: true},
+		{URN: testStateURN("b")},
+	}}
+
+	res, err := locateResource(deployment, string(testStateURN("a")))
+	assert.NoError(t, err)
+	assert.Equal(t, testStateURN("a"), res.URN)
+
+	_, err = locateResource(deployment, string(testStateURN("c")))
+	var notFound ResourceNotFoundError
+	assert.True(t, errors.As(err, &notFound))
+	assert.Equal(t, string(testStateURN("c")), notFound.URN)
+
+	_, err = locateResource(deployment, string(testStateURN("b")))
+	assert.Error(t, err)
+}
+
+func TestDependingOn(t *testing.T) {
+	t.Parallel()
+
+	provider := apitype.ResourceV3{URN: "urn:pulumi:stack::proj::pulumi:providers:test::prov", ID: "id"}
+	a := apitype.ResourceV3{URN: testStateURN("a"), Provider: string(provider.URN) + "::id"}
+	b := apitype.ResourceV3{URN: testStateURN("b"), Dependencies: []resource.URN{a.URN}}
+	c := apitype.ResourceV3{URN: testStateURN("c"), Parent: b.URN}
+	d := apitype.ResourceV3{URN: testStateURN("d"), Parent: c.URN}
+	deployment := apitype.DeploymentV3{Resources: []apitype.ResourceV3{provider, a, b, c, d}}
+
+	// Dependents through providers and dependencies are found transitively.
+	assert.Equal(t, []apitype.ResourceV3{a, b}, dependingOn(deployment, provider))
+	assert.Equal(t, []apitype.ResourceV3{b}, dependingOn(deployment, a))
+
+	// Without dependents, only direct children are reported.
+	assert.Equal(t, []apitype.ResourceV3{c}, dependingOn(deployment, b))
+	assert.Empty(t, dependingOn(deployment, d))
+}
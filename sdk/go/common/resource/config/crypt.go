@@ -157,6 +157,38 @@ func NewSymmetricCrypterFromPassphrase(phrase string, salt []byte) Crypter {
 	return NewSymmetricCrypter(key)
 }
 
+// ErrIncorrectPassphrase is returned by NewSymmetricCrypterFromPassphraseState if the passphrase does not match the
+// state it is given.
+var ErrIncorrectPassphrase = errors.New("incorrect passphrase")
+
+// NewSymmetricCrypterFromPassphraseState returns a crypter for a passphrase and the encryption state that the
+// passphrase secrets provider stores in a stack's settings. The state is a version tag followed by version specific
+// state information. Presently, we only have one version we support (`v1`), which is a base64-encoded salt followed
+// by a known value encrypted with the key derived from the passphrase and salt, which is used to check the passphrase.
+func NewSymmetricCrypterFromPassphraseState(phrase, state string) (Crypter, error) {
+	splits := strings.SplitN(state, ":", 3)
+	if len(splits) != 3 {
+		return nil, errors.New("malformed state value")
+	}
+
+	if splits[0] != "v1" {
+		return nil, errors.New("unknown state version")
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(splits[1])
+	if err != nil {
+		return nil, err
+	}
+
+	crypter := NewSymmetricCrypterFromPassphrase(phrase, salt)
+	decrypted, err := crypter.DecryptValue(splits[2])
+	if err != nil || decrypted != "pulumi" {
+		return nil, ErrIncorrectPassphrase
+	}
+
+	return crypter, nil
+}
+
 // SymmetricCrypterKeyBytes is the required key size in bytes.
 const SymmetricCrypterKeyBytes = 32
 
@@ -17,9 +17,12 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 )
 
@@ -122,6 +125,48 @@ func (c Value) SecureValues(decrypter Decrypter) ([]string, error) {
 	return d.SecureValues(), nil
 }
 
+// SecurePaths returns the paths of the secure values within this value without decrypting them. A secure scalar has
+// a single, empty path; a secure object has one path per secure value nested within it. Plaintext values have no
+// secure paths.
+func (c Value) SecurePaths() ([]resource.PropertyPath, error) {
+	if !c.secure {
+		return nil, nil
+	}
+	if !c.object {
+		return []resource.PropertyPath{{}}, nil
+	}
+
+	obj, err := c.unmarshalObjectJSON()
+	if err != nil {
+		return nil, err
+	}
+	return securePaths(obj, nil, nil), nil
+}
+
+// securePaths appends the paths of the secure values within v, each prefixed by path, to paths.
+func securePaths(v interface{}, path resource.PropertyPath, paths []resource.PropertyPath) []resource.PropertyPath {
+	if isSecure, _ := isSecureValue(v); isSecure {
+		return append(paths, append(resource.PropertyPath{}, path...))
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for key := range t {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			paths = securePaths(t[key], append(path, key), paths)
+		}
+	case []interface{}:
+		for i, val := range t {
+			paths = securePaths(val, append(path, i), paths)
+		}
+	}
+	return paths
+}
+
 func (c Value) Secure() bool {
 	return c.secure
 }
@@ -21,6 +21,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	yaml "gopkg.in/yaml.v2"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 )
 
 func TestMarshallNormalValueYAML(t *testing.T) {
@@ -275,6 +277,44 @@ func TestSecureValues(t *testing.T) {
 	}
 }
 
+func TestSecurePaths(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Value    Value
+		Expected []resource.PropertyPath
+	}{
+		{
+			Value:    NewValue("value"),
+			Expected: nil,
+		},
+		{
+			Value:    NewSecureValue("securevalue"),
+			Expected: []resource.PropertyPath{{}},
+		},
+		{
+			Value:    NewSecureObjectValue(`{"foo":{"secure":"securevalue"},"bar":"plain"}`),
+			Expected: []resource.PropertyPath{{"foo"}},
+		},
+		{
+			Value:    NewSecureObjectValue(`["a",{"secure":"alpha"},{"test":{"secure":"beta"}}]`),
+			Expected: []resource.PropertyPath{{1}, {2, "test"}},
+		},
+	}
+
+	//nolint:paralleltest // false positive because range var isn't used directly in t.Run(name) arg
+	for _, test := range tests {
+		test := test
+		t.Run(fmt.Sprintf("%v", test.Value), func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := test.Value.SecurePaths()
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expected, actual)
+		})
+	}
+}
+
 func TestCopyValue(t *testing.T) {
 	t.Parallel()
 
@@ -35,6 +35,12 @@ type Context struct {
 	Pwd        string    // the working directory to spawn all plugins in.
 	Root       string    // the root directory of the project.
 
+	// RecordProvidersDir, if set, is the directory in which the RPCs made to resource providers are recorded.
+	RecordProvidersDir string
+	// ReplayProvidersDir, if set, is a directory of recorded provider RPCs. Resource providers are replaced by
+	// stand-ins that answer RPCs with the recorded responses rather than being started.
+	ReplayProvidersDir string
+
 	tracingSpan opentracing.Span // the OpenTracing span to parent requests within.
 }
 
@@ -62,6 +62,9 @@ type Host interface {
 	// Provider loads a new copy of the provider for a given package.  If a provider for this package could not be
 	// found, or an error occurs while creating it, a non-nil error is returned.
 	Provider(pkg tokens.Package, version *semver.Version) (Provider, error)
+	// DefaultProvider is like Provider, but loads the default provider for a given package, which may attach to a
+	// pooled provider process rather than starting a new one.
+	DefaultProvider(pkg tokens.Package, version *semver.Version) (Provider, error)
 	// CloseProvider closes the given provider plugin and deregisters it from this host.
 	CloseProvider(provider Provider) error
 	// LanguageRuntime fetches the language runtime plugin for a given language, lazily allocating if necessary.  If
@@ -258,9 +261,19 @@ func (host *defaultHost) ListAnalyzers() []Analyzer {
 }
 
 func (host *defaultHost) Provider(pkg tokens.Package, version *semver.Version) (Provider, error) {
+	return host.provider(pkg, version, NewProvider)
+}
+
+func (host *defaultHost) DefaultProvider(pkg tokens.Package, version *semver.Version) (Provider, error) {
+	return host.provider(pkg, version, NewDefaultProvider)
+}
+
+func (host *defaultHost) provider(pkg tokens.Package, version *semver.Version,
+	load func(Host, *Context, tokens.Package, *semver.Version, map[string]interface{}, bool) (Provider, error),
+) (Provider, error) {
 	plugin, err := host.loadPlugin(func() (interface{}, error) {
 		// Try to load and bind to a plugin.
-		plug, err := NewProvider(host, host.ctx, pkg, version, host.runtimeOptions, host.disableProviderPreview)
+		plug, err := load(host, host.ctx, pkg, version, host.runtimeOptions, host.disableProviderPreview)
 		if err == nil && plug != nil {
 			info, infoerr := plug.GetPluginInfo()
 			if infoerr != nil {
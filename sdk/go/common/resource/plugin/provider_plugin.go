@@ -21,6 +21,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/blang/semver"
 	pbempty "github.com/golang/protobuf/ptypes/empty"
@@ -29,6 +30,7 @@ import (
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
@@ -70,30 +72,94 @@ type provider struct {
 	supportsPreview        bool                             // true if this plugin supports previews for Create and Update.
 	disableProviderPreview bool                             // true if previews for Create and Update are disabled.
 	legacyPreview          bool                             // enables legacy behavior for unconfigured provider previews.
+	timeoutGrace           time.Duration                    // the time allowed past a custom timeout before canceling.
+	recorded               bool                             // true if this provider's RPCs are recorded or replayed.
 }
 
+// attachPort returns the port of the running provider process that the given PULUMI_DEBUG_PROVIDERS value says to
+// attach to for the given package and version, or "" if the provider's process should be started as usual. Each
+// comma-separated entry is either NAME:PORT, which applies to every version of the provider, or NAME@VERSION:PORT,
+// which only applies to the given version. Versioned entries take precedence.
+func attachPort(providers string, pkg tokens.Package, version *semver.Version) string {
+	var port string
+	for _, provider := range strings.Split(providers, ",") {
+		parts := strings.SplitN(provider, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		name, v := parts[0], ""
+		if i := strings.Index(name, "@"); i != -1 {
+			name, v = name[:i], name[i+1:]
+		}
+		if name != pkg.String() {
+			continue
+		}
+
+		if v == "" {
+			if port == "" {
+				port = parts[1]
+			}
+		} else if version != nil {
+			if sv, err := semver.ParseTolerant(v); err == nil && sv.EQ(*version) {
+				return parts[1]
+			}
+		}
+	}
+	return port
+}
+
+// defaultTimeoutGrace is the time that a provider is given beyond a custom timeout to report the timeout itself
+// before the engine cancels the operation.
+const defaultTimeoutGrace = 30 * time.Second
+
 // NewProvider attempts to bind to a given package's resource plugin and then creates a gRPC connection to it.  If the
 // plugin could not be found, or an error occurs while creating the child process, an error is returned.
 func NewProvider(host Host, ctx *Context, pkg tokens.Package, version *semver.Version,
 	options map[string]interface{}, disableProviderPreview bool) (Provider, error) {
 
+	optAttach := attachPort(os.Getenv("PULUMI_DEBUG_PROVIDERS"), pkg, version)
+	return newProvider(host, ctx, pkg, version, options, disableProviderPreview, optAttach)
+}
+
+// NewDefaultProvider is like NewProvider, but for a package's default provider. Default providers may also attach to
+// the pooled provider processes that PULUMI_POOLED_PROVIDERS lists, in the same syntax as PULUMI_DEBUG_PROVIDERS.
+// Pooled processes are only ever configured by the stack's own configuration, so explicit provider resources, which
+// may be configured differently, never attach to them.
+func NewDefaultProvider(host Host, ctx *Context, pkg tokens.Package, version *semver.Version,
+	options map[string]interface{}, disableProviderPreview bool) (Provider, error) {
+
+	optAttach := attachPort(os.Getenv("PULUMI_DEBUG_PROVIDERS"), pkg, version)
+	if optAttach == "" {
+		optAttach = attachPort(os.Getenv("PULUMI_POOLED_PROVIDERS"), pkg, version)
+	}
+	return newProvider(host, ctx, pkg, version, options, disableProviderPreview, optAttach)
+}
+
+// newProvider creates a provider for the given package, attaching to the process listening on the given port if it is
+// not empty rather than starting one.
+func newProvider(host Host, ctx *Context, pkg tokens.Package, version *semver.Version,
+	options map[string]interface{}, disableProviderPreview bool, optAttach string) (Provider, error) {
+
 	// See if this is a provider we just want to attach to
 	var plug *plugin
-	var optAttach string
-	if providersEnvVar, has := os.LookupEnv("PULUMI_DEBUG_PROVIDERS"); has {
-		for _, provider := range strings.Split(providersEnvVar, ",") {
-			parts := strings.SplitN(provider, ":", 2)
-
-			if parts[0] == pkg.String() {
-				optAttach = parts[1]
-				break
-			}
-		}
-	}
 
 	prefix := fmt.Sprintf("%v (resource)", pkg)
 
-	if optAttach != "" {
+	var replay *replayConn
+	if ctx.ReplayProvidersDir != "" {
+		// Replay the provider's recorded RPCs rather than starting it.
+		path := providerRecordingPath(ctx.ReplayProvidersDir, pkg, version)
+		var err error
+		if replay, err = newReplayConn(path); err != nil {
+			return nil, err
+		}
+		plug = &plugin{
+			Bin: path,
+			// Nothing to kill
+			Kill: func() error { return nil },
+		}
+	} else if optAttach != "" {
 		conn, err := dialPlugin(optAttach, pkg.String(), prefix)
 		if err != nil {
 			return nil, err
@@ -129,6 +195,18 @@ func NewProvider(host Host, ctx *Context, pkg tokens.Package, version *semver.Ve
 	}
 
 	contract.Assertf(plug != nil, "unexpected nil resource plugin for %s", pkg)
+	var conn grpc.ClientConnInterface = plug.Conn
+	if replay != nil {
+		conn = replay
+	}
+	if ctx.RecordProvidersDir != "" {
+		recording, err := openProviderRecording(providerRecordingPath(ctx.RecordProvidersDir, pkg, version))
+		if err != nil {
+			contract.IgnoreClose(plug)
+			return nil, fmt.Errorf("recording provider %s: %w", pkg, err)
+		}
+		conn = &recordingConn{ClientConnInterface: conn, recording: recording}
+	}
 
 	legacyPreview := cmdutil.IsTruthy(os.Getenv("PULUMI_LEGACY_PROVIDER_PREVIEW"))
 
@@ -136,10 +214,12 @@ func NewProvider(host Host, ctx *Context, pkg tokens.Package, version *semver.Ve
 		ctx:                    ctx,
 		pkg:                    pkg,
 		plug:                   plug,
-		clientRaw:              pulumirpc.NewResourceProviderClient(plug.Conn),
+		clientRaw:              pulumirpc.NewResourceProviderClient(conn),
 		cfgdone:                make(chan bool),
 		disableProviderPreview: disableProviderPreview,
 		legacyPreview:          legacyPreview,
+		timeoutGrace:           defaultTimeoutGrace,
+		recorded:               ctx.RecordProvidersDir != "" || replay != nil,
 	}
 
 	// If we just attached (i.e. plugin bin is nil) we need to call attach
@@ -162,6 +242,7 @@ func NewProviderWithClient(ctx *Context, pkg tokens.Package, client pulumirpc.Re
 		clientRaw:              client,
 		cfgdone:                make(chan bool),
 		disableProviderPreview: disableProviderPreview,
+		timeoutGrace:           defaultTimeoutGrace,
 	}
 }
 
@@ -179,6 +260,25 @@ func (p *provider) requestContext() context.Context {
 	return p.ctx.Request()
 }
 
+// recordingContext returns a context that records which property maps the fields of an RPC's request were marshaled
+// from, so that the values that are secret in them are masked in recordings even if the provider doesn't accept
+// secrets.
+func (p *provider) recordingContext(ctx context.Context, props map[string]resource.PropertyMap) context.Context {
+	if !p.recorded {
+		return ctx
+	}
+	return withRecordedProperties(ctx, props)
+}
+
+// operationContext returns the context for a Create, Update or Delete call with the given custom timeout, in seconds.
+// If the timeout is set, the context is canceled once the timeout and the provider's grace period have elapsed.
+func (p *provider) operationContext(timeout float64) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(p.requestContext())
+	}
+	return context.WithTimeout(p.requestContext(), time.Duration(timeout*float64(time.Second))+p.timeoutGrace)
+}
+
 // isDiffCheckConfigLogicallyUnimplemented returns true when an rpcerror.Error should be treated as if it was an error
 // due to a rpc being unimplemented. Due to past mistakes, different providers returned "Unimplemented" in a variaity of
 // different ways that don't always result in an Uimplemented error code.
@@ -241,7 +341,9 @@ func (p *provider) CheckConfig(urn resource.URN, olds,
 		return nil, nil, err
 	}
 
-	resp, err := p.clientRaw.CheckConfig(p.requestContext(), &pulumirpc.CheckRequest{
+	resp, err := p.clientRaw.CheckConfig(p.recordingContext(p.requestContext(), map[string]resource.PropertyMap{
+		"olds": olds, "news": news,
+	}), &pulumirpc.CheckRequest{
 		Urn:  string(urn),
 		Olds: molds,
 		News: mnews,
@@ -345,7 +447,9 @@ func (p *provider) DiffConfig(urn resource.URN, olds, news resource.PropertyMap,
 		return DiffResult{}, err
 	}
 
-	resp, err := p.clientRaw.DiffConfig(p.requestContext(), &pulumirpc.DiffRequest{
+	resp, err := p.clientRaw.DiffConfig(p.recordingContext(p.requestContext(), map[string]resource.PropertyMap{
+		"olds": olds, "news": news,
+	}), &pulumirpc.DiffRequest{
 		Urn:           string(urn),
 		Olds:          molds,
 		News:          mnews,
@@ -600,7 +704,9 @@ func (p *provider) Check(urn resource.URN,
 		return nil, nil, err
 	}
 
-	resp, err := client.Check(p.requestContext(), &pulumirpc.CheckRequest{
+	resp, err := client.Check(p.recordingContext(p.requestContext(), map[string]resource.PropertyMap{
+		"olds": olds, "news": news,
+	}), &pulumirpc.CheckRequest{
 		Urn:            string(urn),
 		Olds:           molds,
 		News:           mnews,
@@ -693,7 +799,9 @@ func (p *provider) Diff(urn resource.URN, id resource.ID,
 		return DiffResult{}, err
 	}
 
-	resp, err := client.Diff(p.requestContext(), &pulumirpc.DiffRequest{
+	resp, err := client.Diff(p.recordingContext(p.requestContext(), map[string]resource.PropertyMap{
+		"olds": olds, "news": news,
+	}), &pulumirpc.DiffRequest{
 		Id:            string(id),
 		Urn:           string(urn),
 		Olds:          molds,
@@ -789,12 +897,20 @@ func (p *provider) Create(urn resource.URN, props resource.PropertyMap, timeout
 	var liveObject *_struct.Struct
 	var resourceError error
 	var resourceStatus = resource.StatusOK
-	resp, err := client.Create(p.requestContext(), &pulumirpc.CreateRequest{
+	ctx, cancel := p.operationContext(timeout)
+	defer cancel()
+	resp, err := client.Create(p.recordingContext(ctx, map[string]resource.PropertyMap{
+		"properties": props,
+	}), &pulumirpc.CreateRequest{
 		Urn:        string(urn),
 		Properties: mprops,
 		Timeout:    timeout,
 		Preview:    preview,
 	})
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		logging.V(7).Infof("%s timed out", label)
+		return "", nil, resource.StatusUnknown, &TimeoutError{Operation: "create", Timeout: timeout}
+	}
 	if err != nil {
 		resourceStatus, id, liveObject, _, resourceError = parseError(err)
 		logging.V(7).Infof("%s failed: %v", label, resourceError)
@@ -893,7 +1009,9 @@ func (p *provider) Read(urn resource.URN, id resource.ID,
 	var liveInputs *_struct.Struct
 	var resourceError error
 	var resourceStatus = resource.StatusOK
-	resp, err := client.Read(p.requestContext(), &pulumirpc.ReadRequest{
+	resp, err := client.Read(p.recordingContext(p.requestContext(), map[string]resource.PropertyMap{
+		"properties": state, "inputs": inputs,
+	}), &pulumirpc.ReadRequest{
 		Id:         string(id),
 		Urn:        string(urn),
 		Properties: mstate,
@@ -1025,7 +1143,11 @@ func (p *provider) Update(urn resource.URN, id resource.ID,
 	var liveObject *_struct.Struct
 	var resourceError error
 	var resourceStatus = resource.StatusOK
-	resp, err := client.Update(p.requestContext(), &pulumirpc.UpdateRequest{
+	ctx, cancel := p.operationContext(timeout)
+	defer cancel()
+	resp, err := client.Update(p.recordingContext(ctx, map[string]resource.PropertyMap{
+		"olds": olds, "news": news,
+	}), &pulumirpc.UpdateRequest{
 		Id:            string(id),
 		Urn:           string(urn),
 		Olds:          molds,
@@ -1034,6 +1156,10 @@ func (p *provider) Update(urn resource.URN, id resource.ID,
 		IgnoreChanges: ignoreChanges,
 		Preview:       preview,
 	})
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		logging.V(7).Infof("%s timed out", label)
+		return nil, resource.StatusUnknown, &TimeoutError{Operation: "update", Timeout: timeout}
+	}
 	if err != nil {
 		resourceStatus, _, liveObject, _, resourceError = parseError(err)
 		logging.V(7).Infof("%s failed: %v", label, resourceError)
@@ -1099,12 +1225,20 @@ func (p *provider) Delete(urn resource.URN, id resource.ID, props resource.Prope
 	// We should only be calling {Create,Update,Delete} if the provider is fully configured.
 	contract.Assert(p.cfgknown)
 
-	if _, err := client.Delete(p.requestContext(), &pulumirpc.DeleteRequest{
+	ctx, cancel := p.operationContext(timeout)
+	defer cancel()
+	if _, err := client.Delete(p.recordingContext(ctx, map[string]resource.PropertyMap{
+		"properties": props,
+	}), &pulumirpc.DeleteRequest{
 		Id:         string(id),
 		Urn:        string(urn),
 		Properties: mprops,
 		Timeout:    timeout,
 	}); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			logging.V(7).Infof("%s timed out", label)
+			return resource.StatusUnknown, &TimeoutError{Operation: "delete", Timeout: timeout}
+		}
 		resourceStatus, rpcErr := resourceStateAndError(err)
 		logging.V(7).Infof("%s failed: %v", label, rpcErr)
 		return resourceStatus, rpcErr
@@ -1185,7 +1319,9 @@ func (p *provider) Construct(info ConstructInfo, typ tokens.Type, name tokens.QN
 		configSecretKeys = append(configSecretKeys, k.String())
 	}
 
-	resp, err := client.Construct(p.requestContext(), &pulumirpc.ConstructRequest{
+	resp, err := client.Construct(p.recordingContext(p.requestContext(), map[string]resource.PropertyMap{
+		"inputs": inputs,
+	}), &pulumirpc.ConstructRequest{
 		Project:           info.Project,
 		Stack:             info.Stack,
 		Config:            config,
@@ -1262,7 +1398,9 @@ func (p *provider) Invoke(tok tokens.ModuleMember, args resource.PropertyMap) (r
 		return nil, nil, err
 	}
 
-	resp, err := client.Invoke(p.requestContext(), &pulumirpc.InvokeRequest{
+	resp, err := client.Invoke(p.recordingContext(p.requestContext(), map[string]resource.PropertyMap{
+		"args": args,
+	}), &pulumirpc.InvokeRequest{
 		Tok:  string(tok),
 		Args: margs,
 	})
@@ -1642,6 +1780,21 @@ func (ie *InitError) Error() string {
 	return err.Error()
 }
 
+// TimeoutError is returned by a Create, Update or Delete operation that the engine canceled because it ran past its
+// custom timeout. The operation may or may not have taken effect.
+type TimeoutError struct {
+	Operation string  // the operation that timed out, e.g. "create".
+	Timeout   float64 // the operation's custom timeout, in seconds.
+}
+
+var _ error = (*TimeoutError)(nil)
+
+func (te *TimeoutError) Error() string {
+	timeout := time.Duration(te.Timeout * float64(time.Second))
+	return fmt.Sprintf("%s timed out after %v and was canceled; the resource may be in an unknown state",
+		te.Operation, timeout)
+}
+
 func decorateSpanWithType(span opentracing.Span, urn string) {
 	if urn := resource.URN(urn); urn.IsValid() {
 		span.SetTag("pulumi-decorator", urn.Type())
@@ -1,12 +1,20 @@
 package plugin
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
 
+	"github.com/blang/semver"
+	pbempty "github.com/golang/protobuf/ptypes/empty"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
 )
 
 func TestAnnotateSecrets(t *testing.T) {
@@ -225,3 +233,78 @@ func TestNestedSecret(t *testing.T) {
 
 	assert.Truef(t, reflect.DeepEqual(to, expected), "did not match expected after annotation")
 }
+
+// blockingProviderClient is a provider client whose Create, Update and Delete calls block until they are canceled.
+type blockingProviderClient struct {
+	pulumirpc.ResourceProviderClient
+}
+
+func (c *blockingProviderClient) Create(ctx context.Context, req *pulumirpc.CreateRequest,
+	opts ...grpc.CallOption) (*pulumirpc.CreateResponse, error) {
+	<-ctx.Done()
+	return nil, status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+}
+
+func (c *blockingProviderClient) Update(ctx context.Context, req *pulumirpc.UpdateRequest,
+	opts ...grpc.CallOption) (*pulumirpc.UpdateResponse, error) {
+	<-ctx.Done()
+	return nil, status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+}
+
+func (c *blockingProviderClient) Delete(ctx context.Context, req *pulumirpc.DeleteRequest,
+	opts ...grpc.CallOption) (*pbempty.Empty, error) {
+	<-ctx.Done()
+	return nil, status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+}
+
+func TestProviderOperationTimeouts(t *testing.T) {
+	t.Parallel()
+
+	cfgdone := make(chan bool)
+	close(cfgdone)
+	p := &provider{
+		pkg:       "pkgA",
+		clientRaw: &blockingProviderClient{},
+		cfgdone:   cfgdone,
+		cfgknown:  true,
+	}
+
+	urn := resource.NewURN("test", "test", "", "pkgA:m:typA", "resA")
+	props := resource.PropertyMap{"foo": resource.NewStringProperty("bar")}
+
+	var timeoutErr *TimeoutError
+	_, _, rst, err := p.Create(urn, props, 0.01, false)
+	assert.Equal(t, resource.StatusUnknown, rst)
+	if assert.True(t, errors.As(err, &timeoutErr)) {
+		assert.Equal(t, "create", timeoutErr.Operation)
+		assert.Equal(t, "create timed out after 10ms and was canceled; the resource may be in an unknown state",
+			err.Error())
+	}
+
+	_, rst, err = p.Update(urn, "id", props, props, 0.01, nil, false)
+	assert.Equal(t, resource.StatusUnknown, rst)
+	if assert.True(t, errors.As(err, &timeoutErr)) {
+		assert.Equal(t, "update", timeoutErr.Operation)
+	}
+
+	rst, err = p.Delete(urn, "id", props, 0.01)
+	assert.Equal(t, resource.StatusUnknown, rst)
+	if assert.True(t, errors.As(err, &timeoutErr)) {
+		assert.Equal(t, "delete", timeoutErr.Operation)
+	}
+}
+
+func TestAttachPort(t *testing.T) {
+	t.Parallel()
+
+	v1, v2 := semver.MustParse("1.0.0"), semver.MustParse("2.0.0")
+	providers := "aws:1000,aws@2.0.0:2000,gcp@1.0.0:3000"
+
+	assert.Equal(t, "1000", attachPort(providers, "aws", &v1))
+	assert.Equal(t, "2000", attachPort(providers, "aws", &v2))
+	assert.Equal(t, "1000", attachPort(providers, "aws", nil))
+	assert.Equal(t, "3000", attachPort(providers, "gcp", &v1))
+	assert.Equal(t, "", attachPort(providers, "gcp", &v2))
+	assert.Equal(t, "", attachPort(providers, "azure", &v1))
+	assert.Equal(t, "", attachPort("", "aws", &v1))
+}
@@ -0,0 +1,399 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/blang/semver"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+// maskedSecret replaces the values of secrets in recorded provider RPCs.
+const maskedSecret = "[secret]"
+
+// recordedPropertiesKey is the key of the context value that holds the property maps that the fields of a provider
+// RPC's request were marshaled from.
+type recordedPropertiesKey struct{}
+
+// withRecordedProperties returns a context that tells the connection that records or replays a provider RPC which
+// property maps the fields of its request were marshaled from, by the fields' JSON names. This lets the connection
+// mask the values that the engine knows to be secret even when they were marshaled without secret signatures, as they
+// are for providers that don't accept secrets.
+func withRecordedProperties(ctx context.Context, props map[string]resource.PropertyMap) context.Context {
+	return context.WithValue(ctx, recordedPropertiesKey{}, props)
+}
+
+func recordedProperties(ctx context.Context) map[string]resource.PropertyMap {
+	props, _ := ctx.Value(recordedPropertiesKey{}).(map[string]resource.PropertyMap)
+	return props
+}
+
+// recordedCall is a provider RPC in a recording. Recordings hold one JSON-encoded call per line.
+type recordedCall struct {
+	Method   string          `json:"method"`             // the full name of the RPC's method.
+	Request  json.RawMessage `json:"request"`            // the RPC's request, with its secrets masked.
+	Response json.RawMessage `json:"response,omitempty"` // the RPC's response, with its secrets masked.
+	Error    json.RawMessage `json:"error,omitempty"`    // the RPC's error status, if it failed.
+}
+
+// providerRecordingPath returns the path of the recording of the RPCs made to the given provider in the given
+// directory.
+func providerRecordingPath(dir string, pkg tokens.Package, version *semver.Version) string {
+	name := strings.Replace(string(pkg), tokens.QNameDelimiter, "_", -1)
+	if version != nil {
+		name += "-v" + version.String()
+	}
+	return filepath.Join(dir, name+".jsonl")
+}
+
+// providerRecording is a recording of provider RPCs that is being written. Every instance of a provider records to
+// the same file.
+type providerRecording struct {
+	m    sync.Mutex
+	path string
+}
+
+var providerRecordings = struct {
+	m          sync.Mutex
+	recordings map[string]*providerRecording
+}{recordings: map[string]*providerRecording{}}
+
+// openProviderRecording returns the recording with the given path. The first time that a path is opened, any
+// previous recording with the same path is replaced.
+func openProviderRecording(path string) (*providerRecording, error) {
+	providerRecordings.m.Lock()
+	defer providerRecordings.m.Unlock()
+
+	if rec, has := providerRecordings.recordings[path]; has {
+		return rec, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = f.Close(); err != nil {
+		return nil, err
+	}
+
+	rec := &providerRecording{path: path}
+	providerRecordings.recordings[path] = rec
+	return rec, nil
+}
+
+// record appends an RPC to the recording. The request was marshaled from the given property maps.
+func (rec *providerRecording) record(method string, req, resp interface{}, rpcErr error,
+	props map[string]resource.PropertyMap) error {
+
+	call := recordedCall{Method: method}
+
+	var err error
+	if call.Request, err = maskProviderMessage(req, true, props); err != nil {
+		return err
+	}
+	if rpcErr != nil {
+		if call.Error, err = maskProviderMessage(status.Convert(rpcErr).Proto(), false, props); err != nil {
+			return err
+		}
+	} else if call.Response, err = maskProviderMessage(resp, false, props); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(call)
+	if err != nil {
+		return err
+	}
+
+	rec.m.Lock()
+	defer rec.m.Unlock()
+	f, err := os.OpenFile(rec.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(b, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// recordingConn is a connection to a provider that records every unary RPC made over it.
+type recordingConn struct {
+	grpc.ClientConnInterface
+	recording *providerRecording
+}
+
+func (c *recordingConn) Invoke(ctx context.Context, method string, args, reply interface{},
+	opts ...grpc.CallOption) error {
+
+	err := c.ClientConnInterface.Invoke(ctx, method, args, reply, opts...)
+	if recErr := c.recording.record(method, args, reply, err, recordedProperties(ctx)); recErr != nil {
+		logging.V(5).Infof("failed to record %s to %s: %v", method, c.recording.path, recErr)
+	}
+	return err
+}
+
+// replayConn stands in for a connection to a provider. It serves the responses in a recording of the provider's RPCs
+// rather than making calls. A request is answered with the response to the first unanswered identical request in the
+// recording, once its secrets are masked, or with the response to the last identical request if every one has been
+// answered.
+type replayConn struct {
+	m     sync.Mutex
+	path  string
+	calls map[string][]recordedCall // the recorded calls, by method and masked request.
+}
+
+func newReplayConn(path string) (*replayConn, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening provider recording: %w", err)
+	}
+	defer f.Close()
+
+	conn := &replayConn{path: path, calls: map[string][]recordedCall{}}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		var call recordedCall
+		if err := json.Unmarshal(scanner.Bytes(), &call); err != nil {
+			return nil, fmt.Errorf("%s: malformed recorded call: %w", path, err)
+		}
+		key := call.Method + " " + string(call.Request)
+		conn.calls[key] = append(conn.calls[key], call)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading provider recording: %w", err)
+	}
+	return conn, nil
+}
+
+func (c *replayConn) Invoke(ctx context.Context, method string, args, reply interface{},
+	opts ...grpc.CallOption) error {
+
+	// There is nothing to cancel.
+	if method == "/pulumirpc.ResourceProvider/Cancel" {
+		return nil
+	}
+
+	req, err := maskProviderMessage(args, true, recordedProperties(ctx))
+	if err != nil {
+		return err
+	}
+	key := method + " " + string(req)
+
+	c.m.Lock()
+	calls := c.calls[key]
+	if len(calls) == 0 {
+		c.m.Unlock()
+		return status.Errorf(codes.NotFound, "%s has no recorded response to %s request %s", c.path, method, req)
+	}
+	call := calls[0]
+	if len(calls) > 1 {
+		c.calls[key] = calls[1:]
+	}
+	c.m.Unlock()
+
+	if len(call.Error) != 0 {
+		st := status.New(codes.OK, "").Proto()
+		if err := jsonpb.Unmarshal(bytes.NewReader(call.Error), st); err != nil {
+			return fmt.Errorf("%s: malformed recorded error: %w", c.path, err)
+		}
+		return status.ErrorProto(st)
+	}
+	if err := jsonpb.Unmarshal(bytes.NewReader(call.Response), reply.(proto.Message)); err != nil {
+		return fmt.Errorf("%s: malformed recorded response: %w", c.path, err)
+	}
+	return nil
+}
+
+func (c *replayConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string,
+	opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Errorf(codes.Unimplemented, "streaming RPCs such as %s cannot be replayed", method)
+}
+
+// maskProviderMessage returns the canonical JSON encoding of a provider RPC's request or response, with the values of
+// its secrets replaced by a placeholder. Secrets are the values with secret signatures, and the values that are secret
+// in the property maps that the request was marshaled from. The fields of a request are masked using the property map
+// that they were marshaled from, and the object fields of a response using every one of the request's property maps,
+// as the engine does when it marks the outputs of providers that don't accept secrets as secret. A string anywhere in
+// the message that equals the value of one of its secrets is masked too, which masks secret configuration variables.
+// The endpoints of resource monitors, which change from one run to the next, are removed from requests.
+func maskProviderMessage(msg interface{}, request bool, props map[string]resource.PropertyMap) (json.RawMessage,
+	error) {
+
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", msg)
+	}
+	var buf bytes.Buffer
+	if err := (&jsonpb.Marshaler{}).Marshal(&buf, m); err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(&buf)
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	secrets := map[string]bool{}
+	v = maskSecrets(v, secrets)
+	if obj, ok := v.(map[string]interface{}); ok {
+		for field, e := range obj {
+			if request {
+				if pm, has := props[field]; has {
+					obj[field] = maskSecretProperties(e, pm, secrets)
+				}
+				continue
+			}
+			for _, pm := range props {
+				e = maskSecretProperties(e, pm, secrets)
+			}
+			obj[field] = e
+		}
+		if request {
+			delete(obj, "monitorEndpoint")
+		}
+	}
+	v = maskSecretStrings(v, secrets)
+	return json.Marshal(v)
+}
+
+// maskSecretProperties replaces the values in a JSON-encoded property map that are secret in the given property map
+// with a placeholder, and adds the strings that it replaces to the given set.
+func maskSecretProperties(v interface{}, props resource.PropertyMap, secrets map[string]bool) interface{} {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	for k, pv := range props {
+		if e, has := obj[string(k)]; has {
+			obj[string(k)] = maskSecretProperty(e, pv, secrets)
+		}
+	}
+	return obj
+}
+
+func maskSecretProperty(v interface{}, pv resource.PropertyValue, secrets map[string]bool) interface{} {
+	// Values with secret signatures have already been masked.
+	if obj, ok := v.(map[string]interface{}); ok && obj[resource.SigKey] == resource.SecretSig {
+		return v
+	}
+
+	switch {
+	case pv.IsSecret() || pv.IsOutput() && pv.OutputValue().Secret:
+		addSecretStrings(v, secrets)
+		return maskedSecret
+	case pv.IsObject():
+		return maskSecretProperties(v, pv.ObjectValue(), secrets)
+	case pv.IsArray():
+		if arr, ok := v.([]interface{}); ok {
+			for i, e := range pv.ArrayValue() {
+				if i < len(arr) {
+					arr[i] = maskSecretProperty(arr[i], e, secrets)
+				}
+			}
+		}
+	}
+	return v
+}
+
+// addSecretStrings adds the non-empty strings in a JSON value to the given set.
+func addSecretStrings(v interface{}, secrets map[string]bool) {
+	switch v := v.(type) {
+	case string:
+		if v != "" {
+			secrets[v] = true
+		}
+	case map[string]interface{}:
+		for _, e := range v {
+			addSecretStrings(e, secrets)
+		}
+	case []interface{}:
+		for _, e := range v {
+			addSecretStrings(e, secrets)
+		}
+	}
+}
+
+// maskSecrets replaces the values of the secrets in a JSON value with a placeholder, and adds the values that it
+// replaces to the given set.
+func maskSecrets(v interface{}, secrets map[string]bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if v[resource.SigKey] == resource.SecretSig {
+			switch value := v["value"].(type) {
+			case string:
+				if value != "" {
+					secrets[value] = true
+				}
+			case nil:
+			default:
+				if b, err := json.Marshal(value); err == nil {
+					secrets[string(b)] = true
+				}
+			}
+			v["value"] = maskedSecret
+			return v
+		}
+		for k, e := range v {
+			v[k] = maskSecrets(e, secrets)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = maskSecrets(e, secrets)
+		}
+	}
+	return v
+}
+
+// maskSecretStrings replaces every string in a JSON value that is in the given set of secret values.
+func maskSecretStrings(v interface{}, secrets map[string]bool) interface{} {
+	switch v := v.(type) {
+	case string:
+		if secrets[v] {
+			return maskedSecret
+		}
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = maskSecretStrings(e, secrets)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = maskSecretStrings(e, secrets)
+		}
+	}
+	return v
+}
@@ -0,0 +1,189 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+// fakeProviderConn answers provider RPCs without a provider: CheckConfig and Create echo their inputs, Configure
+// succeeds, and Read fails.
+type fakeProviderConn struct {
+	grpc.ClientConnInterface
+	rejectSecrets bool
+}
+
+func (c *fakeProviderConn) Invoke(ctx context.Context, method string, args, reply interface{},
+	opts ...grpc.CallOption) error {
+
+	switch method {
+	case "/pulumirpc.ResourceProvider/CheckConfig":
+		reply.(*pulumirpc.CheckResponse).Inputs = args.(*pulumirpc.CheckRequest).News
+	case "/pulumirpc.ResourceProvider/Configure":
+		reply.(*pulumirpc.ConfigureResponse).AcceptSecrets = !c.rejectSecrets
+	case "/pulumirpc.ResourceProvider/Create":
+		req, resp := args.(*pulumirpc.CreateRequest), reply.(*pulumirpc.CreateResponse)
+		resp.Id, resp.Properties = "id1", req.Properties
+	case "/pulumirpc.ResourceProvider/Read":
+		return status.Error(codes.Internal, "read failed")
+	}
+	return nil
+}
+
+func TestRecordAndReplayProviderRPCs(t *testing.T) {
+	t.Parallel()
+
+	const password = "hunter2"
+	props, err := MarshalProperties(resource.PropertyMap{
+		"password": resource.MakeSecret(resource.NewStringProperty(password)),
+		"region":   resource.NewStringProperty("us-west-2"),
+	}, MarshalOptions{KeepSecrets: true})
+	require.NoError(t, err)
+	configure := &pulumirpc.ConfigureRequest{
+		Variables:     map[string]string{"test:config:password": password, "test:config:region": "us-west-2"},
+		Args:          props,
+		AcceptSecrets: true,
+	}
+	create := &pulumirpc.CreateRequest{Urn: "urn:pulumi:stack::proj::test:index:Res::res", Properties: props}
+	read := &pulumirpc.ReadRequest{Id: "id1", Urn: create.Urn}
+
+	path := providerRecordingPath(t.TempDir(), "test", nil)
+	recording, err := openProviderRecording(path)
+	require.NoError(t, err)
+	client := pulumirpc.NewResourceProviderClient(
+		&recordingConn{ClientConnInterface: &fakeProviderConn{}, recording: recording})
+
+	ctx := context.Background()
+	_, err = client.Configure(ctx, configure)
+	require.NoError(t, err)
+	_, err = client.Create(ctx, create)
+	require.NoError(t, err)
+	_, err = client.Read(ctx, read)
+	require.Error(t, err)
+
+	// Secrets are masked, including the secret's configuration variable.
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.False(t, strings.Contains(string(b), password))
+	assert.Equal(t, 3, strings.Count(string(b), "\n"))
+
+	replay, err := newReplayConn(path)
+	require.NoError(t, err)
+	client = pulumirpc.NewResourceProviderClient(replay)
+
+	// Replayed calls may be made in a different order.
+	_, err = client.Read(ctx, read)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "read failed", status.Convert(err).Message())
+
+	created, err := client.Create(ctx, create)
+	require.NoError(t, err)
+	assert.Equal(t, "id1", created.Id)
+	outs, err := UnmarshalProperties(created.Properties, MarshalOptions{KeepSecrets: true})
+	require.NoError(t, err)
+	assert.Equal(t, resource.MakeSecret(resource.NewStringProperty(maskedSecret)), outs["password"])
+	assert.Equal(t, resource.NewStringProperty("us-west-2"), outs["region"])
+
+	configured, err := client.Configure(ctx, configure)
+	require.NoError(t, err)
+	assert.True(t, configured.AcceptSecrets)
+
+	// Requests that weren't recorded can't be answered.
+	_, err = client.Read(ctx, &pulumirpc.ReadRequest{Id: "id2", Urn: create.Urn})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// TestRecordAndReplayWithoutSecrets checks that the values that the engine knows to be secret are masked when a
+// provider doesn't accept secrets, so that they are marshaled as plain values.
+func TestRecordAndReplayWithoutSecrets(t *testing.T) {
+	t.Parallel()
+
+	const password = "hunter2"
+	urn := resource.URN("urn:pulumi:stack::proj::test:index:Res::res")
+	config := resource.PropertyMap{
+		"password": resource.MakeSecret(resource.NewStringProperty(password)),
+		"region":   resource.NewStringProperty("us-west-2"),
+	}
+	props := resource.PropertyMap{
+		"nested": resource.NewObjectProperty(resource.PropertyMap{
+			"token": resource.MakeSecret(resource.NewStringProperty("s3cr3t")),
+		}),
+		"size": resource.NewNumberProperty(42),
+	}
+	newProvider := func(conn grpc.ClientConnInterface) *provider {
+		return &provider{
+			pkg:          "test",
+			clientRaw:    pulumirpc.NewResourceProviderClient(conn),
+			cfgdone:      make(chan bool),
+			timeoutGrace: defaultTimeoutGrace,
+			recorded:     true,
+		}
+	}
+
+	path := providerRecordingPath(t.TempDir(), "test", nil)
+	recording, err := openProviderRecording(path)
+	require.NoError(t, err)
+	p := newProvider(&recordingConn{ClientConnInterface: &fakeProviderConn{rejectSecrets: true}, recording: recording})
+
+	// CheckConfig runs before Configure, when secrets are never sent with their signatures.
+	checked, _, err := p.CheckConfig(urn, nil, config, false)
+	require.NoError(t, err)
+	assert.Equal(t, resource.MakeSecret(resource.NewStringProperty(password)), checked["password"])
+	require.NoError(t, p.Configure(config))
+	assert.False(t, p.acceptSecrets)
+	id, outs, _, err := p.Create(urn, props, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, resource.ID("id1"), id)
+	assert.Equal(t, resource.MakeSecret(resource.NewStringProperty("s3cr3t")), outs["nested"].ObjectValue()["token"])
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.False(t, strings.Contains(string(b), password))
+	assert.False(t, strings.Contains(string(b), "s3cr3t"))
+
+	replay, err := newReplayConn(path)
+	require.NoError(t, err)
+	p = newProvider(replay)
+
+	checked, _, err = p.CheckConfig(urn, nil, config, false)
+	require.NoError(t, err)
+	assert.Equal(t, resource.MakeSecret(resource.NewStringProperty(maskedSecret)), checked["password"])
+	assert.Equal(t, resource.NewStringProperty("us-west-2"), checked["region"])
+	require.NoError(t, p.Configure(config))
+	id, outs, _, err = p.Create(urn, props, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, resource.ID("id1"), id)
+	assert.Equal(t, resource.MakeSecret(resource.NewStringProperty(maskedSecret)), outs["nested"].ObjectValue()["token"])
+	assert.Equal(t, resource.NewNumberProperty(42), outs["size"])
+}
+
+func TestProviderRecordingPath(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, filepath.Join("dir", "aws.jsonl"), providerRecordingPath("dir", "aws", nil))
+}
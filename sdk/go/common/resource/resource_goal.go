@@ -40,6 +40,18 @@ type Goal struct {
 	ReplaceOnChanges        []string              // a list of property paths that if changed should force a replacement.
 	// if set to True, the providers Delete method will not be called for this resource.
 	RetainOnDelete bool
+	// if set, the providers Delete method will not be called for this resource
+	// if specified resource is being deleted as well.
+	DeletedWith URN
+	// callbacks in the program that run before and after operations on this resource.
+	Hooks []ResourceHook
+}
+
+// ResourceHook is a callback in a program that the engine calls before or after an operation on a resource.
+type ResourceHook struct {
+	Event  string // the event at which the hook runs, e.g. "before-create".
+	Target string // the address of the program's ResourceHooks service.
+	Token  string // identifies the callback to the program's ResourceHooks service.
 }
 
 // NewGoal allocates a new resource goal state.
@@ -47,7 +59,7 @@ func NewGoal(t tokens.Type, name tokens.QName, custom bool, props PropertyMap,
 	parent URN, protect bool, dependencies []URN, provider string, initErrors []string,
 	propertyDependencies map[PropertyKey][]URN, deleteBeforeReplace *bool, ignoreChanges []string,
 	additionalSecretOutputs []PropertyKey, aliases []URN, id ID, customTimeouts *CustomTimeouts,
-	replaceOnChanges []string, retainOnDelete bool) *Goal {
+	replaceOnChanges []string, retainOnDelete bool, deletedWith URN, hooks []ResourceHook) *Goal {
 
 	g := &Goal{
 		Type:                    t,
@@ -67,6 +79,8 @@ func NewGoal(t tokens.Type, name tokens.QName, custom bool, props PropertyMap,
 		ID:                      id,
 		ReplaceOnChanges:        replaceOnChanges,
 		RetainOnDelete:          retainOnDelete,
+		DeletedWith:             deletedWith,
+		Hooks:                   hooks,
 	}
 
 	if customTimeouts != nil {
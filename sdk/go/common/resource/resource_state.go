@@ -45,6 +45,7 @@ type State struct {
 	ImportID                ID                    // the resource's import id, if this was an imported resource.
 	SequenceNumber          int                   // an auto-incrementing sequence number for each time this resource gets created/replaced (0 means sequence numbers are unknown, -1 means the last replace didn't use a sequence number).
 	RetainOnDelete          bool                  // if set to True, the providers Delete method will not be called for this resource.
+	DeletedWith             URN                   // If set, the providers Delete method will not be called for this resource if specified resource is being deleted as well.
 }
 
 // NewState creates a new resource value from existing resource state information.
@@ -53,7 +54,7 @@ func NewState(t tokens.Type, urn URN, custom bool, del bool, id ID,
 	external bool, dependencies []URN, initErrors []string, provider string,
 	propertyDependencies map[PropertyKey][]URN, pendingReplacement bool,
 	additionalSecretOutputs []PropertyKey, aliases []URN, timeouts *CustomTimeouts,
-	importID ID, sequenceNumber int, retainOnDelete bool) *State {
+	importID ID, sequenceNumber int, retainOnDelete bool, deletedWith URN) *State {
 
 	contract.Assertf(t != "", "type was empty")
 	contract.Assertf(custom || id == "", "is custom or had empty ID")
@@ -80,6 +81,7 @@ func NewState(t tokens.Type, urn URN, custom bool, del bool, id ID,
 		ImportID:                importID,
 		SequenceNumber:          sequenceNumber,
 		RetainOnDelete:          retainOnDelete,
+		DeletedWith:             deletedWith,
 	}
 
 	if timeouts != nil {
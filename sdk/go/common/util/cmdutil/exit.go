@@ -120,7 +120,11 @@ func RunResultFunc(run func(cmd *cobra.Command, args []string) result.Result) fu
 			// to quit at this point (with an error code so no one thinks we succeeded).  Bailing
 			// always indicates a failure, just one we don't need to print a message for.
 			if res.IsBail() {
-				os.Exit(-1)
+				code := -1
+				if coder, ok := res.(exitCoder); ok {
+					code = coder.ExitCode()
+				}
+				os.Exit(code)
 				return
 			}
 
@@ -140,6 +144,24 @@ func RunResultFunc(run func(cmd *cobra.Command, args []string) result.Result) fu
 	}
 }
 
+// exitCoder is implemented by bail results that request a specific process exit code.
+type exitCoder interface {
+	ExitCode() int
+}
+
+type exitCodeResult int
+
+func (r exitCodeResult) Error() error  { return nil }
+func (r exitCodeResult) IsBail() bool  { return true }
+func (r exitCodeResult) ExitCode() int { return int(r) }
+
+// BailWithExitCode returns a bail result that causes a command wrapped in [RunResultFunc] to exit with the given
+// code rather than the standard failure code. As with any bail, it is up to the command to have already printed any
+// message for the user.
+func BailWithExitCode(code int) result.Result {
+	return exitCodeResult(code)
+}
+
 // Exit exits with a given error.
 func Exit(err error) {
 	ExitError(errorMessage(err))
@@ -45,6 +45,8 @@ const (
 	PolicyDir = "policies"
 	// StackDir is the name of the directory that holds stack information for projects.
 	StackDir = "stacks"
+	// JournalDir is the name of the directory that holds checkpoint journals for stacks.
+	JournalDir = "journals"
 	// LockDir is the name of the directory that holds locking information for projects.
 	LockDir = "locks"
 	// TemplateDir is the name of the directory containing templates.
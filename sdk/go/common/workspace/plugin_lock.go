@@ -0,0 +1,311 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/blang/semver"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// PluginLockFile is the name of the file, next to a project's Pulumi.yaml, that pins the project's plugins.
+const PluginLockFile = "pulumi-plugins.lock"
+
+// PluginLock pins the exact versions of the plugins that a project uses, along with the download URL and checksum of
+// each plugin's tarball for each platform.
+type PluginLock struct {
+	Plugins []LockedPlugin `json:"plugins"`
+}
+
+// LockedPlugin is a specific version of a plugin in a lock file.
+type LockedPlugin struct {
+	Kind      PluginKind       `json:"kind"`
+	Name      string           `json:"name"`
+	Version   semver.Version   `json:"version"`
+	Artifacts []PluginArtifact `json:"artifacts"`
+}
+
+// UnmarshalJSON decodes a locked plugin. Its version may have a leading "v", as versions can elsewhere in the CLI.
+func (p *LockedPlugin) UnmarshalJSON(b []byte) error {
+	type lockedPlugin LockedPlugin
+	var raw struct {
+		lockedPlugin
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	version, err := semver.ParseTolerant(raw.Version)
+	if err != nil {
+		return fmt.Errorf("invalid version for %s plugin %s: %w", raw.Kind, raw.Name, err)
+	}
+	*p = LockedPlugin(raw.lockedPlugin)
+	p.Version = version
+	return nil
+}
+
+// PluginArtifact is the tarball of a plugin for a specific platform.
+type PluginArtifact struct {
+	OS     string `json:"os"`
+	Arch   string `json:"arch"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+// Download fetches the artifact's tarball from its URL and also returns the size of the response (if known).
+func (a PluginArtifact) Download() (io.ReadCloser, int64, error) {
+	u, err := url.Parse(a.URL)
+	if err != nil {
+		return nil, -1, err
+	}
+	switch u.Scheme {
+	case "file":
+		return openTarball(filePath(u))
+	case "oci":
+		// Locked OCI artifacts are pinned by digest, so the version is not needed to resolve them.
+		return newOCISource("", "", a.URL).Download(semver.Version{}, a.OS, a.Arch, getHTTPResponse)
+	}
+
+	// Assets of private GitHub releases are fetched through the releases API, which requires authentication.
+	token := ""
+	if u.Host == "api.github.com" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	req, err := buildHTTPRequest(a.URL, token)
+	if err != nil {
+		return nil, -1, err
+	}
+	if token != "" {
+		req.Header.Set("Accept", "application/octet-stream")
+	}
+	return getHTTPResponse(req)
+}
+
+// IntegrityError is returned when a plugin's tarball does not match the checksum that its lock file records.
+type IntegrityError struct {
+	Plugin   PluginInfo // the plugin whose tarball failed the check.
+	Expected string     // the expected SHA-256 checksum, hex-encoded.
+	Actual   string     // the actual SHA-256 checksum, hex-encoded.
+}
+
+func (err *IntegrityError) Error() string {
+	return fmt.Sprintf("integrity check failed for %s plugin %s: the tarball's SHA-256 checksum is %s, but %s "+
+		"expects %s", err.Plugin.Kind, err.Plugin, err.Actual, PluginLockFile, err.Expected)
+}
+
+// LoadPluginLock loads the plugin lock file in the given project root directory. If the directory has no lock file,
+// LoadPluginLock returns nil.
+func LoadPluginLock(root string) (*PluginLock, error) {
+	lock, err := LoadPluginLockFile(filepath.Join(root, PluginLockFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return lock, err
+}
+
+// LoadPluginLockFile loads the plugin lock file at the given path.
+func LoadPluginLockFile(path string) (*PluginLock, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock PluginLock
+	if err = json.Unmarshal(b, &lock); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", PluginLockFile, err)
+	}
+	return &lock, nil
+}
+
+// Save writes the lock to the plugin lock file in the given project root directory. Plugins and their artifacts are
+// written in a stable order so that the file can be checked in.
+func (lock *PluginLock) Save(root string) error {
+	sort.Slice(lock.Plugins, func(i, j int) bool {
+		pi, pj := lock.Plugins[i], lock.Plugins[j]
+		if pi.Kind != pj.Kind {
+			return pi.Kind < pj.Kind
+		}
+		if pi.Name != pj.Name {
+			return pi.Name < pj.Name
+		}
+		return pi.Version.LT(pj.Version)
+	})
+	for _, p := range lock.Plugins {
+		sort.Slice(p.Artifacts, func(i, j int) bool {
+			ai, aj := p.Artifacts[i], p.Artifacts[j]
+			if ai.OS != aj.OS {
+				return ai.OS < aj.OS
+			}
+			return ai.Arch < aj.Arch
+		})
+	}
+
+	b, err := json.MarshalIndent(lock, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(root, PluginLockFile), append(b, '\n'), 0644)
+}
+
+// find returns the locked plugin with the given kind, name and version, if any.
+func (lock *PluginLock) find(kind PluginKind, name string, version semver.Version) *LockedPlugin {
+	if lock == nil {
+		return nil
+	}
+	for i := range lock.Plugins {
+		p := &lock.Plugins[i]
+		if p.Kind == kind && p.Name == name && p.Version.EQ(version) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Resolve returns the given plugin with its version pinned to the newest locked version of the plugin if it does not
+// have a version of its own.
+func (lock *PluginLock) Resolve(info PluginInfo) PluginInfo {
+	if lock == nil || info.Version != nil {
+		return info
+	}
+	for _, p := range lock.Plugins {
+		if p.Kind == info.Kind && p.Name == info.Name {
+			v := p.Version
+			if info.Version == nil || v.GT(*info.Version) {
+				info.Version = &v
+			}
+		}
+	}
+	return info
+}
+
+// Artifact returns the locked artifact of the given plugin for the given platform, if any.
+func (lock *PluginLock) Artifact(info PluginInfo, opSy, arch string) (PluginArtifact, bool) {
+	if info.Version == nil {
+		return PluginArtifact{}, false
+	}
+	if p := lock.find(info.Kind, info.Name, *info.Version); p != nil {
+		for _, a := range p.Artifacts {
+			if a.OS == opSy && a.Arch == arch {
+				return a, true
+			}
+		}
+	}
+	return PluginArtifact{}, false
+}
+
+// Record adds the given artifact of the given plugin to the lock, replacing any artifact for the same platform.
+func (lock *PluginLock) Record(info PluginInfo, artifact PluginArtifact) {
+	contract.Require(info.Version != nil, "info.Version != nil")
+
+	p := lock.find(info.Kind, info.Name, *info.Version)
+	if p == nil {
+		lock.Plugins = append(lock.Plugins, LockedPlugin{
+			Kind:    info.Kind,
+			Name:    info.Name,
+			Version: *info.Version,
+		})
+		p = &lock.Plugins[len(lock.Plugins)-1]
+	}
+	for i, a := range p.Artifacts {
+		if a.OS == artifact.OS && a.Arch == artifact.Arch {
+			p.Artifacts[i] = artifact
+			return
+		}
+	}
+	p.Artifacts = append(p.Artifacts, artifact)
+}
+
+// HashingReader computes the SHA-256 checksum of the data read through it.
+type HashingReader struct {
+	io.Reader
+	closer io.Closer
+	hash   hash.Hash
+}
+
+// NewHashingReader returns a reader that computes the SHA-256 checksum of the data read from the given reader.
+func NewHashingReader(r io.ReadCloser) *HashingReader {
+	h := sha256.New()
+	return &HashingReader{Reader: io.TeeReader(r, h), closer: r, hash: h}
+}
+
+func (h *HashingReader) Close() error {
+	return h.closer.Close()
+}
+
+// Sum reads any data that remains in the underlying reader and returns the hex-encoded SHA-256 checksum of all of the
+// data that it held.
+func (h *HashingReader) Sum() (string, error) {
+	if _, err := io.Copy(ioutil.Discard, h); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.hash.Sum(nil)), nil
+}
+
+// InstallWithChecksum installs a plugin's tarball into the cache like Install, but first checks that the tarball's
+// SHA-256 checksum is the given hex-encoded checksum. If it is not, the plugin is not installed and an
+// *IntegrityError is returned.
+func (info PluginInfo) InstallWithChecksum(tgz io.ReadCloser, checksum string, reinstall bool) error {
+	defer contract.IgnoreClose(tgz)
+
+	dir := info.PluginDir
+	if dir == "" {
+		var err error
+		if dir, err = GetPluginDir(); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	// Download the tarball to a temporary file so that it is checked before anything is extracted.
+	f, err := ioutil.TempFile(dir, info.Dir()+".download")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		contract.IgnoreClose(f)
+		contract.IgnoreError(os.Remove(f.Name()))
+	}()
+
+	hashing := NewHashingReader(tgz)
+	if _, err = io.Copy(f, hashing); err != nil {
+		return err
+	}
+	actual, err := hashing.Sum()
+	if err != nil {
+		return err
+	}
+	if actual != checksum {
+		return &IntegrityError{Plugin: info, Expected: checksum, Actual: actual}
+	}
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return info.Install(ioutil.NopCloser(f), reinstall)
+}
@@ -0,0 +1,162 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/archive"
+)
+
+func TestPluginLockRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	lock, err := LoadPluginLock(root)
+	require.NoError(t, err)
+	assert.Nil(t, lock)
+
+	v1, v2 := semver.MustParse("1.0.0"), semver.MustParse("2.0.0")
+	aws1 := PluginInfo{Kind: ResourcePlugin, Name: "aws", Version: &v1}
+	aws2 := PluginInfo{Kind: ResourcePlugin, Name: "aws", Version: &v2}
+
+	lock = &PluginLock{}
+	lock.Record(aws2, PluginArtifact{OS: "linux", Arch: "amd64", URL: "https://example.com/a", SHA256: "aa"})
+	lock.Record(aws1, PluginArtifact{OS: "linux", Arch: "amd64", URL: "https://example.com/b", SHA256: "bb"})
+	lock.Record(aws2, PluginArtifact{OS: "darwin", Arch: "arm64", URL: "https://example.com/c", SHA256: "cc"})
+	lock.Record(aws2, PluginArtifact{OS: "linux", Arch: "amd64", URL: "https://example.com/d", SHA256: "dd"})
+	require.NoError(t, lock.Save(root))
+
+	loaded, err := LoadPluginLock(root)
+	require.NoError(t, err)
+	assert.Equal(t, []LockedPlugin{
+		{
+			Kind:    ResourcePlugin,
+			Name:    "aws",
+			Version: v1,
+			Artifacts: []PluginArtifact{
+				{OS: "linux", Arch: "amd64", URL: "https://example.com/b", SHA256: "bb"},
+			},
+		},
+		{
+			Kind:    ResourcePlugin,
+			Name:    "aws",
+			Version: v2,
+			Artifacts: []PluginArtifact{
+				{OS: "darwin", Arch: "arm64", URL: "https://example.com/c", SHA256: "cc"},
+				{OS: "linux", Arch: "amd64", URL: "https://example.com/d", SHA256: "dd"},
+			},
+		},
+	}, loaded.Plugins)
+
+	// An unversioned plugin resolves to its newest locked version.
+	resolved := loaded.Resolve(PluginInfo{Kind: ResourcePlugin, Name: "aws"})
+	require.NotNil(t, resolved.Version)
+	assert.True(t, resolved.Version.EQ(v2))
+	assert.Nil(t, loaded.Resolve(PluginInfo{Kind: ResourcePlugin, Name: "gcp"}).Version)
+
+	artifact, ok := loaded.Artifact(aws1, "linux", "amd64")
+	assert.True(t, ok)
+	assert.Equal(t, "bb", artifact.SHA256)
+	_, ok = loaded.Artifact(aws1, "darwin", "arm64")
+	assert.False(t, ok)
+
+	// A nil lock locks nothing.
+	var none *PluginLock
+	assert.Nil(t, none.Resolve(PluginInfo{Kind: ResourcePlugin, Name: "aws"}).Version)
+	_, ok = none.Artifact(aws1, "linux", "amd64")
+	assert.False(t, ok)
+}
+
+func TestPluginLockInvalidVersion(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(root, PluginLockFile),
+		[]byte(`{"plugins": [{"kind": "resource", "name": "aws", "version": "latest"}]}`), 0600)
+	require.NoError(t, err)
+
+	_, err = LoadPluginLock(root)
+	assert.Error(t, err)
+}
+
+func TestPluginLockTolerantVersion(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(root, PluginLockFile),
+		[]byte(`{"plugins": [{"kind": "resource", "name": "aws", "version": "v5.1.0"}]}`), 0600)
+	require.NoError(t, err)
+
+	lock, err := LoadPluginLock(root)
+	require.NoError(t, err)
+	resolved := lock.Resolve(PluginInfo{Kind: ResourcePlugin, Name: "aws"})
+	require.NotNil(t, resolved.Version)
+	assert.Equal(t, "5.1.0", resolved.Version.String())
+
+	// The version is written back without its leading "v".
+	require.NoError(t, lock.Save(root))
+	b, err := ioutil.ReadFile(filepath.Join(root, PluginLockFile))
+	require.NoError(t, err)
+	assert.True(t, bytes.Contains(b, []byte(`"version": "5.1.0"`)))
+}
+
+func TestInstallWithChecksum(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(src, "pulumi-resource-test"), []byte("#!/bin/sh\n"), 0700)
+	require.NoError(t, err)
+	tgz, err := archive.TGZ(src, "", false)
+	require.NoError(t, err)
+	sum := sha256.Sum256(tgz)
+	checksum := hex.EncodeToString(sum[:])
+
+	v := semver.MustParse("1.0.0")
+	info := PluginInfo{Kind: ResourcePlugin, Name: "test", Version: &v, PluginDir: t.TempDir()}
+
+	// A tarball that does not match its checksum is not installed.
+	err = info.InstallWithChecksum(ioutil.NopCloser(bytes.NewReader(tgz)), "0123", false)
+	var integrityErr *IntegrityError
+	require.True(t, errors.As(err, &integrityErr))
+	assert.Equal(t, checksum, integrityErr.Actual)
+	assert.Equal(t, "0123", integrityErr.Expected)
+	_, err = os.Stat(filepath.Join(info.PluginDir, info.Dir()))
+	assert.True(t, os.IsNotExist(err))
+
+	err = info.InstallWithChecksum(ioutil.NopCloser(bytes.NewReader(tgz)), checksum, false)
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(info.PluginDir, info.Dir(), "pulumi-resource-test"))
+	assert.NoError(t, err)
+
+	// The temporary download is cleaned up.
+	entries, err := ioutil.ReadDir(info.PluginDir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), ".download")
+	}
+}
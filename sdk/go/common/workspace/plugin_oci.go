@@ -0,0 +1,500 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/blang/semver"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/httputil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+// OCI media types.
+const (
+	ociImageIndexMediaType      = "application/vnd.oci.image.index.v1+json"
+	ociImageManifestMediaType   = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+	dockerManifestMediaType     = "application/vnd.docker.distribution.manifest.v2+json"
+
+	// ociRefNameAnnotation is the annotation that names the tag of a manifest in an OCI layout's index.
+	ociRefNameAnnotation = "org.opencontainers.image.ref.name"
+)
+
+// ociManifestMediaTypes are the manifest media types that are requested from registries.
+var ociManifestMediaTypes = strings.Join([]string{
+	ociImageIndexMediaType, ociImageManifestMediaType, dockerManifestListMediaType, dockerManifestMediaType,
+}, ", ")
+
+// ociReference is a parsed oci:// plugin download URL. An oci:// URL names either an artifact in a registry, as in
+// oci://registry.example.com/providers/foo:v1.0.0, or, if it has no host, an artifact in a local OCI layout directory,
+// as in oci:///path/to/layout:v1.0.0. The tag may be replaced with a digest, as in oci://registry/foo@sha256:....
+type ociReference struct {
+	registry   string // the registry's host, or "" for a local OCI layout.
+	repository string // the repository in the registry, or the path of the local OCI layout.
+	tag        string // the tag, if any.
+	digest     string // the digest, if any.
+}
+
+func parseOCIReference(ref string) (ociReference, error) {
+	if !strings.HasPrefix(ref, "oci://") {
+		return ociReference{}, fmt.Errorf("invalid OCI reference %q: expected an oci:// URL", ref)
+	}
+	rest := strings.TrimPrefix(ref, "oci://")
+
+	var result ociReference
+	if i := strings.Index(rest, "@"); i != -1 {
+		result.digest, rest = rest[i+1:], rest[:i]
+		if !strings.HasPrefix(result.digest, "sha256:") {
+			return ociReference{}, fmt.Errorf("invalid OCI reference %q: only sha256 digests are supported", ref)
+		}
+	}
+	if i := strings.LastIndex(rest, ":"); i != -1 && i > strings.LastIndex(rest, "/") {
+		result.tag, rest = rest[i+1:], rest[:i]
+	}
+
+	if strings.HasPrefix(rest, "/") {
+		// A local OCI layout.
+		path := rest
+		if runtime.GOOS == "windows" && len(path) > 2 && path[2] == ':' {
+			path = path[1:]
+		}
+		result.repository = filepath.FromSlash(path)
+	} else {
+		i := strings.Index(rest, "/")
+		if i == -1 || i == len(rest)-1 {
+			return ociReference{}, fmt.Errorf("invalid OCI reference %q: expected oci://REGISTRY/REPOSITORY", ref)
+		}
+		result.registry, result.repository = rest[:i], rest[i+1:]
+	}
+	return result, nil
+}
+
+// reference returns the reference of the manifest that the OCI reference names: its digest, if it has one, and
+// otherwise its tag.
+func (ref ociReference) reference() string {
+	if ref.digest != "" {
+		return ref.digest
+	}
+	return ref.tag
+}
+
+// String returns the oci:// URL of the reference.
+func (ref ociReference) String() string {
+	s := "oci://"
+	if ref.registry == "" {
+		path := filepath.ToSlash(ref.repository)
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		s += path
+	} else {
+		s += ref.registry + "/" + ref.repository
+	}
+	if ref.digest != "" {
+		return s + "@" + ref.digest
+	}
+	if ref.tag != "" {
+		return s + ":" + ref.tag
+	}
+	return s
+}
+
+// ociDescriptor describes a piece of OCI content.
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+	} `json:"platform,omitempty"`
+}
+
+// ociManifest is an OCI image manifest or image index, or their Docker equivalents.
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Manifests []ociDescriptor `json:"manifests"`
+	Layers    []ociDescriptor `json:"layers"`
+}
+
+func (m ociManifest) isIndex() bool {
+	return m.MediaType == ociImageIndexMediaType || m.MediaType == dockerManifestListMediaType || len(m.Manifests) != 0
+}
+
+// ociStore is a registry or local OCI layout that holds OCI content.
+type ociStore interface {
+	// manifest returns the manifest with the given tag or digest, along with the manifest's digest.
+	manifest(reference string) ([]byte, string, error)
+	// blob returns the contents of the blob with the given descriptor.
+	blob(desc ociDescriptor) (io.ReadCloser, error)
+}
+
+// ociSource can download a plugin from an OCI artifact or container image whose single tar+gzip layer holds the
+// plugin, either from a registry or from a local OCI layout. If the oci:// URL has neither a tag nor a digest, the tag
+// is the plugin's version with a "v" prefix. It doesn't support GetLatestVersion.
+type ociSource struct {
+	name string
+	kind PluginKind
+	url  string
+
+	client *http.Client // the client used to talk to registries.
+	pinned string       // the oci:// URL, by digest, of the last manifest downloaded.
+}
+
+func newOCISource(name string, kind PluginKind, ociURL string) *ociSource {
+	return &ociSource{name: name, kind: kind, url: ociURL, client: http.DefaultClient}
+}
+
+func (source *ociSource) GetLatestVersion(
+	getHTTPResponse func(*http.Request) (io.ReadCloser, int64, error)) (*semver.Version, error) {
+	return nil, errors.New("GetLatestVersion is not supported for plugins from OCI artifacts")
+}
+
+func (source *ociSource) Download(
+	version semver.Version, opSy string, arch string,
+	getHTTPResponse func(*http.Request) (io.ReadCloser, int64, error)) (io.ReadCloser, int64, error) {
+
+	ref, err := parseOCIReference(interpolateURL(source.url, version, opSy, arch))
+	if err != nil {
+		return nil, -1, err
+	}
+	if ref.reference() == "" {
+		ref.tag = "v" + version.String()
+	}
+	logging.V(1).Infof("%s downloading from %s", source.name, ref)
+
+	var store ociStore
+	if ref.registry == "" {
+		store = &ociLayout{dir: ref.repository}
+	} else {
+		store = &ociRegistry{client: source.client, registry: ref.registry, repository: ref.repository}
+	}
+
+	// Resolve the manifest for the platform, then download its layer.
+	manifest, digest, err := store.manifest(ref.reference())
+	if err != nil {
+		return nil, -1, err
+	}
+	layer, digest, err := resolveOCILayer(store, manifest, digest, opSy, arch)
+	if err != nil {
+		return nil, -1, fmt.Errorf("%s: %w", ref, err)
+	}
+	blob, err := store.blob(layer)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	pinned := ref
+	pinned.tag, pinned.digest = "", digest
+	source.pinned = pinned.String()
+	return blob, layer.Size, nil
+}
+
+func (source *ociSource) pinnedURL() string {
+	return source.pinned
+}
+
+// resolveOCILayer returns the layer that holds the plugin in the given manifest, which has the given digest, along
+// with the digest of the manifest that lists the layer. If the manifest is an index, the layer is taken from the
+// index's manifest for the given platform.
+func resolveOCILayer(store ociStore, manifest []byte, digest, opSy, arch string) (ociDescriptor, string, error) {
+	for {
+		var m ociManifest
+		if err := json.Unmarshal(manifest, &m); err != nil {
+			return ociDescriptor{}, "", fmt.Errorf("could not parse manifest %s: %w", digest, err)
+		}
+
+		if !m.isIndex() {
+			var layers []ociDescriptor
+			for _, l := range m.Layers {
+				if strings.HasSuffix(l.MediaType, "tar+gzip") || strings.HasSuffix(l.MediaType, "tar.gzip") {
+					layers = append(layers, l)
+				}
+			}
+			if len(layers) != 1 {
+				return ociDescriptor{}, "", fmt.Errorf("manifest %s has %d tar+gzip layers; expected exactly one layer "+
+					"that holds the plugin", digest, len(layers))
+			}
+			return layers[0], digest, nil
+		}
+
+		var platform *ociDescriptor
+		for i, d := range m.Manifests {
+			if d.Platform != nil && d.Platform.OS == opSy && d.Platform.Architecture == arch {
+				platform = &m.Manifests[i]
+				break
+			}
+		}
+		if platform == nil {
+			return ociDescriptor{}, "", fmt.Errorf("index %s has no manifest for %s/%s", digest, opSy, arch)
+		}
+
+		var err error
+		if manifest, digest, err = store.manifest(platform.Digest); err != nil {
+			return ociDescriptor{}, "", err
+		}
+	}
+}
+
+// ociLayout is a local OCI layout directory.
+type ociLayout struct {
+	dir string
+}
+
+func (layout *ociLayout) manifest(reference string) ([]byte, string, error) {
+	digest := reference
+	if !strings.HasPrefix(reference, "sha256:") {
+		b, err := ioutil.ReadFile(filepath.Join(layout.dir, "index.json"))
+		if err != nil {
+			return nil, "", fmt.Errorf("reading OCI layout index: %w", err)
+		}
+		var index ociManifest
+		if err = json.Unmarshal(b, &index); err != nil {
+			return nil, "", fmt.Errorf("could not parse OCI layout index: %w", err)
+		}
+
+		digest = ""
+		for _, d := range index.Manifests {
+			if d.Annotations[ociRefNameAnnotation] == reference {
+				digest = d.Digest
+				break
+			}
+		}
+		if digest == "" {
+			return nil, "", fmt.Errorf("tag %s not found in OCI layout %s", reference, layout.dir)
+		}
+	}
+
+	blob, err := layout.blob(ociDescriptor{Digest: digest})
+	if err != nil {
+		return nil, "", err
+	}
+	defer contract.IgnoreClose(blob)
+	b, err := ioutil.ReadAll(blob)
+	if err != nil {
+		return nil, "", err
+	}
+	return b, digest, nil
+}
+
+func (layout *ociLayout) blob(desc ociDescriptor) (io.ReadCloser, error) {
+	hex := strings.TrimPrefix(desc.Digest, "sha256:")
+	if hex == desc.Digest || strings.ContainsAny(hex, `/\.`) {
+		return nil, fmt.Errorf("unsupported digest %q", desc.Digest)
+	}
+	f, err := os.Open(filepath.Join(layout.dir, "blobs", "sha256", hex))
+	if err != nil {
+		return nil, err
+	}
+	return newOCIDigestReader(f, desc.Digest), nil
+}
+
+// ociRegistry is a repository in an OCI registry.
+type ociRegistry struct {
+	client     *http.Client
+	registry   string
+	repository string
+
+	authorization string // the Authorization header to send, once the registry has challenged a request.
+}
+
+func (r *ociRegistry) manifest(reference string) ([]byte, string, error) {
+	resp, err := r.get("manifests/"+reference, ociManifestMediaTypes)
+	if err != nil {
+		return nil, "", err
+	}
+	defer contract.IgnoreClose(resp.Body)
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(b)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	if strings.HasPrefix(reference, "sha256:") && digest != reference {
+		return nil, "", fmt.Errorf("manifest %s failed digest verification", reference)
+	}
+	return b, digest, nil
+}
+
+func (r *ociRegistry) blob(desc ociDescriptor) (io.ReadCloser, error) {
+	resp, err := r.get("blobs/"+desc.Digest, "")
+	if err != nil {
+		return nil, err
+	}
+	return newOCIDigestReader(resp.Body, desc.Digest), nil
+}
+
+// get fetches the given path under the repository. If the registry challenges the request, get authenticates with
+// the registry, using the credentials in the PULUMI_OCI_USERNAME and PULUMI_OCI_PASSWORD environment variables if they
+// are set, and tries again.
+func (r *ociRegistry) get(path, accept string) (*http.Response, error) {
+	scheme := "https"
+	if host, _, err := net.SplitHostPort(r.registry); err == nil && isLoopbackHost(host) || isLoopbackHost(r.registry) {
+		scheme = "http"
+	}
+	endpoint := fmt.Sprintf("%s://%s/v2/%s/%s", scheme, r.registry, r.repository, path)
+
+	for attempt := 0; ; attempt++ {
+		req, err := buildHTTPRequest(endpoint, "")
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if r.authorization != "" {
+			req.Header.Set("Authorization", r.authorization)
+		}
+
+		logging.V(9).Infof("full plugin download url: %s", req.URL)
+		resp, err := httputil.DoWithRetry(req, r.client)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			contract.IgnoreClose(resp.Body)
+			if r.authorization, err = r.authenticate(challenge); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			contract.IgnoreClose(resp.Body)
+			return nil, fmt.Errorf("%d HTTP error fetching plugin from %s", resp.StatusCode, req.URL)
+		}
+		return resp, nil
+	}
+}
+
+var ociChallengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authenticate answers the given WWW-Authenticate challenge and returns the Authorization header to send.
+func (r *ociRegistry) authenticate(challenge string) (string, error) {
+	username, password := os.Getenv("PULUMI_OCI_USERNAME"), os.Getenv("PULUMI_OCI_PASSWORD")
+
+	scheme := strings.ToLower(strings.SplitN(challenge, " ", 2)[0])
+	switch scheme {
+	case "basic":
+		if username == "" {
+			return "", fmt.Errorf("registry %s requires credentials; set PULUMI_OCI_USERNAME and PULUMI_OCI_PASSWORD",
+				r.registry)
+		}
+		req, err := http.NewRequest("GET", "", nil)
+		contract.AssertNoError(err)
+		req.SetBasicAuth(username, password)
+		return req.Header.Get("Authorization"), nil
+	case "bearer":
+		params := map[string]string{}
+		for _, m := range ociChallengeParam.FindAllStringSubmatch(challenge, -1) {
+			params[m[1]] = m[2]
+		}
+		if params["realm"] == "" {
+			return "", fmt.Errorf("registry %s sent an invalid authentication challenge", r.registry)
+		}
+
+		query := url.Values{}
+		if service := params["service"]; service != "" {
+			query.Set("service", service)
+		}
+		if scope := params["scope"]; scope != "" {
+			query.Set("scope", scope)
+		} else {
+			query.Set("scope", fmt.Sprintf("repository:%s:pull", r.repository))
+		}
+		req, err := buildHTTPRequest(params["realm"]+"?"+query.Encode(), "")
+		if err != nil {
+			return "", err
+		}
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		resp, err := httputil.DoWithRetry(req, r.client)
+		if err != nil {
+			return "", err
+		}
+		defer contract.IgnoreClose(resp.Body)
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return "", fmt.Errorf("%d HTTP error authenticating with registry %s", resp.StatusCode, r.registry)
+		}
+
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+			return "", fmt.Errorf("could not parse token from registry %s: %w", r.registry, err)
+		}
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		return "Bearer " + token.Token, nil
+	default:
+		return "", fmt.Errorf("registry %s requires unsupported authentication %q", r.registry, scheme)
+	}
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ociDigestReader checks that the content read through it matches its sha256 digest.
+type ociDigestReader struct {
+	r      io.ReadCloser
+	hash   hash.Hash
+	digest string
+}
+
+func newOCIDigestReader(r io.ReadCloser, digest string) *ociDigestReader {
+	return &ociDigestReader{r: r, hash: sha256.New(), digest: digest}
+}
+
+func (d *ociDigestReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	d.hash.Write(p[:n]) // hash.Hash.Write never returns an error.
+	if err == io.EOF && !bytes.Equal([]byte("sha256:"+hex.EncodeToString(d.hash.Sum(nil))), []byte(d.digest)) {
+		return n, fmt.Errorf("blob %s failed digest verification", d.digest)
+	}
+	return n, err
+}
+
+func (d *ociDigestReader) Close() error {
+	return d.r.Close()
+}
@@ -0,0 +1,248 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/archive"
+)
+
+// ociTestArtifact is a plugin packaged as an OCI artifact: an index that lists a manifest for linux/amd64, whose
+// single layer is the plugin's tarball.
+type ociTestArtifact struct {
+	blobs map[string][]byte // the artifact's blobs, by digest.
+	index string            // the digest of the index.
+	tgz   []byte            // the plugin's tarball.
+}
+
+func ociDigest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func newOCITestArtifact(t *testing.T, name string) ociTestArtifact {
+	src := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(src, "pulumi-resource-"+name), []byte("#!/bin/sh\n"), 0700)
+	require.NoError(t, err)
+	tgz, err := archive.TGZ(src, "", false)
+	require.NoError(t, err)
+
+	marshal := func(v interface{}) []byte {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return b
+	}
+	manifest := marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     ociImageManifestMediaType,
+		"config": ociDescriptor{
+			MediaType: "application/vnd.oci.image.config.v1+json", Digest: ociDigest([]byte("{}")), Size: 2,
+		},
+		"layers": []ociDescriptor{{
+			MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: ociDigest(tgz), Size: int64(len(tgz)),
+		}},
+	})
+
+	linux := ociDescriptor{MediaType: ociImageManifestMediaType, Digest: ociDigest(manifest), Size: int64(len(manifest))}
+	linux.Platform = &struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+	}{"linux", "amd64"}
+	index := marshal(ociManifest{MediaType: ociImageIndexMediaType, Manifests: []ociDescriptor{linux}})
+
+	blobs := map[string][]byte{}
+	for _, b := range [][]byte{tgz, manifest, index, []byte("{}")} {
+		blobs[ociDigest(b)] = b
+	}
+	return ociTestArtifact{blobs: blobs, index: ociDigest(index), tgz: tgz}
+}
+
+// writeLayout writes the artifact to a local OCI layout with the given tag.
+func (a ociTestArtifact) writeLayout(t *testing.T, tag string) string {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "blobs", "sha256"), 0700))
+	for digest, b := range a.blobs {
+		path := filepath.Join(dir, "blobs", "sha256", strings.TrimPrefix(digest, "sha256:"))
+		require.NoError(t, ioutil.WriteFile(path, b, 0600))
+	}
+	index, err := json.Marshal(ociManifest{Manifests: []ociDescriptor{{
+		MediaType:   ociImageIndexMediaType,
+		Digest:      a.index,
+		Size:        int64(len(a.blobs[a.index])),
+		Annotations: map[string]string{ociRefNameAnnotation: tag},
+	}}})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "index.json"), index, 0600))
+	return dir
+}
+
+func TestParseOCIReference(t *testing.T) {
+	t.Parallel()
+
+	ref, err := parseOCIReference("oci://localhost:5000/providers/foo:v1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, ociReference{registry: "localhost:5000", repository: "providers/foo", tag: "v1.0.0"}, ref)
+
+	ref, err = parseOCIReference("oci://ghcr.io/pulumi/foo@sha256:abcd")
+	require.NoError(t, err)
+	assert.Equal(t, ociReference{registry: "ghcr.io", repository: "pulumi/foo", digest: "sha256:abcd"}, ref)
+	assert.Equal(t, "oci://ghcr.io/pulumi/foo@sha256:abcd", ref.String())
+
+	ref, err = parseOCIReference("oci:///tmp/layout")
+	require.NoError(t, err)
+	assert.Equal(t, "", ref.registry)
+	assert.Equal(t, "", ref.reference())
+
+	_, err = parseOCIReference("oci://ghcr.io")
+	assert.Error(t, err)
+	_, err = parseOCIReference("oci://ghcr.io/foo@md5:abcd")
+	assert.Error(t, err)
+}
+
+func TestOCILayoutSource(t *testing.T) {
+	t.Parallel()
+
+	artifact := newOCITestArtifact(t, "oci")
+	layout := artifact.writeLayout(t, "v1.0.0")
+
+	v := semver.MustParse("1.0.0")
+	info := PluginInfo{
+		Kind:              ResourcePlugin,
+		Name:              "oci",
+		Version:           &v,
+		PluginDir:         t.TempDir(),
+		PluginDownloadURL: "oci://" + filepath.ToSlash(layout),
+	}
+
+	// The tag defaults to the plugin's version.
+	tarball, size, url, err := info.DownloadArtifact("linux", "amd64")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(artifact.tgz)), size)
+	assert.True(t, strings.HasPrefix(url, "oci://"))
+	assert.True(t, strings.Contains(url, "@sha256:"))
+	require.NoError(t, info.Install(tarball, false))
+	_, err = os.Stat(filepath.Join(info.PluginDir, info.Dir(), "pulumi-resource-oci"))
+	assert.NoError(t, err)
+
+	// The pinned URL downloads the same artifact.
+	tarball, _, err = PluginArtifact{OS: "linux", Arch: "amd64", URL: url}.Download()
+	require.NoError(t, err)
+	b, err := ioutil.ReadAll(tarball)
+	assert.NoError(t, err)
+	assert.Equal(t, artifact.tgz, b)
+
+	// There is no manifest for darwin/arm64.
+	_, _, _, err = info.DownloadArtifact("darwin", "arm64")
+	assert.Error(t, err)
+}
+
+func TestOCIRegistrySource(t *testing.T) {
+	t.Parallel()
+
+	artifact := newOCITestArtifact(t, "oci")
+	const token = "secret-token"
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			assert.Equal(t, "repository:providers/oci:pull", r.URL.Query().Get("scope"))
+			assert.NoError(t, json.NewEncoder(w).Encode(map[string]string{"token": token}))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set("WWW-Authenticate",
+				`Bearer realm="`+server.URL+`/token",service="test",scope="repository:providers/oci:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		const prefix = "/v2/providers/oci/"
+		path := strings.TrimPrefix(r.URL.Path, prefix)
+		switch {
+		case path == "manifests/v2.0.0":
+			w.Header().Set("Content-Type", ociImageIndexMediaType)
+			_, err := w.Write(artifact.blobs[artifact.index])
+			assert.NoError(t, err)
+		case strings.HasPrefix(path, "manifests/"), strings.HasPrefix(path, "blobs/"):
+			b, ok := artifact.blobs[path[strings.Index(path, "/")+1:]]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, err := w.Write(b)
+			assert.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	v := semver.MustParse("2.0.0")
+	info := PluginInfo{
+		Kind:              ResourcePlugin,
+		Name:              "oci",
+		Version:           &v,
+		PluginDir:         t.TempDir(),
+		PluginDownloadURL: "oci://" + host + "/providers/oci:v${VERSION}",
+	}
+
+	tarball, _, url, err := info.DownloadArtifact("linux", "amd64")
+	require.NoError(t, err)
+	require.NoError(t, info.Install(tarball, false))
+	_, err = os.Stat(filepath.Join(info.PluginDir, info.Dir(), "pulumi-resource-oci"))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(url, "oci://"+host+"/providers/oci@sha256:"))
+
+	_, _, _, err = info.DownloadArtifact("windows", "amd64")
+	assert.Error(t, err)
+}
+
+func TestOCIDigestMismatch(t *testing.T) {
+	t.Parallel()
+
+	artifact := newOCITestArtifact(t, "oci")
+	layout := artifact.writeLayout(t, "v1.0.0")
+
+	// Corrupt the plugin's layer.
+	path := filepath.Join(layout, "blobs", "sha256", strings.TrimPrefix(ociDigest(artifact.tgz), "sha256:"))
+	require.NoError(t, ioutil.WriteFile(path, append(artifact.tgz, 0), 0600))
+
+	v := semver.MustParse("1.0.0")
+	info := PluginInfo{
+		Kind:              ResourcePlugin,
+		Name:              "oci",
+		Version:           &v,
+		PluginDownloadURL: "oci://" + filepath.ToSlash(layout),
+	}
+	tarball, _, _, err := info.DownloadArtifact("linux", "amd64")
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(tarball)
+	assert.EqualError(t, err, "blob "+ociDigest(artifact.tgz)+" failed digest verification")
+}
@@ -0,0 +1,317 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// PluginSignaturePolicy controls whether the detached signatures of plugin tarballs are verified when plugins are
+// installed. The policy is set with the PULUMI_PLUGIN_SIGNATURE_POLICY environment variable.
+type PluginSignaturePolicy string
+
+const (
+	// PluginSignaturesOff does not verify the signatures of plugin tarballs. This is the default.
+	PluginSignaturesOff PluginSignaturePolicy = "off"
+	// PluginSignaturesWarn verifies the signatures of plugin tarballs, but only warns if a signature is missing or
+	// invalid.
+	PluginSignaturesWarn PluginSignaturePolicy = "warn"
+	// PluginSignaturesRequire refuses to install plugins whose tarballs do not have a valid signature. Plugins from
+	// OCI registries and layouts have no detached signatures, so they can't be installed under this policy.
+	PluginSignaturesRequire PluginSignaturePolicy = "require"
+)
+
+// PluginSignatureSuffix is the suffix of the name of a plugin tarball's detached signature. The signature is published
+// next to the tarball, e.g. as pulumi-resource-aws-v5.0.0-linux-amd64.tar.gz.minisig.
+const PluginSignatureSuffix = ".minisig"
+
+const (
+	// pluginSignaturePolicyEnvVar is the environment variable that sets the plugin signature policy.
+	pluginSignaturePolicyEnvVar = "PULUMI_PLUGIN_SIGNATURE_POLICY"
+	// pluginPublicKeysEnvVar is the environment variable that lists the files of the public keys that plugin
+	// signatures are verified with, separated by the OS's path list separator.
+	pluginPublicKeysEnvVar = "PULUMI_PLUGIN_PUBLIC_KEYS"
+)
+
+// minisign key and signature algorithms. Keys are Ed25519 keys, and only prehashed signatures, which sign the BLAKE2b-512
+// hash of a file rather than the file itself, are supported.
+const (
+	minisignAlgorithm          = "Ed"
+	minisignPrehashedAlgorithm = "ED"
+)
+
+// PluginPublicKey is a minisign public key that plugin signatures are verified with.
+type PluginPublicKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+// ParsePluginPublicKey parses a minisign public key: either the contents of a minisign public key file, or the
+// base64-encoded key on its own.
+func ParsePluginPublicKey(text string) (PluginPublicKey, error) {
+	lines := nonEmptyLines(text)
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "untrusted comment:") {
+		lines = lines[1:]
+	}
+	if len(lines) != 1 {
+		return PluginPublicKey{}, fmt.Errorf("malformed public key")
+	}
+
+	b, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil {
+		return PluginPublicKey{}, fmt.Errorf("malformed public key: %w", err)
+	}
+	if len(b) != 2+8+ed25519.PublicKeySize || string(b[:2]) != minisignAlgorithm {
+		return PluginPublicKey{}, fmt.Errorf("malformed public key: not an Ed25519 minisign key")
+	}
+
+	var key PluginPublicKey
+	copy(key.id[:], b[2:10])
+	key.key = ed25519.PublicKey(b[10:])
+	return key, nil
+}
+
+// pluginSignature is a parsed minisign signature.
+type pluginSignature struct {
+	keyID          [8]byte
+	signature      []byte
+	trustedComment string
+	globalSig      []byte
+}
+
+func parsePluginSignature(text string) (pluginSignature, error) {
+	lines := nonEmptyLines(text)
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "untrusted comment:") ||
+		!strings.HasPrefix(lines[2], "trusted comment: ") {
+		return pluginSignature{}, fmt.Errorf("malformed signature")
+	}
+
+	b, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil {
+		return pluginSignature{}, fmt.Errorf("malformed signature: %w", err)
+	}
+	if len(b) != 2+8+ed25519.SignatureSize {
+		return pluginSignature{}, fmt.Errorf("malformed signature")
+	}
+	if string(b[:2]) != minisignPrehashedAlgorithm {
+		return pluginSignature{}, fmt.Errorf("unsupported signature algorithm %q; sign the tarball with a prehashed "+
+			"signature instead", b[:2])
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return pluginSignature{}, fmt.Errorf("malformed signature: invalid trusted comment signature")
+	}
+
+	var sig pluginSignature
+	copy(sig.keyID[:], b[2:10])
+	sig.signature = b[10:]
+	sig.trustedComment = strings.TrimPrefix(lines[2], "trusted comment: ")
+	sig.globalSig = globalSig
+	return sig, nil
+}
+
+func nonEmptyLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// SignatureError is returned when a plugin's tarball does not have a valid signature and the plugin signature policy
+// requires one.
+type SignatureError struct {
+	Plugin PluginInfo // the plugin whose signature failed to verify.
+	Reason error      // the reason that the signature failed to verify.
+}
+
+func (err *SignatureError) Error() string {
+	return fmt.Sprintf("signature verification failed for %s plugin %s: %v", err.Plugin.Kind, err.Plugin, err.Reason)
+}
+
+func (err *SignatureError) Unwrap() error {
+	return err.Reason
+}
+
+// PluginSignatureVerifier verifies the detached signatures of plugin tarballs according to a signature policy.
+type PluginSignatureVerifier struct {
+	Policy PluginSignaturePolicy // the signature policy.
+	Keys   []PluginPublicKey     // the public keys that signatures are verified with.
+	// Warn reports a signature that failed to verify under the warn policy. If it is nil, the failure is reported as
+	// a warning to the CLI's diagnostics sink.
+	Warn func(err *SignatureError)
+}
+
+// NewPluginSignatureVerifierFromEnv returns the plugin signature verifier that the PULUMI_PLUGIN_SIGNATURE_POLICY and
+// PULUMI_PLUGIN_PUBLIC_KEYS environment variables configure.
+func NewPluginSignatureVerifierFromEnv() (*PluginSignatureVerifier, error) {
+	policy := PluginSignaturePolicy(strings.ToLower(os.Getenv(pluginSignaturePolicyEnvVar)))
+	switch policy {
+	case "", PluginSignaturesOff:
+		return &PluginSignatureVerifier{Policy: PluginSignaturesOff}, nil
+	case PluginSignaturesWarn, PluginSignaturesRequire:
+	default:
+		return nil, fmt.Errorf("invalid %s %q: expected require, warn or off", pluginSignaturePolicyEnvVar, policy)
+	}
+
+	verifier := &PluginSignatureVerifier{Policy: policy}
+	for _, path := range filepath.SplitList(os.Getenv(pluginPublicKeysEnvVar)) {
+		if path == "" {
+			continue
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading plugin public key: %w", err)
+		}
+		key, err := ParsePluginPublicKey(string(b))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		verifier.Keys = append(verifier.Keys, key)
+	}
+	if len(verifier.Keys) == 0 {
+		return nil, fmt.Errorf("%s is %s, but %s names no public keys", pluginSignaturePolicyEnvVar, policy,
+			pluginPublicKeysEnvVar)
+	}
+	return verifier, nil
+}
+
+// verify checks the given minisign signature of a tarball against the tarball's BLAKE2b-512 hash.
+func (v *PluginSignatureVerifier) verify(hash []byte, signature []byte) error {
+	sig, err := parsePluginSignature(string(signature))
+	if err != nil {
+		return err
+	}
+	for _, key := range v.Keys {
+		if key.id != sig.keyID {
+			continue
+		}
+		if !ed25519.Verify(key.key, hash, sig.signature) {
+			return fmt.Errorf("the tarball does not match its signature")
+		}
+		if !ed25519.Verify(key.key, append(append([]byte{}, sig.signature...), sig.trustedComment...), sig.globalSig) {
+			return fmt.Errorf("the signature's trusted comment does not match its signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("the signature was not made with a trusted public key")
+}
+
+// DownloadSignature downloads the detached signature of the plugin's tarball for the given platform from the plugin's
+// source.
+func (info PluginInfo) DownloadSignature(opSy, arch string) ([]byte, error) {
+	if info.Version == nil {
+		return nil, fmt.Errorf("unknown version for plugin %s", info.Name)
+	}
+
+	source := info.GetSource()
+	if _, ok := source.(*ociSource); ok {
+		return nil, fmt.Errorf("plugins from OCI registries and layouts do not have detached signatures")
+	}
+	assets, ok := source.(assetSource)
+	if !ok {
+		return nil, fmt.Errorf("the plugin's source does not publish signatures")
+	}
+	asset := pluginTarballName(info.Kind, info.Name, *info.Version, opSy, arch) + PluginSignatureSuffix
+	resp, _, err := assets.downloadAsset(asset, *info.Version, opSy, arch, getHTTPResponse)
+	if err != nil {
+		return nil, fmt.Errorf("downloading signature: %w", err)
+	}
+	defer contract.IgnoreClose(resp)
+
+	// Signatures are small; don't read more than a reasonable signature could hold.
+	return ioutil.ReadAll(io.LimitReader(resp, 4096))
+}
+
+// verifySignature checks the plugin's tarball, which has the given BLAKE2b-512 hash, against its detached signature
+// according to the verifier's policy. If the policy is warn, a failed check is only reported as a warning.
+func (info PluginInfo) verifySignature(v *PluginSignatureVerifier, hash []byte,
+	signature func() ([]byte, error)) error {
+
+	if v == nil || v.Policy == PluginSignaturesOff {
+		return nil
+	}
+
+	sig, err := signature()
+	if err == nil {
+		err = v.verify(hash, sig)
+	}
+	if err == nil {
+		return nil
+	}
+
+	sigErr := &SignatureError{Plugin: info, Reason: err}
+	if v.Policy == PluginSignaturesWarn {
+		if v.Warn != nil {
+			v.Warn(sigErr)
+		} else {
+			cmdutil.Diag().Warningf(diag.RawMessage("" /*urn*/, sigErr.Error()))
+		}
+		return nil
+	}
+	return sigErr
+}
+
+// spoolTarball copies a plugin's tarball to a temporary file in the plugin directory, so that it can be checked
+// before anything is extracted, and returns the file along with the tarball's BLAKE2b-512 hash. The caller must call
+// the returned cleanup function when it is done with the file.
+func (info PluginInfo) spoolTarball(tgz io.Reader) (*os.File, []byte, func(), error) {
+	dir := info.PluginDir
+	if dir == "" {
+		var err error
+		if dir, err = GetPluginDir(); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, nil, nil, err
+	}
+
+	f, err := ioutil.TempFile(dir, info.Dir()+".download")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cleanup := func() {
+		contract.IgnoreClose(f)
+		contract.IgnoreError(os.Remove(f.Name()))
+	}
+
+	hash, err := blake2b.New512(nil)
+	contract.AssertNoError(err)
+	if _, err = io.Copy(f, io.TeeReader(tgz, hash)); err != nil {
+		cleanup()
+		return nil, nil, nil, err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, nil, err
+	}
+	return f, hash.Sum(nil), cleanup, nil
+}
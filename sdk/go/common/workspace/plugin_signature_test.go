@@ -0,0 +1,183 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/archive"
+)
+
+// minisignKey is a minisign key pair for tests.
+type minisignKey struct {
+	id      [8]byte
+	public  ed25519.PublicKey
+	private ed25519.PrivateKey
+}
+
+func newMinisignKey(t *testing.T, id byte) minisignKey {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return minisignKey{id: [8]byte{id}, public: public, private: private}
+}
+
+func (k minisignKey) publicKey() string {
+	b := append(append([]byte("Ed"), k.id[:]...), k.public...)
+	return "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(b) + "\n"
+}
+
+func (k minisignKey) sign(data []byte) []byte {
+	hash := blake2b.Sum512(data)
+	sig := ed25519.Sign(k.private, hash[:])
+	const comment = "timestamp:1650000000\tfile:plugin.tar.gz"
+	global := ed25519.Sign(k.private, append(append([]byte{}, sig...), comment...))
+	return []byte(fmt.Sprintf("untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(append(append([]byte("ED"), k.id[:]...), sig...)),
+		comment,
+		base64.StdEncoding.EncodeToString(global)))
+}
+
+func TestPluginSignatureVerify(t *testing.T) {
+	t.Parallel()
+
+	trusted, untrusted := newMinisignKey(t, 1), newMinisignKey(t, 2)
+	key, err := ParsePluginPublicKey(trusted.publicKey())
+	require.NoError(t, err)
+	v := &PluginSignatureVerifier{Policy: PluginSignaturesRequire, Keys: []PluginPublicKey{key}}
+
+	data := []byte("tarball")
+	hash := blake2b.Sum512(data)
+	assert.NoError(t, v.verify(hash[:], trusted.sign(data)))
+
+	otherHash := blake2b.Sum512([]byte("tampered"))
+	assert.EqualError(t, v.verify(otherHash[:], trusted.sign(data)), "the tarball does not match its signature")
+	assert.EqualError(t, v.verify(hash[:], untrusted.sign(data)),
+		"the signature was not made with a trusted public key")
+
+	// The trusted comment is covered by the global signature.
+	tampered := bytes.Replace(trusted.sign(data), []byte("file:plugin"), []byte("file:other"), 1)
+	assert.EqualError(t, v.verify(hash[:], tampered), "the signature's trusted comment does not match its signature")
+
+	assert.Error(t, v.verify(hash[:], []byte("not a signature")))
+
+	_, err = ParsePluginPublicKey("untrusted comment: nothing")
+	assert.Error(t, err)
+}
+
+func TestInstallVerifiesSignatures(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(src, "pulumi-resource-signed"), []byte("#!/bin/sh\n"), 0700)
+	require.NoError(t, err)
+	tgz, err := archive.TGZ(src, "", false)
+	require.NoError(t, err)
+
+	trusted, untrusted := newMinisignKey(t, 1), newMinisignKey(t, 2)
+	key, err := ParsePluginPublicKey(trusted.publicKey())
+	require.NoError(t, err)
+
+	missing := func() ([]byte, error) { return nil, errors.New("no signature") }
+	valid := func() ([]byte, error) { return trusted.sign(tgz), nil }
+	invalid := func() ([]byte, error) { return untrusted.sign(tgz), nil }
+
+	tests := []struct {
+		name      string
+		policy    PluginSignaturePolicy
+		signature func() ([]byte, error)
+		installed bool
+		warned    bool
+	}{
+		{"RequireValid", PluginSignaturesRequire, valid, true, false},
+		{"RequireInvalid", PluginSignaturesRequire, invalid, false, false},
+		{"RequireMissing", PluginSignaturesRequire, missing, false, false},
+		{"WarnValid", PluginSignaturesWarn, valid, true, false},
+		{"WarnInvalid", PluginSignaturesWarn, invalid, true, true},
+		{"Off", PluginSignaturesOff, missing, true, false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			v := semver.MustParse("1.0.0")
+			info := PluginInfo{Kind: ResourcePlugin, Name: "signed", Version: &v, PluginDir: t.TempDir()}
+			var warnings []*SignatureError
+			verifier := &PluginSignatureVerifier{
+				Policy: tt.policy,
+				Keys:   []PluginPublicKey{key},
+				Warn:   func(err *SignatureError) { warnings = append(warnings, err) },
+			}
+
+			err := info.installVerified(ioutil.NopCloser(bytes.NewReader(tgz)), false, verifier, tt.signature)
+			_, statErr := os.Stat(filepath.Join(info.PluginDir, info.Dir(), "pulumi-resource-signed"))
+			if tt.installed {
+				assert.NoError(t, err)
+				assert.NoError(t, statErr)
+			} else {
+				var sigErr *SignatureError
+				assert.True(t, errors.As(err, &sigErr))
+				assert.True(t, os.IsNotExist(statErr))
+			}
+			assert.Equal(t, tt.warned, len(warnings) == 1)
+		})
+	}
+}
+
+func TestDownloadSignatureFromFileSource(t *testing.T) {
+	t.Parallel()
+
+	mirror := t.TempDir()
+	v := semver.MustParse("1.0.0")
+	info := PluginInfo{Kind: ResourcePlugin, Name: "signed", Version: &v}
+	sigPath := filepath.Join(mirror, info.TarballName("linux", "amd64")+PluginSignatureSuffix)
+	require.NoError(t, ioutil.WriteFile(sigPath, []byte("signature"), 0600))
+
+	info.PluginDownloadURL = "file://" + filepath.ToSlash(mirror)
+	sig, err := info.DownloadSignature("linux", "amd64")
+	assert.NoError(t, err)
+	assert.Equal(t, "signature", string(sig))
+
+	_, err = info.DownloadSignature("darwin", "arm64")
+	assert.Error(t, err)
+}
+
+func TestDownloadSignatureFromOCISource(t *testing.T) {
+	t.Parallel()
+
+	v := semver.MustParse("1.0.0")
+	info := PluginInfo{
+		Kind:              ResourcePlugin,
+		Name:              "oci",
+		Version:           &v,
+		PluginDownloadURL: "oci://ghcr.io/pulumi/oci",
+	}
+	_, err := info.DownloadSignature("linux", "amd64")
+	assert.EqualError(t, err, "plugins from OCI registries and layouts do not have detached signatures")
+}
@@ -162,6 +162,19 @@ type PluginSource interface {
 	GetLatestVersion(getHTTPResponse func(*http.Request) (io.ReadCloser, int64, error)) (*semver.Version, error)
 }
 
+// assetSource is a PluginSource that can download any asset that is published next to a plugin's tarballs, such as
+// the detached signature of a tarball.
+type assetSource interface {
+	downloadAsset(asset string, version semver.Version, opSy string, arch string,
+		getHTTPResponse func(*http.Request) (io.ReadCloser, int64, error)) (io.ReadCloser, int64, error)
+}
+
+// pinnedSource is a PluginSource whose downloads are not plain HTTP requests. It returns the URL that its last download
+// can be repeated from, e.g. to record the download in a plugin lock file.
+type pinnedSource interface {
+	pinnedURL() string
+}
+
 // getPulumiSource can download a plugin from get.pulumi.com
 type getPulumiSource struct {
 	name string
@@ -178,6 +191,13 @@ func (source *getPulumiSource) GetLatestVersion(
 }
 
 func (source *getPulumiSource) Download(
+	version semver.Version, opSy string, arch string,
+	getHTTPResponse func(*http.Request) (io.ReadCloser, int64, error)) (io.ReadCloser, int64, error) {
+	return source.downloadAsset(pluginTarballName(source.kind, source.name, version, opSy, arch),
+		version, opSy, arch, getHTTPResponse)
+}
+
+func (source *getPulumiSource) downloadAsset(asset string,
 	version semver.Version, opSy string, arch string,
 	getHTTPResponse func(*http.Request) (io.ReadCloser, int64, error)) (io.ReadCloser, int64, error) {
 	serverURL := "https://get.pulumi.com/releases/plugins"
@@ -190,7 +210,7 @@ func (source *getPulumiSource) Download(
 	logging.V(1).Infof("%s downloading from %s", source.name, serverURL)
 	endpoint := fmt.Sprintf("%s/%s",
 		serverURL,
-		url.QueryEscape(fmt.Sprintf("pulumi-%s-%s-v%s-%s-%s.tar.gz", source.kind, source.name, version.String(), opSy, arch)))
+		url.QueryEscape(asset))
 
 	req, err := buildHTTPRequest(endpoint, "")
 	if err != nil {
@@ -266,6 +286,13 @@ func (source *githubSource) GetLatestVersion(
 }
 
 func (source *githubSource) Download(
+	version semver.Version, opSy string, arch string,
+	getHTTPResponse func(*http.Request) (io.ReadCloser, int64, error)) (io.ReadCloser, int64, error) {
+	return source.downloadAsset(pluginTarballName(source.kind, source.name, version, opSy, arch),
+		version, opSy, arch, getHTTPResponse)
+}
+
+func (source *githubSource) downloadAsset(asset string,
 	version semver.Version, opSy string, arch string,
 	getHTTPResponse func(*http.Request) (io.ReadCloser, int64, error)) (io.ReadCloser, int64, error) {
 	if !source.HasAuthentication() {
@@ -276,8 +303,7 @@ func (source *githubSource) Download(
 			source.name, source.organization, source.name)
 
 		pluginURL := fmt.Sprintf("https://github.com/%s/pulumi-%s/releases/download/v%s/%s",
-			source.organization, source.name, version.String(), url.QueryEscape(fmt.Sprintf("pulumi-%s-%s-v%s-%s-%s.tar.gz",
-				source.kind, source.name, version.String(), opSy, arch)))
+			source.organization, source.name, version.String(), url.QueryEscape(asset))
 
 		req, err := buildHTTPRequest(pluginURL, "")
 		if err != nil {
@@ -287,7 +313,6 @@ func (source *githubSource) Download(
 	}
 
 	// If we are using authentication we need to lookup the asset via the github releases API
-	assetName := fmt.Sprintf("pulumi-%s-%s-v%s-%s-%s.tar.gz", source.kind, source.name, version.String(), opSy, arch)
 
 	releaseURL := fmt.Sprintf(
 		"https://api.github.com/repos/%s/pulumi-%s/releases/tags/v%s",
@@ -321,15 +346,15 @@ func (source *githubSource) Download(
 		return nil, -1, err
 	}
 	assetURL := ""
-	for _, asset := range release.Assets {
-		if asset.Name == assetName {
-			assetURL = asset.URL
+	for _, a := range release.Assets {
+		if a.Name == asset {
+			assetURL = a.URL
 		}
 	}
 	if assetURL == "" {
 		logging.V(9).Infof("github json response: %s", jsonBody)
-		logging.V(9).Infof("plugin asset '%s' not found", assetName)
-		return nil, -1, errors.Errorf("plugin asset '%s' not found", assetName)
+		logging.V(9).Infof("plugin asset '%s' not found", asset)
+		return nil, -1, errors.Errorf("plugin asset '%s' not found", asset)
 	}
 
 	logging.V(1).Infof("%s downloading from %s", source.name, assetURL)
@@ -363,6 +388,13 @@ func (source *pluginURLSource) GetLatestVersion(
 }
 
 func (source *pluginURLSource) Download(
+	version semver.Version, opSy string, arch string,
+	getHTTPResponse func(*http.Request) (io.ReadCloser, int64, error)) (io.ReadCloser, int64, error) {
+	return source.downloadAsset(pluginTarballName(source.kind, source.name, version, opSy, arch),
+		version, opSy, arch, getHTTPResponse)
+}
+
+func (source *pluginURLSource) downloadAsset(asset string,
 	version semver.Version, opSy string, arch string,
 	getHTTPResponse func(*http.Request) (io.ReadCloser, int64, error)) (io.ReadCloser, int64, error) {
 	serverURL := source.pluginDownloadURL
@@ -374,7 +406,7 @@ func (source *pluginURLSource) Download(
 	logging.V(1).Infof("%s downloading from %s", source.name, serverURL)
 	endpoint := fmt.Sprintf("%s/%s",
 		serverURL,
-		url.QueryEscape(fmt.Sprintf("pulumi-%s-%s-v%s-%s-%s.tar.gz", source.kind, source.name, version.String(), opSy, arch)))
+		url.QueryEscape(asset))
 
 	req, err := buildHTTPRequest(endpoint, "")
 	if err != nil {
@@ -383,6 +415,130 @@ func (source *pluginURLSource) Download(
 	return getHTTPResponse(req)
 }
 
+// pluginTarballName returns the file name of the tarball of a plugin for the given platform.
+func pluginTarballName(kind PluginKind, name string, version semver.Version, opSy, arch string) string {
+	return fmt.Sprintf("pulumi-%s-%s-v%s-%s-%s.tar.gz", kind, name, version.String(), opSy, arch)
+}
+
+// fileSource can install a plugin from a local directory of plugin tarballs, such as a mirror written by
+// `pulumi plugin vendor`. The tarballs are named like the tarballs that a pluginURLSource downloads.
+type fileSource struct {
+	name string
+	kind PluginKind
+	dir  string
+
+	pinned string // the file URL of the last tarball downloaded.
+}
+
+func newFileSource(name string, kind PluginKind, fileURL string) (*fileSource, error) {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSource{name: name, kind: kind, dir: filePath(u)}, nil
+}
+
+// filePath returns the local path of a file URL. Relative paths, such as file://./mirror, are relative to the current
+// working directory.
+func filePath(u *url.URL) string {
+	path := u.Host + u.Path
+	if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
+
+func (source *fileSource) GetLatestVersion(
+	getHTTPResponse func(*http.Request) (io.ReadCloser, int64, error)) (*semver.Version, error) {
+	opSy, arch, err := GetPluginPlatform()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := ioutil.ReadDir(source.dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix := fmt.Sprintf("pulumi-%s-%s-v", source.kind, source.name)
+	suffix := fmt.Sprintf("-%s-%s.tar.gz", opSy, arch)
+
+	var latest *semver.Version
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		version, err := semver.Parse(strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix))
+		if err != nil {
+			continue
+		}
+		if latest == nil || version.GT(*latest) {
+			v := version
+			latest = &v
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no %s/%s tarballs of %s plugin %s in %s", opSy, arch, source.kind, source.name, source.dir)
+	}
+	return latest, nil
+}
+
+func (source *fileSource) Download(
+	version semver.Version, opSy string, arch string,
+	getHTTPResponse func(*http.Request) (io.ReadCloser, int64, error)) (io.ReadCloser, int64, error) {
+	asset := pluginTarballName(source.kind, source.name, version, opSy, arch)
+	pinned, err := fileURL(filepath.Join(source.dir, asset))
+	if err != nil {
+		return nil, -1, err
+	}
+	resp, length, err := source.downloadAsset(asset, version, opSy, arch, getHTTPResponse)
+	if err != nil {
+		return nil, -1, err
+	}
+	source.pinned = pinned
+	return resp, length, nil
+}
+
+func (source *fileSource) pinnedURL() string {
+	return source.pinned
+}
+
+// fileURL returns the file URL of the given local path.
+func fileURL(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // e.g. C:/mirror on Windows
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String(), nil
+}
+
+func (source *fileSource) downloadAsset(asset string,
+	version semver.Version, opSy string, arch string,
+	getHTTPResponse func(*http.Request) (io.ReadCloser, int64, error)) (io.ReadCloser, int64, error) {
+	path := filepath.Join(source.dir, asset)
+	logging.V(1).Infof("%s installing from %s", source.name, path)
+
+	return openTarball(path)
+}
+
+// openTarball opens a local plugin tarball and also returns its size.
+func openTarball(path string) (io.ReadCloser, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, -1, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		contract.IgnoreClose(f)
+		return nil, -1, err
+	}
+	return f, info.Size(), nil
+}
+
 // fallbackSource handles our current complicated default logic of trying the pulumi public github, then maybe
 // the users private github, then get.pulumi.com
 type fallbackSource struct {
@@ -436,11 +592,18 @@ func (source *fallbackSource) GetLatestVersion(
 }
 
 func (source *fallbackSource) Download(
+	version semver.Version, opSy string, arch string,
+	getHTTPResponse func(*http.Request) (io.ReadCloser, int64, error)) (io.ReadCloser, int64, error) {
+	return source.downloadAsset(pluginTarballName(source.kind, source.name, version, opSy, arch),
+		version, opSy, arch, getHTTPResponse)
+}
+
+func (source *fallbackSource) downloadAsset(asset string,
 	version semver.Version, opSy string, arch string,
 	getHTTPResponse func(*http.Request) (io.ReadCloser, int64, error)) (io.ReadCloser, int64, error) {
 	// Try and get this package from public pulumi github
 	public := newGithubSource("pulumi", source.name, source.kind)
-	resp, length, err := public.Download(version, opSy, arch, getHTTPResponse)
+	resp, length, err := public.downloadAsset(asset, version, opSy, arch, getHTTPResponse)
 	if err == nil {
 		return resp, length, nil
 	}
@@ -456,7 +619,7 @@ func (source *fallbackSource) Download(
 			if !private.HasAuthentication() {
 				err = errors.New("no GitHub authentication information provided")
 			} else {
-				resp, length, err := private.Download(version, opSy, arch, getHTTPResponse)
+				resp, length, err := private.downloadAsset(asset, version, opSy, arch, getHTTPResponse)
 				if err == nil {
 					return resp, length, nil
 				}
@@ -468,7 +631,7 @@ func (source *fallbackSource) Download(
 
 	// Fallback to get.pulumi.com
 	pulumi := newGetPulumiSource(source.name, source.kind)
-	return pulumi.Download(version, opSy, arch, getHTTPResponse)
+	return pulumi.downloadAsset(asset, version, opSy, arch, getHTTPResponse)
 }
 
 // PluginInfo provides basic information about a plugin.  Each plugin gets installed into a system-wide
@@ -513,6 +676,13 @@ func (info PluginInfo) FileSuffix() string {
 	return ""
 }
 
+// TarballName returns the file name of this plugin's tarball for the given platform in a plugin mirror, such as a
+// directory written by `pulumi plugin vendor`.
+func (info PluginInfo) TarballName(opSy, arch string) string {
+	contract.Require(info.Version != nil, "info.Version != nil")
+	return pluginTarballName(info.Kind, info.Name, *info.Version, opSy, arch)
+}
+
 // DirPath returns the directory where this plugin should be installed.
 func (info PluginInfo) DirPath() (string, error) {
 	var err error
@@ -573,6 +743,29 @@ func (info PluginInfo) Delete() error {
 	return nil
 }
 
+// DeleteWithLock removes the plugin from the cache like Delete, but holds the plugin's install lock while it does so.
+// This waits for any concurrent install of the plugin to finish, and keeps the plugin from being installed while it
+// is being removed. Unlike Delete, DeleteWithLock leaves the lock file in place, so that installs that are waiting on
+// the lock continue to exclude each other.
+func (info PluginInfo) DeleteWithLock() error {
+	unlock, err := info.installLock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	dir, err := info.DirPath()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	// Attempt to delete any leftover .partial file. Don't fail the operation if we can't delete it.
+	contract.IgnoreError(os.Remove(fmt.Sprintf("%s.partial", dir)))
+	return nil
+}
+
 // SetFileMetadata adds extra metadata from the given file, representing this plugin's directory.
 func (info *PluginInfo) SetFileMetadata(path string) error {
 	// Get the file info.
@@ -607,15 +800,30 @@ func interpolateURL(serverURL string, version semver.Version, os, arch string) s
 	return replacer.Replace(serverURL)
 }
 
+// newURLSource returns the source for a plugin download URL. file:// URLs name a local directory of plugin tarballs.
+func newURLSource(name string, kind PluginKind, pluginDownloadURL string) PluginSource {
+	if strings.HasPrefix(pluginDownloadURL, "oci://") {
+		return newOCISource(name, kind, pluginDownloadURL)
+	}
+	if strings.HasPrefix(pluginDownloadURL, "file://") {
+		source, err := newFileSource(name, kind, pluginDownloadURL)
+		if err == nil {
+			return source
+		}
+		logging.V(1).Infof("%s: invalid file URL %s: %v", name, pluginDownloadURL, err)
+	}
+	return newPluginURLSource(name, kind, pluginDownloadURL)
+}
+
 func (info PluginInfo) GetSource() PluginSource {
 	// The plugin has a set URL use that.
 	if info.PluginDownloadURL != "" {
-		return newPluginURLSource(info.Name, info.Kind, info.PluginDownloadURL)
+		return newURLSource(info.Name, info.Kind, info.PluginDownloadURL)
 	}
 
 	// If the plugin name matches an override, download the plugin from the override URL.
 	if url, ok := pluginDownloadURLOverridesParsed.get(info.Name); ok {
-		return newPluginURLSource(info.Name, info.Kind, url)
+		return newURLSource(info.Name, info.Kind, url)
 	}
 
 	// Use our default fallback behaviour of github then get.pulumi.com
@@ -629,31 +837,61 @@ func (info PluginInfo) GetLatestVersion() (*semver.Version, error) {
 	return source.GetLatestVersion(getHTTPResponse)
 }
 
-// Download fetches an io.ReadCloser for this plugin and also returns the size of the response (if known).
-func (info PluginInfo) Download() (io.ReadCloser, int64, error) {
-	// Figure out the OS/ARCH pair for the download URL.
+// GetPluginPlatform returns the OS and architecture of the plugins that run on the current platform.
+func GetPluginPlatform() (string, string, error) {
 	var opSy string
 	switch runtime.GOOS {
 	case "darwin", "linux", "windows":
 		opSy = runtime.GOOS
 	default:
-		return nil, -1, errors.Errorf("unsupported plugin OS: %s", runtime.GOOS)
+		return "", "", errors.Errorf("unsupported plugin OS: %s", runtime.GOOS)
 	}
 	var arch string
 	switch runtime.GOARCH {
 	case "amd64", "arm64":
 		arch = runtime.GOARCH
 	default:
-		return nil, -1, errors.Errorf("unsupported plugin architecture: %s", runtime.GOARCH)
+		return "", "", errors.Errorf("unsupported plugin architecture: %s", runtime.GOARCH)
+	}
+	return opSy, arch, nil
+}
+
+// Download fetches an io.ReadCloser for this plugin and also returns the size of the response (if known).
+func (info PluginInfo) Download() (io.ReadCloser, int64, error) {
+	// Figure out the OS/ARCH pair for the download URL.
+	opSy, arch, err := GetPluginPlatform()
+	if err != nil {
+		return nil, -1, err
 	}
 
+	resp, length, _, err := info.DownloadArtifact(opSy, arch)
+	return resp, length, err
+}
+
+// DownloadArtifact fetches an io.ReadCloser for this plugin's tarball for the given OS and architecture. It also
+// returns the size of the response (if known) and the URL that the tarball was downloaded from.
+func (info PluginInfo) DownloadArtifact(opSy, arch string) (io.ReadCloser, int64, string, error) {
 	// The plugin version is necessary for the endpoint. If it's not present, return an error.
 	if info.Version == nil {
-		return nil, -1, errors.Errorf("unknown version for plugin %s", info.Name)
+		return nil, -1, "", errors.Errorf("unknown version for plugin %s", info.Name)
+	}
+
+	// Sources may make several requests, e.g. to look up a release asset. The last request is the download.
+	var downloadURL string
+	get := func(req *http.Request) (io.ReadCloser, int64, error) {
+		downloadURL = req.URL.String()
+		return getHTTPResponse(req)
 	}
 
 	source := info.GetSource()
-	return source.Download(*info.Version, opSy, arch, getHTTPResponse)
+	resp, length, err := source.Download(*info.Version, opSy, arch, get)
+	if err != nil {
+		return nil, -1, "", err
+	}
+	if p, ok := source.(pinnedSource); ok {
+		downloadURL = p.pinnedURL()
+	}
+	return resp, length, downloadURL, nil
 }
 
 func buildHTTPRequest(pluginEndpoint string, token string) (*http.Request, error) {
@@ -731,9 +969,45 @@ func (info PluginInfo) installLock() (unlock func(), err error) {
 // If a failure occurs during installation, the `.partial` file will remain, indicating the plugin wasn't fully
 // installed. The next time the plugin is installed, the old installation directory will be removed and replaced with
 // a fresh install.
+//
+// If the plugin signature policy that the PULUMI_PLUGIN_SIGNATURE_POLICY environment variable sets is not off, the
+// tarball is first checked against the detached signature that the plugin's source publishes next to it. If the
+// policy is require and the signature is missing or invalid, the plugin is not installed and a *SignatureError is
+// returned.
 func (info PluginInfo) Install(tgz io.ReadCloser, reinstall bool) error {
+	verifier, err := NewPluginSignatureVerifierFromEnv()
+	if err != nil {
+		contract.IgnoreClose(tgz)
+		return err
+	}
+	return info.installVerified(tgz, reinstall, verifier, func() ([]byte, error) {
+		opSy, arch, err := GetPluginPlatform()
+		if err != nil {
+			return nil, err
+		}
+		return info.DownloadSignature(opSy, arch)
+	})
+}
+
+// installVerified installs a plugin's tarball like Install, checking the tarball against the signature that the given
+// function returns with the given verifier.
+func (info PluginInfo) installVerified(tgz io.ReadCloser, reinstall bool, verifier *PluginSignatureVerifier,
+	signature func() ([]byte, error)) error {
+
 	defer contract.IgnoreClose(tgz)
 
+	if verifier != nil && verifier.Policy != PluginSignaturesOff {
+		f, hash, cleanup, err := info.spoolTarball(tgz)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		if err = info.verifySignature(verifier, hash, signature); err != nil {
+			return err
+		}
+		tgz = ioutil.NopCloser(f)
+	}
+
 	// Fetch the directory into which we will expand this tarball.
 	finalDir, err := info.DirPath()
 	if err != nil {
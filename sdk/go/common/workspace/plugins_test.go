@@ -21,8 +21,11 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/blang/semver"
 	"github.com/stretchr/testify/assert"
@@ -554,6 +557,91 @@ func TestPluginGetLatestVersion(t *testing.T) {
 	})
 }
 
+func TestPluginFileSource(t *testing.T) {
+	t.Parallel()
+
+	opSy, arch, err := GetPluginPlatform()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	mirror := t.TempDir()
+	write := func(name, contents string) {
+		err := ioutil.WriteFile(filepath.Join(mirror, name), []byte(contents), 0600)
+		assert.NoError(t, err)
+	}
+	write(fmt.Sprintf("pulumi-resource-mockdl-v1.0.0-%s-%s.tar.gz", opSy, arch), "1.0.0")
+	write(fmt.Sprintf("pulumi-resource-mockdl-v1.2.0-%s-%s.tar.gz", opSy, arch), "1.2.0")
+	write("pulumi-resource-mockdl-v2.0.0-plan9-mips.tar.gz", "2.0.0")
+	write(fmt.Sprintf("pulumi-resource-other-v3.0.0-%s-%s.tar.gz", opSy, arch), "3.0.0")
+
+	mirrorURL := "file://" + filepath.ToSlash(mirror)
+	if !strings.HasPrefix(mirrorURL, "file:///") {
+		mirrorURL = "file:///" + strings.TrimPrefix(mirrorURL, "file://")
+	}
+	info := PluginInfo{Kind: ResourcePlugin, Name: "mockdl", PluginDownloadURL: mirrorURL}
+
+	// The latest version is the newest tarball for the current platform.
+	version, err := info.GetLatestVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, semver.MustParse("1.2.0"), *version)
+
+	info.Version = version
+	r, size, downloadURL, err := info.DownloadArtifact(opSy, arch)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), size)
+	readBytes, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.Equal(t, "1.2.0", string(readBytes))
+
+	// The download URL of the tarball can be used to download it again.
+	artifact := PluginArtifact{OS: opSy, Arch: arch, URL: downloadURL}
+	r, _, err = artifact.Download()
+	assert.NoError(t, err)
+	readBytes, err = ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.Equal(t, "1.2.0", string(readBytes))
+
+	_, _, _, err = info.DownloadArtifact("plan9", "arm64")
+	assert.Error(t, err)
+}
+
+func TestPluginDeleteWithLock(t *testing.T) {
+	t.Parallel()
+
+	v := semver.MustParse("1.0.0")
+	info := PluginInfo{Kind: ResourcePlugin, Name: "test", Version: &v, PluginDir: t.TempDir()}
+	dir, err := info.DirPath()
+	assert.NoError(t, err)
+	assert.NoError(t, os.MkdirAll(dir, 0700))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pulumi-resource-test"), nil, 0700))
+	assert.NoError(t, ioutil.WriteFile(dir+".partial", nil, 0600))
+
+	// Hold the install lock, as an install would, and check that the delete waits for it.
+	unlock, err := info.installLock()
+	assert.NoError(t, err)
+	deleted := make(chan error)
+	go func() {
+		deleted <- info.DeleteWithLock()
+	}()
+	select {
+	case <-deleted:
+		assert.Fail(t, "the plugin was deleted while its install lock was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	assert.NoError(t, <-deleted)
+
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(dir + ".partial")
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(dir + ".lock")
+	assert.NoError(t, err)
+}
+
 func TestInterpolateURL(t *testing.T) {
 	t.Parallel()
 
@@ -61,6 +61,77 @@ type ProjectBackend struct {
 type ProjectOptions struct {
 	// Refresh is the ability to always run a refresh as part of a pulumi update / preview / destroy
 	Refresh string `json:"refresh,omitempty" yaml:"refresh,omitempty"`
+	// Retries configures the automatic retry of resource operations that fail with transient provider errors.
+	Retries *ProjectRetries `json:"retries,omitempty" yaml:"retries,omitempty"`
+	// Concurrency limits the number of resource operations that may run at once for specific packages and types.
+	Concurrency *ProjectConcurrency `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+	// Hooks lists commands that are run before and after operations on specific resources.
+	Hooks []ProjectHook `json:"hooks,omitempty" yaml:"hooks,omitempty"`
+	// Timeouts sets the default custom timeouts of the resources whose types match a pattern.
+	Timeouts []ProjectTimeouts `json:"timeouts,omitempty" yaml:"timeouts,omitempty"`
+}
+
+// ProjectTimeouts sets the default create, update and delete timeouts of the custom resources whose types match a
+// pattern. A timeout set by a resource's customTimeouts option takes precedence over these defaults.
+type ProjectTimeouts struct {
+	// Type is a pattern matched against the type token of each resource, in which "*" matches any sequence of
+	// characters, e.g. "aws:rds/*". If empty, the timeouts apply to all types.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+
+	// Create, Update and Delete are durations such as "30m" or "1h30m".
+	Create string `json:"create,omitempty" yaml:"create,omitempty"`
+	Update string `json:"update,omitempty" yaml:"update,omitempty"`
+	Delete string `json:"delete,omitempty" yaml:"delete,omitempty"`
+}
+
+// ProjectHook is a set of commands that are run before and after operations on the custom resources that it matches.
+// Each command is run by the system shell in the project's root directory. If a command fails, the operation fails;
+// if an after command fails, the result of the operation is still recorded. Deletes that do not call the resource's
+// provider, such as those of retained resources, run no commands.
+type ProjectHook struct {
+	// Type is the type token of the resources to which the hook applies. If empty, the hook applies to all types.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// Name is the name of the resources to which the hook applies. If empty, the hook applies to all names.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	BeforeCreate string `json:"beforeCreate,omitempty" yaml:"beforeCreate,omitempty"`
+	AfterCreate  string `json:"afterCreate,omitempty" yaml:"afterCreate,omitempty"`
+	BeforeUpdate string `json:"beforeUpdate,omitempty" yaml:"beforeUpdate,omitempty"`
+	AfterUpdate  string `json:"afterUpdate,omitempty" yaml:"afterUpdate,omitempty"`
+	BeforeDelete string `json:"beforeDelete,omitempty" yaml:"beforeDelete,omitempty"`
+	AfterDelete  string `json:"afterDelete,omitempty" yaml:"afterDelete,omitempty"`
+}
+
+// ProjectConcurrency limits the number of resource operations that may run concurrently. These limits apply in
+// addition to the overall degree of parallelism set by `--parallel`.
+type ProjectConcurrency struct {
+	// Packages maps a package name, e.g. "aws", to the maximum number of concurrent operations on its resources.
+	Packages map[string]int `json:"packages,omitempty" yaml:"packages,omitempty"`
+	// Types maps a resource type token to the maximum number of concurrent operations on resources of that type.
+	Types map[string]int `json:"types,omitempty" yaml:"types,omitempty"`
+}
+
+// ProjectRetries configures the automatic retry of failed resource operations. The default policy applies to all
+// resources; a policy for a specific resource type overrides any fields it sets in the default policy.
+type ProjectRetries struct {
+	Default *ProjectRetryPolicy           `json:"default,omitempty" yaml:"default,omitempty"`
+	Types   map[string]ProjectRetryPolicy `json:"types,omitempty" yaml:"types,omitempty"`
+}
+
+// ProjectRetryPolicy describes when and how often a failed resource operation is retried.
+type ProjectRetryPolicy struct {
+	// MaxAttempts is the maximum number of times an operation is attempted, including the first attempt.
+	MaxAttempts int `json:"maxAttempts,omitempty" yaml:"maxAttempts,omitempty"`
+	// InitialDelay is the delay before the first retry, e.g. "1s". The delay doubles with each subsequent retry.
+	InitialDelay string `json:"initialDelay,omitempty" yaml:"initialDelay,omitempty"`
+	// MaxDelay caps the delay between retries, e.g. "30s".
+	MaxDelay string `json:"maxDelay,omitempty" yaml:"maxDelay,omitempty"`
+	// Codes lists the gRPC status codes that are retryable, e.g. "Unavailable" or "ResourceExhausted".
+	Codes []string `json:"codes,omitempty" yaml:"codes,omitempty"`
+	// Patterns lists regular expressions matched against error messages; a matching error is retryable.
+	Patterns []string `json:"patterns,omitempty" yaml:"patterns,omitempty"`
+	// RetryNonIdempotent allows operations that are not idempotent, such as creates, to be retried.
+	RetryNonIdempotent *bool `json:"retryNonIdempotent,omitempty" yaml:"retryNonIdempotent,omitempty"`
 }
 
 // Project is a Pulumi project manifest.
@@ -53,6 +53,10 @@ type Context struct {
 
 	keepResources    bool // true if resources should be marshaled as strongly-typed references.
 	keepOutputValues bool // true if outputs should be marshaled as strongly-type output values.
+	supportsHooks    bool // true if the engine supports resource hooks.
+
+	hooks     *hookServer // the server through which the engine runs resource hooks, once one has been registered.
+	hooksLock sync.Mutex  // a lock protecting the hook server.
 
 	rpcs     int        // the number of outstanding RPC requests.
 	rpcsDone *sync.Cond // an event signaling completion of RPCs.
@@ -126,6 +130,11 @@ func NewContext(ctx context.Context, info RunInfo) (*Context, error) {
 		return nil, err
 	}
 
+	supportsHooks, err := supportsFeature("resourceHooks")
+	if err != nil {
+		return nil, err
+	}
+
 	context := &Context{
 		ctx:              ctx,
 		info:             info,
@@ -136,6 +145,7 @@ func NewContext(ctx context.Context, info RunInfo) (*Context, error) {
 		engine:           engine,
 		keepResources:    keepResources,
 		keepOutputValues: keepOutputValues,
+		supportsHooks:    supportsHooks,
 	}
 	context.rpcsDone = sync.NewCond(&context.rpcsLock)
 	context.Log = &logState{
@@ -148,6 +158,14 @@ func NewContext(ctx context.Context, info RunInfo) (*Context, error) {
 
 // Close implements io.Closer and relinquishes any outstanding resources held by the context.
 func (ctx *Context) Close() error {
+	ctx.hooksLock.Lock()
+	hooks := ctx.hooks
+	ctx.hooksLock.Unlock()
+	if hooks != nil {
+		if err := hooks.Close(); err != nil {
+			return err
+		}
+	}
 	if ctx.engineConn != nil {
 		if err := ctx.engineConn.Close(); err != nil {
 			return err
@@ -845,6 +863,8 @@ func (ctx *Context) registerResource(
 				Remote:                  remote,
 				ReplaceOnChanges:        inputs.replaceOnChanges,
 				RetainOnDelete:          inputs.retainOnDelete,
+				DeletedWith:             inputs.deletedWith,
+				Hooks:                   inputs.hooks,
 			})
 			if err != nil {
 				logging.V(9).Infof("RegisterResource(%s, %s): error: %v", t, name, err)
@@ -1232,6 +1252,8 @@ type resourceInputs struct {
 	pluginDownloadURL       string
 	replaceOnChanges        []string
 	retainOnDelete          bool
+	deletedWith             string
+	hooks                   []*pulumirpc.RegisterResourceRequest_ResourceHook
 }
 
 // prepareResourceInputs prepares the inputs for a resource operation, shared between read and register.
@@ -1300,6 +1322,21 @@ func (ctx *Context) prepareResourceInputs(res Resource, props Input, t string, o
 		aliases[i] = string(urn)
 	}
 
+	// Await the URN of the resource that this resource is deleted with, if any.
+	var deletedWith string
+	if opts.DeletedWith != nil {
+		urn, _, _, err := opts.DeletedWith.URN().awaitURN(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("error waiting for DeletedWith URN to resolve: %w", err)
+		}
+		deletedWith = string(urn)
+	}
+
+	hooks, err := ctx.registerHooks(opts.Hooks)
+	if err != nil {
+		return nil, fmt.Errorf("registering hooks: %w", err)
+	}
+
 	return &resourceInputs{
 		parent:                  string(resOpts.parentURN),
 		deps:                    deps,
@@ -1319,6 +1356,8 @@ func (ctx *Context) prepareResourceInputs(res Resource, props Input, t string, o
 		pluginDownloadURL:       state.pluginDownloadURL,
 		replaceOnChanges:        resOpts.replaceOnChanges,
 		retainOnDelete:          opts.RetainOnDelete,
+		deletedWith:             deletedWith,
+		hooks:                   hooks,
 	}, nil
 }
 
@@ -0,0 +1,176 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pulumi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"google.golang.org/grpc"
+)
+
+// ResourceHookArgs is the argument bag passed to a resource hook.
+type ResourceHookArgs struct {
+	// The URN of the resource.
+	URN URN
+	// The ID of the resource. It is empty before the resource has been created.
+	ID ID
+	// The inputs of the resource, with secrets revealed.
+	Inputs map[string]interface{}
+	// The outputs of the resource, with secrets revealed. They are only complete after the resource has been created
+	// or updated.
+	Outputs map[string]interface{}
+}
+
+// ResourceHook is a callback that the engine runs before or after an operation on a resource. If the hook returns an
+// error, the operation fails.
+type ResourceHook func(*ResourceHookArgs) error
+
+// ResourceHooks are the callbacks that run before and after the create and update of a custom resource. Hooks are
+// only run when the engine calls the resource's provider, so they are not run during previews or for resources that
+// have not changed. Hooks that run before or after a delete can only be configured in Pulumi.yaml, since a program
+// has exited by the time the resources that it no longer declares are deleted.
+type ResourceHooks struct {
+	// BeforeCreate runs before the resource is created, including when it is created as a replacement.
+	BeforeCreate ResourceHook
+	// AfterCreate runs after the resource is created.
+	AfterCreate ResourceHook
+	// BeforeUpdate runs before the resource is updated.
+	BeforeUpdate ResourceHook
+	// AfterUpdate runs after the resource is updated. The result of the update is kept even if the hook fails.
+	AfterUpdate ResourceHook
+}
+
+// hookServer serves the ResourceHooks interface through which the engine runs the resource hooks of a program.
+type hookServer struct {
+	address string
+	cancel  chan bool
+	done    chan error
+
+	m     sync.Mutex
+	hooks map[string]ResourceHook
+}
+
+func startHookServer() (*hookServer, error) {
+	s := &hookServer{
+		cancel: make(chan bool),
+		hooks:  map[string]ResourceHook{},
+	}
+	port, done, err := rpcutil.Serve(0, s.cancel, []func(*grpc.Server) error{
+		func(srv *grpc.Server) error {
+			pulumirpc.RegisterResourceHooksServer(srv, s)
+			return nil
+		},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.address, s.done = fmt.Sprintf("127.0.0.1:%d", port), done
+	return s, nil
+}
+
+// register adds a hook for the given event to the server and returns its registration for a RegisterResource call.
+func (s *hookServer) register(event string, hook ResourceHook) *pulumirpc.RegisterResourceRequest_ResourceHook {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	token := strconv.Itoa(len(s.hooks))
+	s.hooks[token] = hook
+	return &pulumirpc.RegisterResourceRequest_ResourceHook{Event: event, Target: s.address, Token: token}
+}
+
+func (s *hookServer) Close() error {
+	close(s.cancel)
+	return <-s.done
+}
+
+func (s *hookServer) RunHook(ctx context.Context, req *pulumirpc.RunHookRequest) (*empty.Empty, error) {
+	s.m.Lock()
+	hook, ok := s.hooks[req.GetToken()]
+	s.m.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown resource hook %q", req.GetToken())
+	}
+
+	opts := plugin.MarshalOptions{SkipNulls: true}
+	inputs, err := plugin.UnmarshalProperties(req.GetInputs(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling inputs: %w", err)
+	}
+	outputs, err := plugin.UnmarshalProperties(req.GetOutputs(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling outputs: %w", err)
+	}
+
+	err = hook(&ResourceHookArgs{
+		URN:     URN(req.GetUrn()),
+		ID:      ID(req.GetId()),
+		Inputs:  inputs.Mappable(),
+		Outputs: outputs.Mappable(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &empty.Empty{}, nil
+}
+
+// registerHooks registers the given hooks with the context's hook server, starting the server if necessary, and
+// returns their registrations for a RegisterResource call.
+func (ctx *Context) registerHooks(hooks *ResourceHooks) ([]*pulumirpc.RegisterResourceRequest_ResourceHook, error) {
+	if hooks == nil {
+		return nil, nil
+	}
+	// Mocked programs aren't deployed, so their hooks never run.
+	if ctx.info.Mocks != nil {
+		return nil, nil
+	}
+	if !ctx.supportsHooks {
+		return nil, errors.New("the Pulumi CLI does not support resource hooks; please upgrade to a newer version")
+	}
+
+	ctx.hooksLock.Lock()
+	defer ctx.hooksLock.Unlock()
+
+	var registrations []*pulumirpc.RegisterResourceRequest_ResourceHook
+	for _, h := range []struct {
+		event string
+		hook  ResourceHook
+	}{
+		{"before-create", hooks.BeforeCreate},
+		{"after-create", hooks.AfterCreate},
+		{"before-update", hooks.BeforeUpdate},
+		{"after-update", hooks.AfterUpdate},
+	} {
+		if h.hook == nil {
+			continue
+		}
+		if ctx.hooks == nil {
+			server, err := startHookServer()
+			if err != nil {
+				return nil, fmt.Errorf("starting the resource hook server: %w", err)
+			}
+			ctx.hooks = server
+		}
+		registrations = append(registrations, ctx.hooks.register(h.event, h.hook))
+	}
+	return registrations, nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pulumi
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestRegisterHooks(t *testing.T) {
+	t.Parallel()
+
+	hook := func(*ResourceHookArgs) error { return nil }
+
+	ctx := &Context{}
+	hooks, err := ctx.registerHooks(nil)
+	require.NoError(t, err)
+	assert.Nil(t, hooks)
+
+	// Hooks can't be registered with an engine that doesn't support them.
+	_, err = ctx.registerHooks(&ResourceHooks{AfterCreate: hook})
+	assert.Error(t, err)
+
+	// Mocked programs aren't deployed, so their hooks aren't registered.
+	mocked := &Context{info: RunInfo{Mocks: &testMonitor{}}}
+	hooks, err = mocked.registerHooks(&ResourceHooks{AfterCreate: hook})
+	require.NoError(t, err)
+	assert.Nil(t, hooks)
+
+	ctx.supportsHooks = true
+	hooks, err = ctx.registerHooks(&ResourceHooks{BeforeCreate: hook, AfterUpdate: hook})
+	require.NoError(t, err)
+	require.NotNil(t, ctx.hooks)
+	assert.Equal(t, []*pulumirpc.RegisterResourceRequest_ResourceHook{
+		{Event: "before-create", Target: ctx.hooks.address, Token: "0"},
+		{Event: "after-update", Target: ctx.hooks.address, Token: "1"},
+	}, hooks)
+	assert.NoError(t, ctx.Close())
+}
+
+func TestRunHook(t *testing.T) {
+	t.Parallel()
+
+	server, err := startHookServer()
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, server.Close())
+	}()
+
+	var args *ResourceHookArgs
+	ok := server.register("after-create", func(a *ResourceHookArgs) error {
+		args = a
+		return nil
+	})
+	failing := server.register("before-update", func(*ResourceHookArgs) error {
+		return errors.New("the cache is not ready")
+	})
+
+	conn, err := grpc.Dial(server.address, grpc.WithInsecure(), rpcutil.GrpcChannelOptions())
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pulumirpc.NewResourceHooksClient(conn)
+
+	inputs, err := plugin.MarshalProperties(resource.PropertyMap{
+		"foo": resource.NewStringProperty("bar"),
+	}, plugin.MarshalOptions{})
+	require.NoError(t, err)
+	outputs, err := plugin.MarshalProperties(resource.PropertyMap{
+		"foo": resource.NewStringProperty("bar"),
+		"url": resource.NewStringProperty("https://example.com"),
+	}, plugin.MarshalOptions{})
+	require.NoError(t, err)
+
+	_, err = client.RunHook(context.Background(), &pulumirpc.RunHookRequest{
+		Token:   ok.Token,
+		Event:   "after-create",
+		Urn:     "urn:pulumi:stack::proj::pkgA:m:typA::resA",
+		Id:      "resA-id",
+		Inputs:  inputs,
+		Outputs: outputs,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &ResourceHookArgs{
+		URN:     "urn:pulumi:stack::proj::pkgA:m:typA::resA",
+		ID:      "resA-id",
+		Inputs:  map[string]interface{}{"foo": "bar"},
+		Outputs: map[string]interface{}{"foo": "bar", "url": "https://example.com"},
+	}, args)
+
+	// A hook's error is returned to the engine.
+	_, err = client.RunHook(context.Background(), &pulumirpc.RunHookRequest{Token: failing.Token})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the cache is not ready")
+
+	_, err = client.RunHook(context.Background(), &pulumirpc.RunHookRequest{Token: "unknown"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown resource hook "unknown"`)
+}
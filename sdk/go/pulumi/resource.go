@@ -311,6 +311,11 @@ type resourceOptions struct {
 	PluginDownloadURL string
 	// If set to True, the providers Delete method will not be called for this resource.
 	RetainOnDelete bool
+	// If set, the providers Delete method will not be called for this resource
+	// if specified resource is being deleted as well.
+	DeletedWith Resource
+	// Hooks are callbacks that run before and after the resource is created or updated.
+	Hooks *ResourceHooks
 }
 
 type invokeOptions struct {
@@ -575,3 +580,20 @@ func RetainOnDelete(b bool) ResourceOption {
 		ro.RetainOnDelete = b
 	})
 }
+
+// DeletedWith specifies that this resource is deleted along with the given resource. If set, the providers Delete
+// method will not be called for this resource when the given resource is deleted in the same operation, e.g. for the
+// contents of a Kubernetes namespace or the tables of a database.
+func DeletedWith(r Resource) ResourceOption {
+	return resourceOption(func(ro *resourceOptions) {
+		ro.DeletedWith = r
+	})
+}
+
+// Hooks sets callbacks that the engine runs before and after it creates or updates this resource. A hook that returns
+// an error fails the operation. Hooks can only be set on custom resources.
+func Hooks(hooks *ResourceHooks) ResourceOption {
+	return resourceOption(func(ro *resourceOptions) {
+		ro.Hooks = hooks
+	})
+}
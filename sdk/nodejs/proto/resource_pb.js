@@ -1339,7 +1339,8 @@ proto.pulumirpc.RegisterResourceRequest.toObject = function(includeInstance, msg
     providersMap: (f = msg.getProvidersMap()) ? f.toObject(includeInstance, undefined) : [],
     replaceonchangesList: (f = jspb.Message.getRepeatedField(msg, 23)) == null ? undefined : f,
     plugindownloadurl: jspb.Message.getFieldWithDefault(msg, 24, ""),
-    retainondelete: jspb.Message.getBooleanFieldWithDefault(msg, 25, false)
+    retainondelete: jspb.Message.getBooleanFieldWithDefault(msg, 25, false),
+    deletedwith: jspb.Message.getFieldWithDefault(msg, 26, "")
   };
 
   if (includeInstance) {
@@ -1482,6 +1483,10 @@ proto.pulumirpc.RegisterResourceRequest.deserializeBinaryFromReader = function(m
       var value = /** @type {boolean} */ (reader.readBool());
       msg.setRetainondelete(value);
       break;
+    case 26:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setDeletedwith(value);
+      break;
     default:
       reader.skipField();
       break;
@@ -1682,6 +1687,13 @@ proto.pulumirpc.RegisterResourceRequest.serializeBinaryToWriter = function(messa
       f
     );
   }
+  f = message.getDeletedwith();
+  if (f.length > 0) {
+    writer.writeString(
+      26,
+      f
+    );
+  }
 };
 
 
@@ -2622,6 +2634,24 @@ proto.pulumirpc.RegisterResourceRequest.prototype.setRetainondelete = function(v
 };
 
 
+/**
+ * optional string deletedWith = 26;
+ * @return {string}
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.getDeletedwith = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 26, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.setDeletedwith = function(value) {
+  return jspb.Message.setProto3StringField(this, 26, value);
+};
+
+
 
 /**
  * List of repeated fields within this message type.
@@ -317,6 +317,8 @@ type RegisterResourceRequest struct {
 	ReplaceOnChanges           []string                                                 `protobuf:"bytes,23,rep,name=replaceOnChanges,proto3" json:"replaceOnChanges,omitempty"`
 	PluginDownloadURL          string                                                   `protobuf:"bytes,24,opt,name=pluginDownloadURL,proto3" json:"pluginDownloadURL,omitempty"`
 	RetainOnDelete             bool                                                     `protobuf:"varint,25,opt,name=retainOnDelete,proto3" json:"retainOnDelete,omitempty"`
+	DeletedWith                string                                                   `protobuf:"bytes,26,opt,name=deletedWith,proto3" json:"deletedWith,omitempty"`
+	Hooks                      []*RegisterResourceRequest_ResourceHook                  `protobuf:"bytes,27,rep,name=hooks,proto3" json:"hooks,omitempty"`
 	XXX_NoUnkeyedLiteral       struct{}                                                 `json:"-"`
 	XXX_unrecognized           []byte                                                   `json:"-"`
 	XXX_sizecache              int32                                                    `json:"-"`
@@ -522,6 +524,20 @@ func (m *RegisterResourceRequest) GetRetainOnDelete() bool {
 	return false
 }
 
+func (m *RegisterResourceRequest) GetDeletedWith() string {
+	if m != nil {
+		return m.DeletedWith
+	}
+	return ""
+}
+
+func (m *RegisterResourceRequest) GetHooks() []*RegisterResourceRequest_ResourceHook {
+	if m != nil {
+		return m.Hooks
+	}
+	return nil
+}
+
 // PropertyDependencies describes the resources that a particular property depends on.
 type RegisterResourceRequest_PropertyDependencies struct {
 	Urns                 []string `protobuf:"bytes,1,rep,name=urns,proto3" json:"urns,omitempty"`
@@ -624,6 +640,62 @@ func (m *RegisterResourceRequest_CustomTimeouts) GetDelete() string {
 	return ""
 }
 
+// ResourceHook is a callback in the program that the engine calls before or after an operation on the resource.
+type RegisterResourceRequest_ResourceHook struct {
+	Event                string   `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	Target               string   `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	Token                string   `protobuf:"bytes,3,opt,name=token,proto3" json:"token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RegisterResourceRequest_ResourceHook) Reset()         { *m = RegisterResourceRequest_ResourceHook{} }
+func (m *RegisterResourceRequest_ResourceHook) String() string { return proto.CompactTextString(m) }
+func (*RegisterResourceRequest_ResourceHook) ProtoMessage()    {}
+func (*RegisterResourceRequest_ResourceHook) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d1b72f771c35e3b8, []int{4, 2}
+}
+
+func (m *RegisterResourceRequest_ResourceHook) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RegisterResourceRequest_ResourceHook.Unmarshal(m, b)
+}
+func (m *RegisterResourceRequest_ResourceHook) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RegisterResourceRequest_ResourceHook.Marshal(b, m, deterministic)
+}
+func (m *RegisterResourceRequest_ResourceHook) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RegisterResourceRequest_ResourceHook.Merge(m, src)
+}
+func (m *RegisterResourceRequest_ResourceHook) XXX_Size() int {
+	return xxx_messageInfo_RegisterResourceRequest_ResourceHook.Size(m)
+}
+func (m *RegisterResourceRequest_ResourceHook) XXX_DiscardUnknown() {
+	xxx_messageInfo_RegisterResourceRequest_ResourceHook.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RegisterResourceRequest_ResourceHook proto.InternalMessageInfo
+
+func (m *RegisterResourceRequest_ResourceHook) GetEvent() string {
+	if m != nil {
+		return m.Event
+	}
+	return ""
+}
+
+func (m *RegisterResourceRequest_ResourceHook) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
+}
+
+func (m *RegisterResourceRequest_ResourceHook) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
 // RegisterResourceResponse is returned by the engine after a resource has finished being initialized.  It includes the
 // auto-assigned URN, the provider-assigned ID, and any other properties initialized by the engine.
 type RegisterResourceResponse struct {
@@ -876,6 +948,86 @@ func (m *ResourceInvokeRequest) GetPluginDownloadURL() string {
 	return ""
 }
 
+// RunHookRequest asks a program to run one of the resource hooks that it registered.
+type RunHookRequest struct {
+	Token                string          `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Event                string          `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+	Urn                  string          `protobuf:"bytes,3,opt,name=urn,proto3" json:"urn,omitempty"`
+	Id                   string          `protobuf:"bytes,4,opt,name=id,proto3" json:"id,omitempty"`
+	Inputs               *_struct.Struct `protobuf:"bytes,5,opt,name=inputs,proto3" json:"inputs,omitempty"`
+	Outputs              *_struct.Struct `protobuf:"bytes,6,opt,name=outputs,proto3" json:"outputs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *RunHookRequest) Reset()         { *m = RunHookRequest{} }
+func (m *RunHookRequest) String() string { return proto.CompactTextString(m) }
+func (*RunHookRequest) ProtoMessage()    {}
+func (*RunHookRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d1b72f771c35e3b8, []int{8}
+}
+
+func (m *RunHookRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RunHookRequest.Unmarshal(m, b)
+}
+func (m *RunHookRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RunHookRequest.Marshal(b, m, deterministic)
+}
+func (m *RunHookRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RunHookRequest.Merge(m, src)
+}
+func (m *RunHookRequest) XXX_Size() int {
+	return xxx_messageInfo_RunHookRequest.Size(m)
+}
+func (m *RunHookRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RunHookRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RunHookRequest proto.InternalMessageInfo
+
+func (m *RunHookRequest) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *RunHookRequest) GetEvent() string {
+	if m != nil {
+		return m.Event
+	}
+	return ""
+}
+
+func (m *RunHookRequest) GetUrn() string {
+	if m != nil {
+		return m.Urn
+	}
+	return ""
+}
+
+func (m *RunHookRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *RunHookRequest) GetInputs() *_struct.Struct {
+	if m != nil {
+		return m.Inputs
+	}
+	return nil
+}
+
+func (m *RunHookRequest) GetOutputs() *_struct.Struct {
+	if m != nil {
+		return m.Outputs
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*SupportsFeatureRequest)(nil), "pulumirpc.SupportsFeatureRequest")
 	proto.RegisterType((*SupportsFeatureResponse)(nil), "pulumirpc.SupportsFeatureResponse")
@@ -886,87 +1038,97 @@ func init() {
 	proto.RegisterMapType((map[string]string)(nil), "pulumirpc.RegisterResourceRequest.ProvidersEntry")
 	proto.RegisterType((*RegisterResourceRequest_PropertyDependencies)(nil), "pulumirpc.RegisterResourceRequest.PropertyDependencies")
 	proto.RegisterType((*RegisterResourceRequest_CustomTimeouts)(nil), "pulumirpc.RegisterResourceRequest.CustomTimeouts")
+	proto.RegisterType((*RegisterResourceRequest_ResourceHook)(nil), "pulumirpc.RegisterResourceRequest.ResourceHook")
 	proto.RegisterType((*RegisterResourceResponse)(nil), "pulumirpc.RegisterResourceResponse")
 	proto.RegisterMapType((map[string]*RegisterResourceResponse_PropertyDependencies)(nil), "pulumirpc.RegisterResourceResponse.PropertyDependenciesEntry")
 	proto.RegisterType((*RegisterResourceResponse_PropertyDependencies)(nil), "pulumirpc.RegisterResourceResponse.PropertyDependencies")
 	proto.RegisterType((*RegisterResourceOutputsRequest)(nil), "pulumirpc.RegisterResourceOutputsRequest")
 	proto.RegisterType((*ResourceInvokeRequest)(nil), "pulumirpc.ResourceInvokeRequest")
+	proto.RegisterType((*RunHookRequest)(nil), "pulumirpc.RunHookRequest")
 }
 
 func init() { proto.RegisterFile("resource.proto", fileDescriptor_d1b72f771c35e3b8) }
 
 var fileDescriptor_d1b72f771c35e3b8 = []byte{
-	// 1110 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x57, 0xdd, 0x6e, 0x1b, 0x45,
-	0x14, 0xae, 0x7f, 0xea, 0xd8, 0x27, 0xa9, 0x93, 0x4e, 0x13, 0x7b, 0xb2, 0xa0, 0x10, 0x16, 0x84,
-	0x4c, 0x41, 0x4e, 0x13, 0x90, 0x9a, 0xa2, 0xc2, 0x05, 0x49, 0x41, 0x95, 0x28, 0x29, 0x1b, 0x40,
-	0x80, 0x04, 0xd2, 0xc4, 0x7b, 0xe2, 0x2e, 0x59, 0xef, 0x6c, 0x67, 0x67, 0x83, 0x7c, 0x07, 0xcf,
-	0xc2, 0x9b, 0x70, 0xc9, 0x35, 0x8f, 0xc0, 0x83, 0xa0, 0x99, 0xd9, 0x71, 0x77, 0xbd, 0xeb, 0xc4,
-	0xa9, 0xb8, 0xdb, 0xf3, 0x3b, 0x33, 0xe7, 0x7c, 0xe7, 0x9b, 0x59, 0xe8, 0x0a, 0x4c, 0x78, 0x2a,
-	0x46, 0x38, 0x8c, 0x05, 0x97, 0x9c, 0x74, 0xe2, 0x34, 0x4c, 0x27, 0x81, 0x88, 0x47, 0xce, 0x1b,
-	0x63, 0xce, 0xc7, 0x21, 0xee, 0x69, 0xc3, 0x59, 0x7a, 0xbe, 0x87, 0x93, 0x58, 0x4e, 0x8d, 0x9f,
-	0xf3, 0xe6, 0xbc, 0x31, 0x91, 0x22, 0x1d, 0xc9, 0xcc, 0xda, 0x8d, 0x05, 0xbf, 0x0c, 0x7c, 0x14,
-	0x46, 0x76, 0x07, 0xd0, 0x3b, 0x4d, 0xe3, 0x98, 0x0b, 0x99, 0x7c, 0x81, 0x4c, 0xa6, 0x02, 0x3d,
-	0x7c, 0x99, 0x62, 0x22, 0x49, 0x17, 0xea, 0x81, 0x4f, 0x6b, 0xbb, 0xb5, 0x41, 0xc7, 0xab, 0x07,
-	0xbe, 0xfb, 0x08, 0xfa, 0x25, 0xcf, 0x24, 0xe6, 0x51, 0x82, 0x64, 0x07, 0xe0, 0x05, 0x4b, 0x32,
-	0xab, 0x0e, 0x69, 0x7b, 0x39, 0x8d, 0xfb, 0x77, 0x03, 0xee, 0x79, 0xc8, 0x7c, 0x2f, 0x3b, 0xd1,
-	0x82, 0x25, 0x08, 0x81, 0xa6, 0x9c, 0xc6, 0x48, 0xeb, 0x5a, 0xa3, 0xbf, 0x95, 0x2e, 0x62, 0x13,
-	0xa4, 0x0d, 0xa3, 0x53, 0xdf, 0xa4, 0x07, 0xad, 0x98, 0x09, 0x8c, 0x24, 0x6d, 0x6a, 0x6d, 0x26,
-	0x91, 0x87, 0x00, 0xb1, 0xe0, 0x31, 0x0a, 0x19, 0x60, 0x42, 0x6f, 0xef, 0xd6, 0x06, 0xab, 0x07,
-	0xfd, 0xa1, 0xa9, 0xc7, 0xd0, 0xd6, 0x63, 0x78, 0xaa, 0xeb, 0xe1, 0xe5, 0x5c, 0x89, 0x0b, 0x6b,
-	0x3e, 0xc6, 0x18, 0xf9, 0x18, 0x8d, 0x54, 0x68, 0x6b, 0xb7, 0x31, 0xe8, 0x78, 0x05, 0x1d, 0x71,
-	0xa0, 0x6d, 0x6b, 0x47, 0x57, 0xf4, 0xb2, 0x33, 0x99, 0x50, 0x58, 0xb9, 0x44, 0x91, 0x04, 0x3c,
-	0xa2, 0x6d, 0x6d, 0xb2, 0x22, 0x79, 0x17, 0xee, 0xb0, 0xd1, 0x08, 0x63, 0x79, 0x8a, 0x23, 0x81,
-	0x32, 0xa1, 0x1d, 0x5d, 0x9d, 0xa2, 0x92, 0x1c, 0x42, 0x9f, 0xf9, 0x7e, 0x20, 0x03, 0x1e, 0xb1,
-	0xd0, 0x28, 0x4f, 0x52, 0x19, 0xa7, 0x32, 0xa1, 0xa0, 0xb7, 0xb2, 0xc8, 0xac, 0x56, 0x66, 0x61,
-	0xc0, 0x12, 0x4c, 0xe8, 0xaa, 0xf6, 0xb4, 0x22, 0x19, 0xc0, 0xba, 0x59, 0xc4, 0x56, 0x3d, 0xa1,
-	0x6b, 0x7a, 0xed, 0x79, 0x35, 0xf9, 0x10, 0xee, 0xc6, 0x61, 0x3a, 0x0e, 0xa2, 0x63, 0xfe, 0x5b,
-	0x14, 0x72, 0xe6, 0x7f, 0xe7, 0x7d, 0x45, 0xef, 0xe8, 0x73, 0x94, 0x0d, 0x2e, 0x83, 0xcd, 0x62,
-	0x2f, 0x33, 0x10, 0x6c, 0x40, 0x23, 0x15, 0x51, 0xd6, 0x4d, 0xf5, 0x39, 0xd7, 0x8e, 0xfa, 0xd2,
-	0xed, 0x70, 0xff, 0x5c, 0x85, 0xbe, 0x87, 0xe3, 0x20, 0x91, 0x28, 0xe6, 0x31, 0x63, 0x31, 0x52,
-	0xab, 0xc0, 0x48, 0xbd, 0x12, 0x23, 0x8d, 0x02, 0x46, 0x7a, 0xd0, 0x1a, 0xa5, 0x89, 0xe4, 0x13,
-	0x8d, 0x9d, 0xb6, 0x97, 0x49, 0x64, 0x0f, 0x5a, 0xfc, 0xec, 0x57, 0x1c, 0xc9, 0xeb, 0x70, 0x93,
-	0xb9, 0xa9, 0xca, 0x2b, 0x93, 0x8a, 0x68, 0xe9, 0x4c, 0x56, 0x2c, 0xa1, 0x69, 0xe5, 0x1a, 0x34,
-	0xb5, 0xe7, 0xd0, 0x14, 0xc3, 0x66, 0x56, 0x8c, 0xe9, 0x71, 0x3e, 0x4f, 0x67, 0xb7, 0x31, 0x58,
-	0x3d, 0x78, 0x3c, 0x9c, 0x11, 0xc1, 0x70, 0x41, 0x91, 0x86, 0xcf, 0x2b, 0xc2, 0x9f, 0x44, 0x52,
-	0x4c, 0xbd, 0xca, 0xcc, 0xe4, 0x01, 0xdc, 0xf3, 0x31, 0x44, 0x89, 0x9f, 0xe3, 0x39, 0x57, 0x83,
-	0x1d, 0x87, 0x6c, 0x84, 0x14, 0xf4, 0xb9, 0xaa, 0x4c, 0x79, 0xc4, 0xaf, 0x96, 0x10, 0x1f, 0x8c,
-	0x23, 0x2e, 0xf0, 0xe8, 0x05, 0x8b, 0xc6, 0x1a, 0x75, 0xea, 0xf8, 0x45, 0x65, 0x79, 0x2e, 0xee,
-	0xdc, 0x70, 0x2e, 0xba, 0x4b, 0xcf, 0xc5, 0x7a, 0x71, 0x2e, 0x1c, 0x68, 0x07, 0x13, 0x45, 0x4b,
-	0x4f, 0x7d, 0xba, 0x61, 0x2a, 0x6f, 0x65, 0xf2, 0x23, 0x74, 0x0d, 0x1c, 0xbe, 0x0d, 0x26, 0xc8,
-	0xd5, 0x32, 0x77, 0x35, 0x18, 0xf6, 0x97, 0xa8, 0xf9, 0x51, 0x21, 0xd0, 0x9b, 0x4b, 0x44, 0x3e,
-	0x03, 0xa7, 0xa2, 0x8e, 0xc7, 0x78, 0x1e, 0x44, 0xe8, 0x53, 0xa2, 0x4f, 0x7f, 0x85, 0x07, 0xf9,
-	0x18, 0xb6, 0x92, 0x8c, 0x7e, 0x9f, 0x33, 0x21, 0x03, 0x16, 0x7e, 0xcf, 0xc2, 0x14, 0x13, 0x7a,
-	0x4f, 0x87, 0x56, 0x1b, 0x15, 0xda, 0x05, 0x4e, 0xb8, 0x44, 0xba, 0x69, 0xd0, 0x6e, 0xa4, 0x2a,
-	0x72, 0xd8, 0xaa, 0x26, 0x87, 0x13, 0xe8, 0x58, 0x60, 0x26, 0xb4, 0xa7, 0x11, 0xb8, 0xbf, 0x1c,
-	0x02, 0x4d, 0x8c, 0x81, 0xdd, 0xab, 0x1c, 0xe4, 0x3e, 0x6c, 0x08, 0x73, 0xb4, 0x93, 0xc8, 0x42,
-	0xa4, 0xaf, 0x5b, 0x54, 0xd2, 0x57, 0x33, 0x13, 0x5d, 0xc0, 0x4c, 0xe4, 0x3d, 0x75, 0x67, 0x4a,
-	0x16, 0x44, 0x27, 0xd1, 0xb1, 0x2e, 0x24, 0xdd, 0xd6, 0x67, 0x9a, 0xd3, 0x3a, 0xf7, 0x61, 0xb3,
-	0x6a, 0x40, 0x14, 0x8d, 0xa4, 0x22, 0x4a, 0x68, 0x4d, 0xef, 0x46, 0x7f, 0x3b, 0x3f, 0x40, 0xb7,
-	0xd8, 0x58, 0x4d, 0x20, 0x02, 0x99, 0xb4, 0x14, 0x94, 0x49, 0x4a, 0x9f, 0xc6, 0xbe, 0xd2, 0x1b,
-	0x1a, 0xca, 0x24, 0xa5, 0x37, 0x6d, 0xb5, 0x44, 0x64, 0x24, 0xe7, 0xf7, 0x1a, 0x6c, 0x2f, 0x9c,
-	0x53, 0xc5, 0xa6, 0x17, 0x38, 0xb5, 0x6c, 0x7a, 0x81, 0x53, 0xf2, 0x0c, 0x6e, 0x5f, 0xaa, 0xa6,
-	0x66, 0x44, 0xfa, 0xf0, 0x35, 0x69, 0xc0, 0x33, 0x59, 0x3e, 0xa9, 0x1f, 0xd6, 0x9c, 0xc7, 0xd0,
-	0x2d, 0xf6, 0xa9, 0x62, 0xd9, 0xcd, 0xfc, 0xb2, 0x9d, 0x5c, 0xb4, 0xfb, 0x57, 0x03, 0x68, 0x79,
-	0xe5, 0x85, 0xb7, 0x81, 0xb9, 0xec, 0xeb, 0xb3, 0xcb, 0xfe, 0x15, 0xe1, 0x36, 0x96, 0x23, 0xdc,
-	0x1e, 0xb4, 0x12, 0xc9, 0xce, 0x42, 0xb4, 0xcc, 0x6d, 0x24, 0x35, 0xea, 0xe6, 0x4b, 0x5d, 0xf9,
-	0x7a, 0xd4, 0x33, 0x91, 0xbc, 0x5c, 0x40, 0xa4, 0x2d, 0x0d, 0xe3, 0x4f, 0xaf, 0xac, 0xa0, 0x39,
-	0xc7, 0x4d, 0x99, 0xf4, 0x46, 0xd8, 0xfa, 0xe3, 0x86, 0x08, 0xf8, 0xba, 0x88, 0x80, 0xc3, 0xd7,
-	0xdd, 0x7f, 0xbe, 0x89, 0x08, 0x3b, 0xf3, 0xb1, 0x19, 0x85, 0xda, 0x0b, 0xb7, 0xdc, 0xc9, 0x7d,
-	0x58, 0xe1, 0x19, 0x0b, 0x5f, 0x73, 0xa9, 0x5b, 0x3f, 0xf7, 0xdf, 0x1a, 0x6c, 0xd9, 0xfc, 0x4f,
-	0xa3, 0x4b, 0x7e, 0x81, 0xb9, 0xf4, 0x92, 0x5f, 0xd8, 0xf4, 0x92, 0x5f, 0x90, 0x0f, 0xa0, 0xc9,
-	0xc4, 0xf8, 0xda, 0xdc, 0xda, 0xa9, 0x70, 0x8f, 0x36, 0x16, 0xbf, 0xca, 0x9a, 0xc5, 0x3b, 0xaa,
-	0x82, 0xfe, 0x6e, 0xdf, 0xe0, 0x6d, 0xd4, 0x5a, 0xc0, 0x40, 0x07, 0xff, 0x34, 0x61, 0xdd, 0xc6,
-	0x3e, 0xe3, 0x51, 0x20, 0xb9, 0x20, 0x3f, 0xc1, 0xfa, 0xdc, 0xbb, 0x99, 0xbc, 0x9d, 0xeb, 0x5c,
-	0xf5, 0xeb, 0xdb, 0x71, 0xaf, 0x72, 0x31, 0xbd, 0x75, 0x6f, 0x91, 0x2f, 0xa1, 0x65, 0xaa, 0x49,
-	0x76, 0x0b, 0x60, 0xa8, 0x28, 0xb4, 0xb3, 0x9d, 0xf3, 0xb0, 0x96, 0x59, 0xa2, 0x13, 0x58, 0x3b,
-	0x95, 0x02, 0xd9, 0xe4, 0x7f, 0x49, 0xf7, 0xa0, 0x46, 0x1e, 0x41, 0xf3, 0x88, 0x85, 0x21, 0xe9,
-	0xe5, 0xdc, 0x94, 0xc2, 0x86, 0xf7, 0x4b, 0xfa, 0xd9, 0x5e, 0xbe, 0x81, 0xb5, 0xfc, 0x03, 0x93,
-	0xec, 0x14, 0xf6, 0x52, 0xfa, 0x8b, 0x70, 0xde, 0x5a, 0x68, 0x9f, 0xa5, 0xfc, 0x19, 0x36, 0xe6,
-	0x51, 0x4e, 0xdc, 0xeb, 0x09, 0xd4, 0x79, 0x67, 0x89, 0x11, 0x73, 0x6f, 0x91, 0x5f, 0xca, 0xcf,
-	0x55, 0xfb, 0x0e, 0x79, 0xff, 0x8a, 0x0c, 0xc5, 0x41, 0x73, 0x7a, 0x25, 0xa4, 0x3f, 0x51, 0xbf,
-	0x75, 0xee, 0xad, 0xb3, 0x96, 0xd6, 0x7c, 0xf4, 0x5f, 0x00, 0x00, 0x00, 0xff, 0xff, 0xd2, 0x7a,
-	0xa7, 0xbf, 0x13, 0x0e, 0x00, 0x00,
+	// 1242 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x57, 0x5f, 0x73, 0xdb, 0x44,
+	0x10, 0xaf, 0xff, 0xc4, 0x89, 0x37, 0x89, 0x93, 0x5e, 0x53, 0xfb, 0xaa, 0x32, 0xc5, 0x08, 0x86,
+	0x09, 0x85, 0x71, 0xda, 0xc0, 0x4c, 0x5b, 0xa6, 0xf0, 0x40, 0x53, 0xa0, 0x33, 0x94, 0x14, 0x85,
+	0xff, 0x33, 0x30, 0x73, 0xb1, 0xb6, 0x8e, 0xb0, 0xac, 0x53, 0x4f, 0xa7, 0x30, 0x7e, 0x83, 0xaf,
+	0xd6, 0x47, 0x9e, 0xf9, 0x00, 0x3c, 0xf0, 0x41, 0x98, 0xbb, 0xd3, 0xb9, 0x92, 0x2d, 0xc5, 0x4e,
+	0x87, 0x37, 0xed, 0xde, 0xde, 0xde, 0xed, 0xde, 0x6f, 0x7f, 0xbb, 0x82, 0x8e, 0xc0, 0x84, 0xa7,
+	0x62, 0x88, 0x83, 0x58, 0x70, 0xc9, 0x49, 0x3b, 0x4e, 0xc3, 0x74, 0x12, 0x88, 0x78, 0xe8, 0xdc,
+	0x1c, 0x71, 0x3e, 0x0a, 0xf1, 0x40, 0x2f, 0x9c, 0xa6, 0xcf, 0x0f, 0x70, 0x12, 0xcb, 0xa9, 0xb1,
+	0x73, 0xde, 0x98, 0x5f, 0x4c, 0xa4, 0x48, 0x87, 0x32, 0x5b, 0xed, 0xc4, 0x82, 0x9f, 0x07, 0x3e,
+	0x0a, 0x23, 0xbb, 0xfb, 0xd0, 0x3d, 0x49, 0xe3, 0x98, 0x0b, 0x99, 0x7c, 0x8e, 0x4c, 0xa6, 0x02,
+	0x3d, 0x7c, 0x91, 0x62, 0x22, 0x49, 0x07, 0xea, 0x81, 0x4f, 0x6b, 0xfd, 0xda, 0x7e, 0xdb, 0xab,
+	0x07, 0xbe, 0xfb, 0x00, 0x7a, 0x0b, 0x96, 0x49, 0xcc, 0xa3, 0x04, 0xc9, 0x2d, 0x80, 0x33, 0x96,
+	0x64, 0xab, 0x7a, 0xcb, 0x86, 0x97, 0xd3, 0xb8, 0x7f, 0x35, 0xe0, 0x9a, 0x87, 0xcc, 0xf7, 0xb2,
+	0x88, 0x2a, 0x8e, 0x20, 0x04, 0x9a, 0x72, 0x1a, 0x23, 0xad, 0x6b, 0x8d, 0xfe, 0x56, 0xba, 0x88,
+	0x4d, 0x90, 0x36, 0x8c, 0x4e, 0x7d, 0x93, 0x2e, 0xb4, 0x62, 0x26, 0x30, 0x92, 0xb4, 0xa9, 0xb5,
+	0x99, 0x44, 0xee, 0x01, 0xc4, 0x82, 0xc7, 0x28, 0x64, 0x80, 0x09, 0x5d, 0xeb, 0xd7, 0xf6, 0x37,
+	0x0f, 0x7b, 0x03, 0x93, 0x8f, 0x81, 0xcd, 0xc7, 0xe0, 0x44, 0xe7, 0xc3, 0xcb, 0x99, 0x12, 0x17,
+	0xb6, 0x7c, 0x8c, 0x31, 0xf2, 0x31, 0x1a, 0xaa, 0xad, 0xad, 0x7e, 0x63, 0xbf, 0xed, 0x15, 0x74,
+	0xc4, 0x81, 0x0d, 0x9b, 0x3b, 0xba, 0xae, 0x8f, 0x9d, 0xc9, 0x84, 0xc2, 0xfa, 0x39, 0x8a, 0x24,
+	0xe0, 0x11, 0xdd, 0xd0, 0x4b, 0x56, 0x24, 0xef, 0xc0, 0x36, 0x1b, 0x0e, 0x31, 0x96, 0x27, 0x38,
+	0x14, 0x28, 0x13, 0xda, 0xd6, 0xd9, 0x29, 0x2a, 0xc9, 0x7d, 0xe8, 0x31, 0xdf, 0x0f, 0x64, 0xc0,
+	0x23, 0x16, 0x1a, 0xe5, 0x71, 0x2a, 0xe3, 0x54, 0x26, 0x14, 0xf4, 0x55, 0xaa, 0x96, 0xd5, 0xc9,
+	0x2c, 0x0c, 0x58, 0x82, 0x09, 0xdd, 0xd4, 0x96, 0x56, 0x24, 0xfb, 0xb0, 0x63, 0x0e, 0xb1, 0x59,
+	0x4f, 0xe8, 0x96, 0x3e, 0x7b, 0x5e, 0x4d, 0x3e, 0x80, 0xab, 0x71, 0x98, 0x8e, 0x82, 0xe8, 0x88,
+	0xff, 0x1e, 0x85, 0x9c, 0xf9, 0xdf, 0x79, 0x5f, 0xd1, 0x6d, 0x1d, 0xc7, 0xe2, 0x82, 0xcb, 0x60,
+	0xaf, 0xf8, 0x96, 0x19, 0x08, 0x76, 0xa1, 0x91, 0x8a, 0x28, 0x7b, 0x4d, 0xf5, 0x39, 0xf7, 0x1c,
+	0xf5, 0x95, 0x9f, 0xc3, 0xfd, 0x67, 0x0b, 0x7a, 0x1e, 0x8e, 0x82, 0x44, 0xa2, 0x98, 0xc7, 0x8c,
+	0xc5, 0x48, 0xad, 0x04, 0x23, 0xf5, 0x52, 0x8c, 0x34, 0x0a, 0x18, 0xe9, 0x42, 0x6b, 0x98, 0x26,
+	0x92, 0x4f, 0x34, 0x76, 0x36, 0xbc, 0x4c, 0x22, 0x07, 0xd0, 0xe2, 0xa7, 0xbf, 0xe1, 0x50, 0x2e,
+	0xc3, 0x4d, 0x66, 0xa6, 0x32, 0xaf, 0x96, 0xd4, 0x8e, 0x96, 0xf6, 0x64, 0xc5, 0x05, 0x34, 0xad,
+	0x2f, 0x41, 0xd3, 0xc6, 0x1c, 0x9a, 0x62, 0xd8, 0xcb, 0x92, 0x31, 0x3d, 0xca, 0xfb, 0x69, 0xf7,
+	0x1b, 0xfb, 0x9b, 0x87, 0x0f, 0x07, 0x33, 0x22, 0x18, 0x54, 0x24, 0x69, 0xf0, 0xac, 0x64, 0xfb,
+	0xe3, 0x48, 0x8a, 0xa9, 0x57, 0xea, 0x99, 0xdc, 0x81, 0x6b, 0x3e, 0x86, 0x28, 0xf1, 0x33, 0x7c,
+	0xce, 0x05, 0x7a, 0x18, 0x87, 0x6c, 0x88, 0x14, 0x74, 0x5c, 0x65, 0x4b, 0x79, 0xc4, 0x6f, 0x2e,
+	0x20, 0x3e, 0x18, 0x45, 0x5c, 0xe0, 0xa3, 0x33, 0x16, 0x8d, 0x34, 0xea, 0x54, 0xf8, 0x45, 0xe5,
+	0x62, 0x5d, 0x6c, 0x5f, 0xb2, 0x2e, 0x3a, 0x2b, 0xd7, 0xc5, 0x4e, 0xb1, 0x2e, 0x1c, 0xd8, 0x08,
+	0x26, 0x8a, 0x96, 0x9e, 0xf8, 0x74, 0xd7, 0x64, 0xde, 0xca, 0xe4, 0x27, 0xe8, 0x18, 0x38, 0x7c,
+	0x1b, 0x4c, 0x90, 0xab, 0x63, 0xae, 0x6a, 0x30, 0xdc, 0x5d, 0x21, 0xe7, 0x8f, 0x0a, 0x1b, 0xbd,
+	0x39, 0x47, 0xe4, 0x53, 0x70, 0x4a, 0xf2, 0x78, 0x84, 0xcf, 0x83, 0x08, 0x7d, 0x4a, 0x74, 0xf4,
+	0x17, 0x58, 0x90, 0x8f, 0xe0, 0x7a, 0x92, 0xd1, 0xef, 0x33, 0x26, 0x64, 0xc0, 0xc2, 0xef, 0x59,
+	0x98, 0x62, 0x42, 0xaf, 0xe9, 0xad, 0xe5, 0x8b, 0x0a, 0xed, 0x02, 0x27, 0x5c, 0x22, 0xdd, 0x33,
+	0x68, 0x37, 0x52, 0x19, 0x39, 0x5c, 0x2f, 0x27, 0x87, 0x63, 0x68, 0x5b, 0x60, 0x26, 0xb4, 0xdb,
+	0x6f, 0xac, 0x98, 0x8d, 0x67, 0x76, 0x8f, 0x81, 0xdd, 0x2b, 0x1f, 0xe4, 0x36, 0xec, 0x0a, 0x13,
+	0xda, 0x71, 0x64, 0x21, 0xd2, 0xd3, 0x4f, 0xb4, 0xa0, 0x2f, 0x67, 0x26, 0x5a, 0xc1, 0x4c, 0xe4,
+	0x5d, 0xd5, 0x33, 0x25, 0x0b, 0xa2, 0xe3, 0xe8, 0x48, 0x27, 0x92, 0xde, 0xd0, 0x31, 0xcd, 0x69,
+	0x49, 0x1f, 0x36, 0x4d, 0xa2, 0xfd, 0x1f, 0x02, 0x79, 0x46, 0x1d, 0xed, 0x2f, 0xaf, 0x22, 0x8f,
+	0x61, 0xed, 0x8c, 0xf3, 0x71, 0x42, 0x6f, 0xea, 0x80, 0x0f, 0x56, 0x08, 0xd8, 0xca, 0x5f, 0x72,
+	0x3e, 0xf6, 0xcc, 0x6e, 0xe7, 0x36, 0xec, 0x95, 0x55, 0xa2, 0xe2, 0xab, 0x54, 0x44, 0x09, 0xad,
+	0xe9, 0xb0, 0xf5, 0xb7, 0xf3, 0x23, 0x74, 0x8a, 0x08, 0xd2, 0x4c, 0x25, 0x90, 0x49, 0xcb, 0x75,
+	0x99, 0xa4, 0xf4, 0x69, 0xec, 0x33, 0x69, 0xf9, 0x2e, 0x93, 0x94, 0xde, 0xc4, 0x60, 0x19, 0xcf,
+	0x48, 0x8e, 0x07, 0x5b, 0xf9, 0xcb, 0x91, 0x3d, 0x58, 0xc3, 0x73, 0x45, 0x8c, 0xc6, 0xad, 0x11,
+	0xd4, 0x6e, 0xc9, 0xc4, 0x08, 0xa5, 0xf5, 0x6a, 0x24, 0x65, 0x2d, 0xf9, 0x18, 0xa3, 0xcc, 0xa9,
+	0x11, 0x9c, 0x3f, 0x6a, 0x70, 0xa3, 0x92, 0x64, 0x54, 0x2b, 0x18, 0xe3, 0xd4, 0xb6, 0x82, 0x31,
+	0x4e, 0xc9, 0x53, 0x58, 0x3b, 0x57, 0x88, 0xcc, 0xba, 0xc0, 0xbd, 0xd7, 0xe4, 0x30, 0xcf, 0x78,
+	0xf9, 0xb8, 0x7e, 0xbf, 0xe6, 0x3c, 0x84, 0x4e, 0x11, 0x64, 0x25, 0xc7, 0xee, 0xe5, 0x8f, 0x6d,
+	0xe7, 0x76, 0xbb, 0x2f, 0x1b, 0x40, 0x17, 0x4f, 0xae, 0x6c, 0x65, 0x66, 0x52, 0xa9, 0xcf, 0x26,
+	0x95, 0x57, 0xdd, 0xa2, 0xb1, 0x5a, 0xb7, 0xe8, 0x42, 0x2b, 0x91, 0xec, 0x34, 0x44, 0xdb, 0x76,
+	0x8c, 0xa4, 0x78, 0xca, 0x7c, 0xa9, 0x79, 0x45, 0xf3, 0x54, 0x26, 0x92, 0x17, 0x15, 0x5d, 0xa0,
+	0xa5, 0x21, 0xf9, 0xc9, 0x85, 0x19, 0x34, 0x71, 0x5c, 0xb6, 0x0d, 0x5c, 0x0a, 0xaf, 0x7f, 0x5e,
+	0x12, 0x01, 0x5f, 0x17, 0x11, 0x70, 0xff, 0x75, 0xef, 0x9f, 0x7f, 0x44, 0x84, 0x5b, 0xf3, 0x7b,
+	0x33, 0xfe, 0xb7, 0xd3, 0xc2, 0xe2, 0x4b, 0xde, 0x85, 0x75, 0x9e, 0xb5, 0x90, 0x25, 0x13, 0x89,
+	0xb5, 0x73, 0xff, 0xad, 0xc1, 0x75, 0xeb, 0xff, 0x49, 0x74, 0xce, 0xc7, 0x98, 0x73, 0x2f, 0xf9,
+	0xd8, 0xba, 0x97, 0x7c, 0x4c, 0xde, 0x87, 0x26, 0x13, 0xa3, 0xa5, 0xbe, 0xb5, 0x51, 0x61, 0x08,
+	0x68, 0x54, 0x8f, 0x94, 0xcd, 0x62, 0x83, 0x2d, 0xe1, 0xee, 0xb5, 0x4b, 0x0c, 0x76, 0xad, 0xaa,
+	0xc1, 0xee, 0x65, 0x0d, 0x3a, 0x5e, 0x1a, 0x69, 0x02, 0xcb, 0xe2, 0x9b, 0x15, 0x7f, 0x2d, 0x57,
+	0xfc, 0xaf, 0x08, 0xa4, 0x9e, 0x27, 0x90, 0x2c, 0xd5, 0x8d, 0xf9, 0xa2, 0x69, 0xe6, 0x8b, 0x26,
+	0x88, 0x74, 0xe6, 0x97, 0x8d, 0x58, 0xc6, 0x2c, 0xff, 0x56, 0xad, 0xd5, 0xde, 0xea, 0xf0, 0xef,
+	0x26, 0xec, 0xd8, 0x04, 0x3c, 0xe5, 0x51, 0x20, 0xb9, 0x20, 0x3f, 0xc3, 0xce, 0xdc, 0x9f, 0x0b,
+	0x79, 0x2b, 0x07, 0xbf, 0xf2, 0xff, 0x1f, 0xc7, 0xbd, 0xc8, 0xc4, 0x00, 0xd4, 0xbd, 0x42, 0xbe,
+	0x80, 0x96, 0x81, 0x04, 0xe9, 0x17, 0x10, 0x5d, 0x82, 0x16, 0xe7, 0x46, 0xce, 0xc2, 0xae, 0xcc,
+	0x1c, 0x1d, 0xc3, 0xd6, 0x89, 0x14, 0xc8, 0x26, 0xff, 0x8b, 0xbb, 0x3b, 0x35, 0xf2, 0x00, 0x9a,
+	0x8f, 0x58, 0x18, 0x92, 0x6e, 0xce, 0x4c, 0x29, 0xec, 0xf6, 0xde, 0x82, 0x7e, 0x76, 0x97, 0x6f,
+	0x60, 0x2b, 0x3f, 0xe2, 0x93, 0x5b, 0x85, 0xbb, 0x2c, 0xfc, 0xc7, 0x39, 0x6f, 0x56, 0xae, 0xcf,
+	0x5c, 0xfe, 0x02, 0xbb, 0xf3, 0xa5, 0x4a, 0xdc, 0xe5, 0x5d, 0xc0, 0x79, 0x7b, 0x05, 0x9e, 0x70,
+	0xaf, 0x90, 0x5f, 0xa1, 0x57, 0xc1, 0x04, 0xe4, 0xbd, 0x0b, 0x3c, 0x14, 0xd9, 0xc2, 0xe9, 0x2e,
+	0xc0, 0xeb, 0xb1, 0xfa, 0xb1, 0x76, 0xaf, 0x1c, 0x1e, 0xc3, 0x76, 0xbe, 0x87, 0xaa, 0x71, 0x6e,
+	0x3d, 0xab, 0x15, 0x92, 0x7f, 0x87, 0x62, 0xfd, 0x54, 0x3b, 0x3c, 0x6d, 0x69, 0xcd, 0x87, 0xff,
+	0x0d, 0x00, 0x9a, 0x9d, 0xef, 0x9d, 0xe6, 0x0f, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1292,3 +1454,75 @@ var _ResourceMonitor_serviceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "resource.proto",
 }
+
+// ResourceHooksClient is the client API for ResourceHooks service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ResourceHooksClient interface {
+	RunHook(ctx context.Context, in *RunHookRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+}
+
+type resourceHooksClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewResourceHooksClient(cc grpc.ClientConnInterface) ResourceHooksClient {
+	return &resourceHooksClient{cc}
+}
+
+func (c *resourceHooksClient) RunHook(ctx context.Context, in *RunHookRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pulumirpc.ResourceHooks/RunHook", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ResourceHooksServer is the server API for ResourceHooks service.
+type ResourceHooksServer interface {
+	RunHook(context.Context, *RunHookRequest) (*empty.Empty, error)
+}
+
+// UnimplementedResourceHooksServer can be embedded to have forward compatible implementations.
+type UnimplementedResourceHooksServer struct {
+}
+
+func (*UnimplementedResourceHooksServer) RunHook(ctx context.Context, req *RunHookRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunHook not implemented")
+}
+
+func RegisterResourceHooksServer(s *grpc.Server, srv ResourceHooksServer) {
+	s.RegisterService(&_ResourceHooks_serviceDesc, srv)
+}
+
+func _ResourceHooks_RunHook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunHookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceHooksServer).RunHook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pulumirpc.ResourceHooks/RunHook",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceHooksServer).RunHook(ctx, req.(*RunHookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _ResourceHooks_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "pulumirpc.ResourceHooks",
+	HandlerType: (*ResourceHooksServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RunHook",
+			Handler:    _ResourceHooks_RunHook_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "resource.proto",
+}